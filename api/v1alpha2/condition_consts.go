@@ -21,6 +21,83 @@ const (
 	// an error while provisioning the cluster load balancer due to configuration not supported by the
 	// the remote server.
 	LoadBalancerProvisioningAbortedReason = "LoadBalancerProvisioningAbortedReason"
+
+	// LoadBalancerDeletionAbortedReason (Severity=Error) documents a LXCCluster controller detecting a non-retriable
+	// error while deleting the cluster load balancer, e.g. the external load balancer webhook rejecting the request,
+	// or its secret missing. Deletion of the cluster continues, and the load balancer may need to be cleaned up manually.
+	LoadBalancerDeletionAbortedReason = "LoadBalancerDeletionAborted"
+
+	// LoadBalancerBackendsSyncedCondition documents whether the backends of the cluster load balancer match the
+	// control plane instances of the cluster. The LXCCluster controller periodically compares the desired and
+	// the actual backends, and reconfigures the load balancer whenever they diverge.
+	LoadBalancerBackendsSyncedCondition clusterv1.ConditionType = "LoadBalancerBackendsSynced"
+
+	// LoadBalancerBackendsSyncFailedReason (Severity=Warning) documents a LXCCluster controller detecting
+	// an error while checking or updating the backends of the cluster load balancer; those kind of errors
+	// are usually transient and are automatically re-tried by the controller.
+	LoadBalancerBackendsSyncFailedReason = "LoadBalancerBackendsSyncFailed"
+
+	// LoadBalancerConfigInvalidReason (Severity=Error) documents a LXCCluster controller detecting
+	// that the rendered load balancer configuration was rejected by the load balancer (e.g. because of a
+	// broken custom haproxy configuration template). The live load balancer configuration is left untouched.
+	LoadBalancerConfigInvalidReason = "LoadBalancerConfigInvalid"
+
+	// LoadBalancerReloadFailedReason (Severity=Warning) documents a LXCCluster controller detecting
+	// an error while reloading the load balancer configuration. The previous load balancer configuration
+	// has been restored.
+	LoadBalancerReloadFailedReason = "LoadBalancerReloadFailed"
+
+	// LoadBalancerHandoverCondition documents the handover of the control plane endpoint from the active load balancer
+	// to a new one, after the load balancer type of the LXCCluster was changed. The condition is true once the new
+	// load balancer is healthy and the resources of the previous load balancer have been deleted.
+	LoadBalancerHandoverCondition clusterv1.ConditionType = "LoadBalancerHandover"
+
+	// LoadBalancerHandoverInProgressReason (Severity=Info) documents a LXCCluster controller waiting for the new
+	// load balancer to become healthy before deleting the previous load balancer.
+	LoadBalancerHandoverInProgressReason = "LoadBalancerHandoverInProgress"
+
+	// LoadBalancerHandoverFailedReason (Severity=Warning) documents a LXCCluster controller detecting an error
+	// during the load balancer handover; those kind of errors are usually transient and are automatically re-tried
+	// by the controller. The previous load balancer keeps serving the control plane endpoint.
+	LoadBalancerHandoverFailedReason = "LoadBalancerHandoverFailed"
+
+	// LoadBalancerHandoverAbortedReason (Severity=Error) documents a LXCCluster controller detecting that the new
+	// load balancer cannot take over the control plane endpoint (e.g. because it does not serve the endpoint address).
+	// The previous load balancer keeps serving the control plane endpoint.
+	LoadBalancerHandoverAbortedReason = "LoadBalancerHandoverAborted"
+
+	// ControlPlaneEndpointDNSRegisteredCondition documents whether the DNS name of the control plane endpoint resolves
+	// to the load balancer addresses. It is only set if the LXCCluster has a control plane endpoint DNS configuration.
+	ControlPlaneEndpointDNSRegisteredCondition clusterv1.ConditionType = "ControlPlaneEndpointDNSRegistered"
+
+	// ControlPlaneEndpointDNSRegistrationFailedReason (Severity=Warning) documents a LXCCluster controller detecting
+	// an error while registering the DNS records of the control plane endpoint; those kind of errors are usually
+	// transient and are automatically re-tried by the controller.
+	ControlPlaneEndpointDNSRegistrationFailedReason = "ControlPlaneEndpointDNSRegistrationFailed"
+
+	// ControlPlaneEndpointDNSRegistrationAbortedReason (Severity=Error) documents a LXCCluster controller detecting
+	// a terminal error while registering the DNS records of the control plane endpoint (e.g. because the server
+	// does not support network zones).
+	ControlPlaneEndpointDNSRegistrationAbortedReason = "ControlPlaneEndpointDNSRegistrationAborted"
+
+	// ControlPlaneEndpointDNSDeregistrationAbortedReason (Severity=Error) documents a LXCCluster controller detecting
+	// a non-retriable error while deleting the DNS records of the control plane endpoint, e.g. the TSIG secret missing.
+	// Deletion of the cluster continues, and the DNS records may need to be cleaned up manually.
+	ControlPlaneEndpointDNSDeregistrationAbortedReason = "ControlPlaneEndpointDNSDeregistrationAborted"
+
+	// ImagesAvailableCondition documents whether the images for Kubernetes versions that are about to be rolled out
+	// (e.g. after a version upgrade) are available on their image servers. It does not affect the Ready condition.
+	ImagesAvailableCondition clusterv1.ConditionType = "ImagesAvailable"
+
+	// ImageNotAvailableReason (Severity=Warning) documents a LXCCluster controller detecting that the image for
+	// a Kubernetes version that is about to be rolled out is not available. Machines with that version will fail
+	// to provision until the image is published or the version is changed.
+	ImageNotAvailableReason = "ImageNotAvailable"
+
+	// ImageCheckFailedReason (Severity=Warning) documents a LXCCluster controller detecting an error while
+	// checking the availability of images; those kind of errors are usually transient and checks are
+	// automatically re-tried by the controller.
+	ImageCheckFailedReason = "ImageCheckFailed"
 )
 
 // Conditions and condition Reasons for the LXCMachine object.
@@ -43,6 +120,11 @@ const (
 	// script to be ready before starting to create the instance that provides the LXCMachine infrastructure.
 	WaitingForBootstrapDataReason = "WaitingForBootstrapData"
 
+	// BootstrapDataInvalidReason (Severity=Error) documents a LXCMachine controller detecting that the bootstrap
+	// data of the machine is invalid (e.g. unknown format, malformed YAML or JSON), or cannot be delivered to the
+	// instance. The instance is not created until the bootstrap data is fixed.
+	BootstrapDataInvalidReason = "BootstrapDataInvalid"
+
 	// CreatingInstanceReason (Severity=Info) documents a LXCMachine waiting for the instance that
 	// provides the LXCMachine infrastructure to be created.
 	CreatingInstanceReason = "CreatingInstance"
@@ -60,3 +142,19 @@ const (
 	// the underlying instance has been deleted unexpectedly.
 	InstanceDeletedReason = "InstanceDeleted"
 )
+
+// Conditions and condition Reasons for the LXCImage object.
+
+const (
+	// ImagePulledCondition documents whether the image of a LXCImage is available on all target cluster members.
+	ImagePulledCondition clusterv1.ConditionType = "ImagePulled"
+
+	// ImagePullFailedReason (Severity=Warning) documents a LXCImage controller detecting an error while pulling
+	// the image on one or more cluster members; those kind of errors are usually transient and failed pulls are
+	// automatically re-tried by the controller.
+	ImagePullFailedReason = "ImagePullFailed"
+
+	// ImagePullAbortedReason (Severity=Error) documents a LXCImage controller detecting a terminal error while
+	// pulling the image (e.g. an unknown image name, or no matching cluster members).
+	ImagePullAbortedReason = "ImagePullAborted"
+)
@@ -44,6 +44,16 @@ type LXCClusterSpec struct {
 	// LoadBalancer is configuration for provisioning the load balancer of the cluster.
 	LoadBalancer LXCClusterLoadBalancer `json:"loadBalancer"`
 
+	// ControlPlaneEndpointDNS registers a DNS name for the control plane endpoint, which resolves to the load balancer
+	// addresses. The DNS name is used as the control plane endpoint host, such that the load balancer addresses can
+	// change without re-issuing certificates and kubeconfig files.
+	//
+	// ControlPlaneEndpointDNS is only supported for the "lxc" and "oci" load balancer types, and the "ovn" load
+	// balancer type with a listen address.
+	//
+	// +optional
+	ControlPlaneEndpointDNS *LXCClusterControlPlaneEndpointDNS `json:"controlPlaneEndpointDNS,omitempty"`
+
 	// Unprivileged will launch unprivileged LXC containers for the cluster machines.
 	//
 	// Known limitations apply for unprivileged LXC containers (e.g. cannot use NFS volumes).
@@ -63,6 +73,33 @@ type LXCClusterSpec struct {
 	// +optional
 	SkipDefaultKubeadmProfile bool `json:"skipDefaultKubeadmProfile"`
 
+	// MachineNaming configures the instance names of new cluster machines. The instance name of existing machines
+	// is not changed. If not set, the instance name is the name of the LXCMachine.
+	//
+	// +optional
+	MachineNaming *LXCClusterMachineNaming `json:"machineNaming,omitempty"`
+
+	// ProviderIDFormat is the format of the provider ID of new cluster machines. The provider ID of existing
+	// machines is not changed. Supported values are:
+	//
+	//   - "Legacy" (default): "lxc:///<instance>"
+	//   - "Project": "lxc://<project>/<instance>"
+	//   - "ProjectMember": "lxc://<project>/<member>/<instance>", where the member is omitted on standalone servers
+	//
+	// The legacy kubelet provider ID "lxc:///{{ v1.local_hostname }}" in the bootstrap data of new machines is
+	// rewritten to match the format, see https://capn.linuxcontainers.org/reference/provider-id.html
+	//
+	// +optional
+	ProviderIDFormat ProviderIDFormat `json:"providerIDFormat,omitempty"`
+
+	// Registry configures container image registry mirrors for the cluster. Mirrors are configured for containerd on
+	// the cluster machines, and are also used to pull OCI images for kind instances and OCI load balancers.
+	//
+	// Registry is applied when instances are launched, so changes only affect new machines.
+	//
+	// +optional
+	Registry *LXCClusterRegistry `json:"registry,omitempty"`
+
 	// TODO(neoaggelos): enable failure domains
 	// FailureDomains clusterv1.FailureDomains `json:"failureDomains,omitempty"`
 }
@@ -73,6 +110,154 @@ type SecretRef struct {
 	Name string `json:"name"`
 }
 
+// LXCClusterMachineNaming configures the instance names of cluster machines.
+//
+// Instance names longer than 63 characters are truncated, and a hash of the full name is appended to keep them unique.
+type LXCClusterMachineNaming struct {
+	// Template is a Go template for the instance name. The following fields are available:
+	//
+	//   - `.MachineName`: the name of the LXCMachine
+	//   - `.ClusterName`: the name of the Cluster
+	//   - `.Namespace`: the namespace of the Cluster
+	//   - `.NamespaceHash`: the first 5 characters of the hex-encoded sha256 sum of the namespace
+	//
+	// If empty, defaults to "{{ .MachineName }}".
+	//
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// NamespaceHashPrefix prepends "<namespacehash>-" to the instance name, such that machines with the same name in
+	// different namespaces do not collide.
+	//
+	// +optional
+	NamespaceHashPrefix bool `json:"namespaceHashPrefix,omitempty"`
+}
+
+// LXCClusterRegistry configures container image registries for the cluster.
+type LXCClusterRegistry struct {
+	// Mirrors configures mirrors for container image registries.
+	//
+	// +listType=map
+	// +listMapKey=registry
+	// +optional
+	Mirrors []LXCClusterRegistryMirror `json:"mirrors,omitempty"`
+}
+
+// LXCClusterRegistryMirror configures the mirrors of a container image registry.
+//
+// Mirrors are configured on the cluster machines as a containerd hosts.toml file, at
+// "/etc/containerd/certs.d/<registry>/hosts.toml".
+type LXCClusterRegistryMirror struct {
+	// Registry is the registry that is mirrored, e.g. "docker.io" or "registry.k8s.io". Use "_default" to configure
+	// mirrors for all registries that do not have their own configuration.
+	//
+	// Registry must be a registry host with an optional port, e.g. "10.0.0.10:5000", or "_default".
+	//
+	// +kubebuilder:validation:MinLength=1
+	Registry string `json:"registry"`
+
+	// Endpoints are the URLs of the mirrors, in order of preference, e.g. "https://mirror.example.com:5000".
+	//
+	// The first endpoint is also used to pull OCI images for kind instances and OCI load balancers. Note that these
+	// are pulled by the Incus server, which does not use the CA bundle and credentials of the mirror.
+	//
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []string `json:"endpoints"`
+
+	// CABundle is a PEM encoded CA bundle that is used to verify the certificates of the mirrors.
+	//
+	// +optional
+	CABundle string `json:"caBundle,omitempty"`
+
+	// InsecureSkipVerify disables verification of the certificates of the mirrors.
+	//
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// AuthSecretRef references a secret with keys "username" and "password", which are used to authenticate to
+	// the mirrors with HTTP basic authentication.
+	//
+	// Note that the credentials are stored in plain text in the hosts.toml file of the cluster machines, which is
+	// readable by any user of the machines. Use credentials with read-only access to the mirrors.
+	//
+	// +optional
+	AuthSecretRef *SecretRef `json:"authSecretRef,omitempty"`
+}
+
+// ControlPlaneEndpointDNSProvider is the method used to register the DNS records of the control plane endpoint.
+//
+// +kubebuilder:validation:Enum=NetworkZone;RFC2136
+type ControlPlaneEndpointDNSProvider string
+
+const (
+	// ControlPlaneEndpointDNSProviderNetworkZone registers DNS records in an Incus network zone.
+	ControlPlaneEndpointDNSProviderNetworkZone ControlPlaneEndpointDNSProvider = "NetworkZone"
+
+	// ControlPlaneEndpointDNSProviderRFC2136 registers DNS records with RFC 2136 dynamic updates.
+	ControlPlaneEndpointDNSProviderRFC2136 ControlPlaneEndpointDNSProvider = "RFC2136"
+)
+
+// LXCClusterControlPlaneEndpointDNS is configuration for registering a DNS name for the control plane endpoint.
+//
+// +kubebuilder:validation:XValidation:rule="self.provider != 'RFC2136' || has(self.rfc2136)",message="rfc2136 is required when provider is RFC2136"
+type LXCClusterControlPlaneEndpointDNS struct {
+	// Name is the fully qualified DNS name of the control plane endpoint, e.g. "c1.k8s.example.com".
+	// It must be a subdomain of Zone.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Zone is the DNS zone of Name, e.g. "k8s.example.com".
+	//
+	// For the "NetworkZone" provider, this is the name of the Incus network zone.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Zone string `json:"zone"`
+
+	// TTL is the time to live of the DNS records, in seconds. Defaults to 60.
+	//
+	// +kubebuilder:default=60
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TTL int32 `json:"ttl,omitempty"`
+
+	// Provider is the method used to register the DNS records. It is one of "NetworkZone" or "RFC2136".
+	//
+	// "NetworkZone" registers the DNS records in an Incus network zone on the same server as the cluster.
+	// Requires server extensions: `network_dns_records`
+	//
+	// "RFC2136" sends dynamic updates to a DNS server (e.g. BIND, PowerDNS). See the .rfc2136 field.
+	Provider ControlPlaneEndpointDNSProvider `json:"provider"`
+
+	// RFC2136 is configuration for the "RFC2136" provider.
+	//
+	// +optional
+	RFC2136 *LXCClusterDNSRFC2136 `json:"rfc2136,omitempty"`
+}
+
+// LXCClusterDNSRFC2136 is configuration for registering DNS records with RFC 2136 dynamic updates.
+type LXCClusterDNSRFC2136 struct {
+	// Server is the address of the DNS server, in host:port format, e.g. "10.0.0.53:53".
+	//
+	// +kubebuilder:validation:MinLength=1
+	Server string `json:"server"`
+
+	// Protocol is the transport used to send updates. It is one of "udp" or "tcp". Defaults to "udp".
+	//
+	// +kubebuilder:validation:Enum=udp;tcp
+	// +kubebuilder:default=udp
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+
+	// TSIGSecretRef references a secret with the TSIG key that is used to sign updates. The secret must have
+	// keys "name" (the key name), "secret" (the base64-encoded key) and optionally "algorithm" (default "hmac-sha256").
+	//
+	// If not set, updates are not signed.
+	//
+	// +optional
+	TSIGSecretRef *SecretRef `json:"tsigSecretRef,omitempty"`
+}
+
 // LXCClusterLoadBalancer is configuration for provisioning the load balancer of the cluster.
 //
 // +kubebuilder:validation:MaxProperties:=1
@@ -139,21 +324,96 @@ type LXCLoadBalancerInstance struct {
 	InstanceSpec LXCLoadBalancerMachineSpec `json:"instanceSpec,omitempty"`
 
 	// CustomHAProxyConfigTemplate allows you to replace the default HAProxy config file content.
-	// Please use it with caution, as there are no checks to ensure the validity of the configuration.
+	// The template is validated on admission (if the admission webhooks are enabled), and the rendered
+	// configuration is validated with "haproxy -c" before it is applied. Please use it with caution.
+	//
+	// See https://capn.linuxcontainers.org/reference/haproxy-template-data.html for the available template data and functions.
 	// +optional
 	CustomHAProxyConfigTemplate string `json:"customHAProxyConfigTemplate,omitempty"`
+
+	// SharedLoadBalancerRef references a LXCSharedLoadBalancer to use for the cluster, instead of provisioning
+	// a dedicated load balancer instance. The LXCSharedLoadBalancer must be of the same type ("lxc" or "oci").
+	//
+	// When set, InstanceSpec and CustomHAProxyConfigTemplate are ignored.
+	//
+	// +optional
+	SharedLoadBalancerRef *LXCSharedLoadBalancerRef `json:"sharedLoadBalancerRef,omitempty"`
+}
+
+// LXCSharedLoadBalancerRef is a reference to a LXCSharedLoadBalancer.
+type LXCSharedLoadBalancerRef struct {
+	// Name is the name of the LXCSharedLoadBalancer.
+	Name string `json:"name"`
 }
 
 type LXCLoadBalancerOVN struct {
 	// NetworkName is the name of the network to create the load balancer.
 	NetworkName string `json:"networkName,omitempty"`
+
+	// ListenAddress is the listen address of the network load balancer. If not set, the control plane endpoint
+	// host is used.
+	//
+	// ListenAddress is required when using ControlPlaneEndpointDNS. It can also be used to switch an existing
+	// cluster that uses ControlPlaneEndpointDNS to a network load balancer with a new address.
+	//
+	// +optional
+	ListenAddress string `json:"listenAddress,omitempty"`
 }
 
 type LXCLoadBalancerExternal struct {
+	// Webhook configures an HTTP endpoint that is notified with the list of control plane backends when the
+	// load balancer is created, reconfigured or deleted. This can be used to integrate with existing load balancer
+	// automation, e.g. to register and deregister API servers as control plane machines are added or removed.
+	//
+	// +optional
+	Webhook *LXCLoadBalancerExternalWebhook `json:"webhook,omitempty"`
+}
+
+// LXCLoadBalancerExternalWebhook is configuration for notifying an external load balancer.
+//
+// See https://capn.linuxcontainers.org/explanation/load-balancer.html for details on the request format.
+type LXCLoadBalancerExternalWebhook struct {
+	// URL is the HTTP endpoint. Requests are sent with the POST method and a JSON body.
+	//
+	// +kubebuilder:validation:Pattern:=`^https?://`
+	URL string `json:"url"`
+
+	// SecretRef references a secret with a "secret" key. If set, requests are signed with HMAC-SHA256 using the
+	// secret, and the signature is sent in the "X-Capn-Signature-256" header.
+	//
+	// +optional
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+
+	// CABundle is a PEM encoded CA bundle used to validate the certificate of the HTTP endpoint.
+	// If not set, the system trust store is used.
+	//
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// InsecureSkipVerify disables validation of the certificate of the HTTP endpoint.
+	//
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// TimeoutSeconds is the timeout for each request.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=10
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+
+	// MaxRetries is the number of times a failed request is retried, with exponential backoff.
+	// Requests are retried on network errors, and on 408, 429 and 5xx responses.
+	//
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=10
+	// +kubebuilder:default:=3
+	// +optional
+	MaxRetries int32 `json:"maxRetries,omitempty"`
 }
 
 type LXCLoadBalancerKubeVIP struct {
-	// Image is the kube-vip image to use. If not set, this is ghcr.io/kube-vip/kube-vip:v0.6.4
+	// Image is the kube-vip image to use. If not set, this is ghcr.io/kube-vip/kube-vip:v0.8.9
 	//
 	// +optional
 	Image string `json:"image,omitempty"`
@@ -178,6 +438,77 @@ type LXCLoadBalancerKubeVIP struct {
 	//
 	// +optional
 	ManifestPath string `json:"manifestPath,omitempty"`
+
+	// BGP configures kube-vip to advertise the VIP to BGP peers, instead of using ARP.
+	//
+	// +optional
+	BGP *LXCLoadBalancerKubeVIPBGP `json:"bgp,omitempty"`
+
+	// Services configures kube-vip to also serve Services of type LoadBalancer, with addresses
+	// allocated from an address range by the kube-vip cloud provider.
+	//
+	// +optional
+	Services *LXCLoadBalancerKubeVIPServices `json:"services,omitempty"`
+
+	// CustomManifestTemplate allows you to replace the default kube-vip static pod manifest.
+	// The template is validated on admission, if the admission webhooks are enabled. Please use it with caution.
+	//
+	// See https://capn.linuxcontainers.org/explanation/load-balancer.html for the available template data.
+	//
+	// +optional
+	CustomManifestTemplate string `json:"customManifestTemplate,omitempty"`
+}
+
+// LXCLoadBalancerKubeVIPBGP is BGP configuration for kube-vip.
+type LXCLoadBalancerKubeVIPBGP struct {
+	// AS is the local AS number.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	AS uint32 `json:"as"`
+
+	// RouterID is the BGP router ID. If not set, kube-vip uses the address of the node.
+	//
+	// +optional
+	RouterID string `json:"routerID,omitempty"`
+
+	// SourceInterface is the interface used to connect to BGP peers. If not set, the default interface is used.
+	//
+	// +optional
+	SourceInterface string `json:"sourceInterface,omitempty"`
+
+	// Peers is the list of BGP peers.
+	//
+	// +kubebuilder:validation:MinItems:=1
+	Peers []LXCLoadBalancerKubeVIPBGPPeer `json:"peers"`
+}
+
+// LXCLoadBalancerKubeVIPBGPPeer is a kube-vip BGP peer.
+type LXCLoadBalancerKubeVIPBGPPeer struct {
+	// Address is the address of the BGP peer.
+	Address string `json:"address"`
+
+	// AS is the AS number of the BGP peer.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	AS uint32 `json:"as"`
+
+	// Multihop enables eBGP multihop for the BGP peer.
+	//
+	// +optional
+	Multihop bool `json:"multihop,omitempty"`
+}
+
+// LXCLoadBalancerKubeVIPServices is configuration for kube-vip Services of type LoadBalancer.
+type LXCLoadBalancerKubeVIPServices struct {
+	// AddressRange is the range of addresses to allocate for Services of type LoadBalancer.
+	// It can be a range (e.g. "10.0.0.100-10.0.0.120") or a CIDR (e.g. "10.0.0.96/28").
+	AddressRange string `json:"addressRange"`
+
+	// CloudProviderImage is the kube-vip cloud provider image to use. If not set, this is
+	// ghcr.io/kube-vip/kube-vip-cloud-provider:v0.0.10
+	//
+	// +optional
+	CloudProviderImage string `json:"cloudProviderImage,omitempty"`
 }
 
 // LXCLoadBalancerMachineSpec is configuration for the container that will host the cluster load balancer, when using the "lxc" or "oci" load balancer type.
@@ -235,12 +566,90 @@ type LXCClusterStatus struct {
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
 
+	// LoadBalancer is the observed state of the cluster load balancer.
+	//
+	// +optional
+	LoadBalancer *LXCClusterLoadBalancerStatus `json:"loadBalancer,omitempty"`
+
+	// ControlPlaneEndpointDNSAddresses are the addresses that the DNS name of the control plane endpoint was last
+	// registered with. See .spec.controlPlaneEndpointDNS.
+	//
+	// +optional
+	ControlPlaneEndpointDNSAddresses []string `json:"controlPlaneEndpointDNSAddresses,omitempty"`
+
 	// V1Beta2 groups all status fields that will be added in LXCCluster's status with the v1beta2 version.
 	//
 	// +optional
 	V1Beta2 *LXCClusterV1Beta2Status `json:"v1beta2,omitempty"`
 }
 
+// LXCClusterLoadBalancerStatus is the observed state of the cluster load balancer.
+type LXCClusterLoadBalancerStatus struct {
+	// Backends is the list of backends of the cluster load balancer, along with their health.
+	//
+	// Backend health is retrieved from the haproxy stats frontend (port 8404 on localhost, from inside the load
+	// balancer instance) for the "lxc" load balancer type, and from the network load balancer state for the "ovn"
+	// load balancer type. Backends are not reported for the "oci", "kube-vip" and "external" load balancer types.
+	//
+	// +optional
+	Backends []LXCLoadBalancerBackendStatus `json:"backends,omitempty"`
+
+	// HealthyBackends is a summary of the number of healthy backends, e.g. "2/3".
+	//
+	// +optional
+	HealthyBackends string `json:"healthyBackends,omitempty"`
+
+	// LastReconfigureTime is the last time that the load balancer was successfully reconfigured by the LXCCluster controller.
+	//
+	// +optional
+	LastReconfigureTime *metav1.Time `json:"lastReconfigureTime,omitempty"`
+
+	// WebhookBackendsHash is a hash of the control plane backends that were last sent to the webhook of the
+	// "external" load balancer type. The webhook is notified again when the control plane backends no longer match.
+	//
+	// +optional
+	WebhookBackendsHash string `json:"webhookBackendsHash,omitempty"`
+
+	// ActiveSpec is the load balancer configuration that currently serves the control plane endpoint.
+	//
+	// When .spec.loadBalancer is changed to a different load balancer type, the LXCCluster controller creates
+	// the new load balancer, waits for it to become healthy, and then deletes the resources of the load balancer
+	// described by ActiveSpec. See the LoadBalancerHandover condition for the progress of the handover.
+	//
+	// +optional
+	ActiveSpec *LXCClusterLoadBalancer `json:"activeSpec,omitempty"`
+}
+
+// LXCLoadBalancerBackendHealth is the health of a load balancer backend.
+type LXCLoadBalancerBackendHealth string
+
+const (
+	// LoadBalancerBackendHealthy is a backend that passes the load balancer health checks.
+	LoadBalancerBackendHealthy LXCLoadBalancerBackendHealth = "Healthy"
+
+	// LoadBalancerBackendUnhealthy is a backend that fails the load balancer health checks.
+	LoadBalancerBackendUnhealthy LXCLoadBalancerBackendHealth = "Unhealthy"
+
+	// LoadBalancerBackendUnknown is a backend whose health could not be determined.
+	LoadBalancerBackendUnknown LXCLoadBalancerBackendHealth = "Unknown"
+)
+
+// LXCLoadBalancerBackendStatus is the observed state of a load balancer backend.
+type LXCLoadBalancerBackendStatus struct {
+	// Name is the name of the backend. This matches the name of the control plane instance.
+	Name string `json:"name"`
+
+	// Address is the address of the backend.
+	//
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Health is the health of the backend, as reported by the load balancer.
+	//
+	// +kubebuilder:validation:Enum:=Healthy;Unhealthy;Unknown
+	Health LXCLoadBalancerBackendHealth `json:"health"`
+}
+
 // LXCClusterV1Beta2Status groups all the fields that will be added or modified in LXCCluster with the V1Beta2 version.
 // See https://github.com/kubernetes-sigs/cluster-api/blob/main/docs/proposals/20240916-improve-status-in-CAPI-resources.md for more context.
 type LXCClusterV1Beta2Status struct {
@@ -257,6 +666,7 @@ type LXCClusterV1Beta2Status struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".metadata.labels.cluster\\.x-k8s\\.io/cluster-name",description="Cluster"
 // +kubebuilder:printcolumn:name="Load Balancer",type="string",JSONPath=".spec.controlPlaneEndpoint.host",description="Load Balancer address"
+// +kubebuilder:printcolumn:name="Backends",type="string",JSONPath=".status.loadBalancer.healthyBackends",description="Healthy load balancer backends"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Cluster infrastructure is ready"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of LXCCluster"
 // +kubebuilder:resource:categories=cluster-api
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// ImageFinalizer allows LXCImageReconciler to clean up resources associated with LXCImage before removing it
+	// from the apiserver.
+	ImageFinalizer = "lxcimage.infrastructure.cluster.x-k8s.io"
+)
+
+// LXCImageSpec defines the desired state of LXCImage.
+type LXCImageSpec struct {
+	// SecretRef references a secret with credentials to access the LXC (e.g. Incus, LXD) server.
+	SecretRef NamespacedSecretRef `json:"secretRef"`
+
+	// Image is the image to pull. It is resolved the same way as the image of a LXCMachine, e.g.
+	// "capi:kubeadm/v1.33.0" or "ubuntu:24.04". Image names must not contain `VERSION` or templates.
+	Image LXCMachineImageSource `json:"image"`
+
+	// InstanceType is the type of instances that use the image, `container` or `virtual-machine`. Empty defaults
+	// to `container`.
+	//
+	// InstanceType may also be set to `kind`, in which case the image is an OCI image, e.g. "kind:v1.33.0".
+	//
+	// +kubebuilder:validation:Enum:=container;virtual-machine;kind;""
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// Targets is the list of cluster members to pull the image on. Cluster groups may be specified
+	// as "@group". If empty, the image is pulled on all cluster members. Ignored if the server is not clustered.
+	//
+	// +optional
+	Targets []string `json:"targets,omitempty"`
+
+	// RefreshInterval is the interval at which the image is pulled again, e.g. to pick up new versions of an image
+	// alias. Older versions of the image that were pulled by the LXCImage are deleted after a refresh. Set to "0s" to
+	// disable periodic refreshes. Defaults to 24h.
+	//
+	// +kubebuilder:default:="24h"
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// LXCImageStatus defines the observed state of LXCImage.
+type LXCImageStatus struct {
+	// Ready denotes that the image is available on all target cluster members.
+	//
+	// +optional
+	Ready bool `json:"ready"`
+
+	// ObservedGeneration is the generation of the LXCImage spec that the image was last pulled for.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Members is the status of the image on each target cluster member.
+	//
+	// +optional
+	Members []LXCImageMemberStatus `json:"members,omitempty"`
+
+	// LastRefreshTime is the time that the image was last pulled on all target cluster members.
+	//
+	// +optional
+	LastRefreshTime *metav1.Time `json:"lastRefreshTime,omitempty"`
+
+	// Conditions defines current service state of the LXCImage.
+	//
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// LXCImageMemberStatus is the status of an image on a cluster member.
+type LXCImageMemberStatus struct {
+	// Name is the name of the cluster member. For servers that are not clustered, this is the server name.
+	Name string `json:"name"`
+
+	// Ready is true if the image is available on the cluster member.
+	//
+	// +optional
+	Ready bool `json:"ready"`
+
+	// Fingerprint is the fingerprint of the image on the cluster member.
+	//
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Managed is true if the image was downloaded by the LXCImage. Images that already existed on the server
+	// before they were pulled are not deleted when the LXCImage is refreshed or deleted.
+	//
+	// +optional
+	Managed bool `json:"managed,omitempty"`
+
+	// Architecture is the architecture of the image on the cluster member.
+	//
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// LastPullTime is the time that the image was last pulled on the cluster member.
+	//
+	// +optional
+	LastPullTime *metav1.Time `json:"lastPullTime,omitempty"`
+
+	// Message describes the error, if the image could not be pulled on the cluster member.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=cluster-api
+// +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".spec.image.name",description="Image name"
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.instanceType",description="Instance type"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Image is available on all target cluster members"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of LXCImage"
+
+// LXCImage is the Schema for the lxcimages API.
+//
+// LXCImage is an image that is pulled ahead of time on the cluster members of an LXC server, such that launching
+// instances does not have to wait for the image download.
+type LXCImage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LXCImageSpec   `json:"spec,omitempty"`
+	Status LXCImageStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (c *LXCImage) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (c *LXCImage) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
+// GetLXCSecretNamespacedName returns the client.ObjectKey for the secret containing LXC credentials.
+func (c *LXCImage) GetLXCSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: c.Spec.SecretRef.Namespace,
+		Name:      c.Spec.SecretRef.Name,
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// LXCImageList contains a list of LXCImage.
+type LXCImageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LXCImage `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LXCImage{}, &LXCImageList{})
+}
@@ -36,7 +36,8 @@ const (
 
 // LXCMachineSpec defines the desired state of LXCMachine.
 type LXCMachineSpec struct {
-	// ProviderID is the container name in ProviderID format (lxc:///<containername>).
+	// ProviderID is the instance in ProviderID format, e.g. "lxc:///<instance>" or "lxc://<project>/<instance>".
+	// See LXCCluster.spec.providerIDFormat for the supported formats.
 	//
 	// +optional
 	ProviderID *string `json:"providerID,omitempty"`
@@ -50,6 +51,16 @@ type LXCMachineSpec struct {
 	// +optional
 	InstanceType string `json:"instanceType,omitempty"`
 
+	// Architecture is the CPU architecture of the instance, in Kubernetes format (e.g. "amd64", "arm64").
+	//
+	// On clustered servers, instances are placed on cluster members with a matching architecture (and within Target,
+	// if set). The image must be available for the architecture. If empty, the instance uses the architecture of the
+	// cluster member it is placed on.
+	//
+	// +kubebuilder:validation:Enum:=amd64;arm64;386;arm;ppc64le;s390x;riscv64;""
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
 	// Flavor is configuration for the instance size (e.g. t3.micro, or c2-m4).
 	//
 	// Examples:
@@ -62,6 +73,9 @@ type LXCMachineSpec struct {
 
 	// Profiles is a list of profiles to attach to the instance.
 	//
+	// Profile names may use Go templates if renderTemplates is set, e.g. `k8s-{{ .Role }}`. See the image name for
+	// available variables.
+	//
 	// +optional
 	Profiles []string `json:"profiles,omitempty"`
 
@@ -84,7 +98,8 @@ type LXCMachineSpec struct {
 	//
 	// Note that the provider will always set the following configuration keys:
 	//
-	// - `cloud-init.user-data`: cloud-init config data
+	// - `cloud-init.user-data`: cloud-init config data (for cloud-config bootstrap data)
+	// - `raw.qemu`: the Ignition config is appended (for ignition bootstrap data on virtual-machine instances)
 	// - `user.cluster-name`: name of owning cluster
 	// - `user.cluster-namespace`: namespace of owning cluster
 	// - `user.cluster-role`: instance role (e.g. control-plane, worker)
@@ -93,9 +108,21 @@ type LXCMachineSpec struct {
 	// See https://linuxcontainers.org/incus/docs/main/reference/instance_options/#instance-options
 	// for details.
 	//
+	// Config values may use Go templates if renderTemplates is set, e.g. `{{ .ClusterName }}`. See the image name
+	// for available variables. Values of `cloud-init.*`, `raw.*` and the legacy `user.user-data`, `user.vendor-data`,
+	// `user.meta-data` and `user.network-config` keys are not rendered, as they may contain cloud-init jinja templates.
+	//
 	// +optional
 	Config map[string]string `json:"config,omitempty"`
 
+	// RenderTemplates enables Go templates in the config values and profile names of the machine. It is disabled by
+	// default, such that values containing a literal "{{" are passed to the server as-is.
+	//
+	// The image name is always rendered.
+	//
+	// +optional
+	RenderTemplates bool `json:"renderTemplates,omitempty"`
+
 	// Image to use for provisioning the machine. If not set, a kubeadm image
 	// from the default upstream simplestreams source will be used, based on
 	// the version of the machine.
@@ -179,9 +206,16 @@ type LXCMachineImageSource struct {
 	//   - `capi:IMAGE` => `IMAGE` from https://d14dnvi2l3tc5t.cloudfront.net
 	//   - `capi-stg:IMAGE` => `IMAGE` from https://djapqxqu5n2qu.cloudfront.net
 	//
+	// Additional image prefixes can be configured with the `--image-prefixes-file` flag of the infrastructure provider.
+	//
 	// Any instances of `VERSION` in the image name will be replaced with the machine version.
 	// For example, to use debian based kubeadm images, you can set image name to "capi:kubeadm/VERSION/debian"
 	//
+	// The image name may also use Go templates, e.g. "images:ubuntu/24.04/{{ .Architecture }}". The available
+	// variables are `.Version` (e.g. "v1.33.0"), `.Major`, `.Minor` and `.Patch` (e.g. "1", "33" and "0"),
+	// `.Architecture` (e.g. "amd64"), `.InstanceType` (e.g. "container"), `.ClusterName`, `.ClusterNamespace`,
+	// `.MachineName` and `.Role` ("control-plane" or "worker").
+	//
 	// +optional
 	Name string `json:"name"`
 
@@ -212,6 +246,12 @@ type LXCMachineStatus struct {
 	// +optional
 	Ready bool `json:"ready,omitempty"`
 
+	// InstanceName is the name of the instance of the LXC machine. It is set before the instance is created, and
+	// does not change afterwards.
+	//
+	// +optional
+	InstanceName string `json:"instanceName,omitempty"`
+
 	// LoadBalancerConfigured will be set to true once for each control plane node, after the load balancer instance is reconfigured.
 	//
 	// +optional
@@ -222,6 +262,11 @@ type LXCMachineStatus struct {
 	// +optional
 	Addresses []clusterv1.MachineAddress `json:"addresses"`
 
+	// Instance describes the instance of the LXC machine. It is set after the instance is created.
+	//
+	// +optional
+	Instance *LXCMachineInstanceStatus `json:"instance,omitempty"`
+
 	// Conditions defines current service state of the LXCMachine.
 	//
 	// +optional
@@ -233,6 +278,51 @@ type LXCMachineStatus struct {
 	V1Beta2 *LXCMachineV1Beta2Status `json:"v1beta2,omitempty"`
 }
 
+// LXCMachineInstanceStatus describes the instance of a LXC machine.
+type LXCMachineInstanceStatus struct {
+	// ImageFingerprint is the fingerprint of the image that the instance was created from.
+	//
+	// +optional
+	ImageFingerprint string `json:"imageFingerprint,omitempty"`
+
+	// ImageAlias is the alias of the image that the instance was created from, e.g. "kubeadm/v1.33.0". Note that
+	// image aliases may point to newer images over time.
+	//
+	// +optional
+	ImageAlias string `json:"imageAlias,omitempty"`
+
+	// ImageServer is the server that the image was pulled from, e.g. "https://images.linuxcontainers.org". Empty
+	// for images that were not pulled from a remote server.
+	//
+	// +optional
+	ImageServer string `json:"imageServer,omitempty"`
+
+	// Location is the cluster member that the instance is running on. Empty for servers that are not clustered.
+	//
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Architecture is the architecture of the instance, e.g. "x86_64".
+	//
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// InstanceType is the type of the instance, "container" or "virtual-machine".
+	//
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// CreatedAt is the time that the instance was created.
+	//
+	// +optional
+	CreatedAt *metav1.Time `json:"createdAt,omitempty"`
+
+	// Status is the status of the instance, as reported by the server, e.g. "Running" or "Stopped".
+	//
+	// +optional
+	Status string `json:"status,omitempty"`
+}
+
 // LXCMachineV1Beta2Status groups all the fields that will be added or modified in LXCMachine with the V1Beta2 version.
 // See https://github.com/kubernetes-sigs/cluster-api/blob/main/docs/proposals/20240916-improve-status-in-CAPI-resources.md for more context.
 type LXCMachineV1Beta2Status struct {
@@ -289,13 +379,27 @@ func (c *LXCMachine) SetV1Beta2Conditions(conditions []metav1.Condition) {
 	c.Status.V1Beta2.Conditions = conditions
 }
 
+// GetInstanceName returns the name of the instance of the LXC machine. Machines that were created before the instance
+// name was recorded in the status use the LXCMachine name.
 func (c *LXCMachine) GetInstanceName() string {
+	if c.Status.InstanceName != "" {
+		return c.Status.InstanceName
+	}
 	return c.Name
 }
 
 // GetExpectedProviderID returns the expected providerID that the Kubernetes node should have.
-func (c *LXCMachine) GetExpectedProviderID() string {
-	return fmt.Sprintf("lxc:///%s", c.GetInstanceName())
+// The member is only used by the "ProjectMember" format, and is ignored if empty.
+func (c *LXCMachine) GetExpectedProviderID(format ProviderIDFormat, project string, member string) string {
+	id := ProviderID{Instance: c.GetInstanceName()}
+	switch format {
+	case ProviderIDFormatProject:
+		id.Project = project
+	case ProviderIDFormatProjectMember:
+		id.Project = project
+		id.Member = member
+	}
+	return id.String()
 }
 
 // +kubebuilder:object:root=true
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -26,7 +27,39 @@ type LXCMachineTemplateSpec struct {
 	Template LXCMachineTemplateResource `json:"template"`
 }
 
+// LXCMachineTemplateStatus defines the observed state of LXCMachineTemplate.
+type LXCMachineTemplateStatus struct {
+	// Capacity defines the resource capacity of machines created from this template. It is used by the
+	// cluster-autoscaler to scale MachineDeployments from zero. Capacity is resolved from the flavor, the
+	// limits.cpu and limits.memory config keys, and the instance profiles.
+	//
+	// +optional
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+
+	// NodeInfo is information about the nodes of machines created from this template.
+	//
+	// +optional
+	NodeInfo *NodeInfo `json:"nodeInfo,omitempty"`
+}
+
+// NodeInfo is information about the nodes of machines created from a template.
+type NodeInfo struct {
+	// Architecture is the CPU architecture of the node, in Kubernetes format (e.g. "amd64", "arm64").
+	//
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// OperatingSystem is the operating system of the node (e.g. "linux").
+	//
+	// +optional
+	OperatingSystem string `json:"operatingSystem,omitempty"`
+}
+
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CPU",type="string",JSONPath=".status.capacity.cpu",description="CPU capacity"
+// +kubebuilder:printcolumn:name="Memory",type="string",JSONPath=".status.capacity.memory",description="Memory capacity"
+// +kubebuilder:printcolumn:name="Architecture",type="string",JSONPath=".status.nodeInfo.architecture",description="Node architecture"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of LXCMachineTemplate"
 
 // LXCMachineTemplate is the Schema for the lxcmachinetemplates API.
@@ -34,7 +67,8 @@ type LXCMachineTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec LXCMachineTemplateSpec `json:"spec,omitempty"`
+	Spec   LXCMachineTemplateSpec   `json:"spec,omitempty"`
+	Status LXCMachineTemplateStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
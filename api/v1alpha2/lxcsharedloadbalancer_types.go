@@ -0,0 +1,220 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// SharedLoadBalancerFinalizer allows LXCSharedLoadBalancerReconciler to clean up resources associated with
+	// LXCSharedLoadBalancer before removing it from the apiserver.
+	SharedLoadBalancerFinalizer = "lxcsharedloadbalancer.infrastructure.cluster.x-k8s.io"
+)
+
+// LXCSharedLoadBalancerRoutingMode is how traffic is routed to the member clusters of a shared load balancer.
+type LXCSharedLoadBalancerRoutingMode string
+
+const (
+	// SharedLoadBalancerRoutingPort assigns a distinct frontend port to each member cluster.
+	SharedLoadBalancerRoutingPort LXCSharedLoadBalancerRoutingMode = "Port"
+
+	// SharedLoadBalancerRoutingSNI routes traffic to member clusters based on the TLS SNI server name,
+	// using TLS passthrough on a single frontend port.
+	SharedLoadBalancerRoutingSNI LXCSharedLoadBalancerRoutingMode = "SNI"
+)
+
+// LXCSharedLoadBalancerSpec defines the desired state of LXCSharedLoadBalancer.
+type LXCSharedLoadBalancerSpec struct {
+	// SecretRef references a secret with credentials to access the LXC (e.g. Incus, LXD) server.
+	//
+	// Member clusters must use credentials for the same server and project. The secret must be in the namespace
+	// configured with the --shared-load-balancer-secret-namespace flag of the controller manager (default "capn-system").
+	SecretRef NamespacedSecretRef `json:"secretRef"`
+
+	// Type is the type of the load balancer instance. Can be one of:
+	//
+	//   - "lxc": an LXC container running haproxy, similar to the "lxc" cluster load balancer type.
+	//   - "oci": an OCI container running haproxy, similar to the "oci" cluster load balancer type.
+	//
+	// Member clusters must use the same load balancer type. It cannot be changed after creation.
+	//
+	// +kubebuilder:validation:Enum:=lxc;oci
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="type is immutable"
+	// +kubebuilder:default:=lxc
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// InstanceSpec can be used to adjust the load balancer instance configuration.
+	//
+	// +optional
+	InstanceSpec LXCLoadBalancerMachineSpec `json:"instanceSpec,omitempty"`
+
+	// Routing configures how traffic is routed to the member clusters. It cannot be changed after creation.
+	//
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="routing is immutable"
+	// +optional
+	Routing LXCSharedLoadBalancerRouting `json:"routing,omitempty"`
+}
+
+// LXCSharedLoadBalancerRouting configures how traffic is routed to the member clusters of a shared load balancer.
+type LXCSharedLoadBalancerRouting struct {
+	// Mode is how traffic is routed to the member clusters. Can be one of:
+	//
+	//   - "Port": each member cluster is assigned a distinct frontend port from the range MinPort-MaxPort.
+	//     The port is set as the control plane endpoint port of the member cluster, unless already specified.
+	//   - "SNI": all member clusters share frontend port 6443, and traffic is routed based on the TLS SNI
+	//     server name (TLS passthrough). Member clusters must set their control plane endpoint host to a DNS
+	//     name that resolves to the address of the shared load balancer.
+	//
+	// +kubebuilder:validation:Enum:=Port;SNI
+	// +kubebuilder:default:=Port
+	// +optional
+	Mode LXCSharedLoadBalancerRoutingMode `json:"mode,omitempty"`
+
+	// MinPort is the first port that can be assigned to member clusters with the "Port" routing mode.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	// +kubebuilder:default:=16443
+	// +optional
+	MinPort int32 `json:"minPort,omitempty"`
+
+	// MaxPort is the last port that can be assigned to member clusters with the "Port" routing mode.
+	//
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	// +kubebuilder:default:=16542
+	// +optional
+	MaxPort int32 `json:"maxPort,omitempty"`
+}
+
+// NamespacedSecretRef is a reference to a secret in a specific namespace.
+type NamespacedSecretRef struct {
+	// Name is the name of the secret to use.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the secret to use.
+	Namespace string `json:"namespace"`
+}
+
+// LXCSharedLoadBalancerStatus defines the observed state of LXCSharedLoadBalancer.
+type LXCSharedLoadBalancerStatus struct {
+	// Ready denotes that the shared load balancer instance is provisioned.
+	//
+	// +optional
+	Ready bool `json:"ready"`
+
+	// Address is the address of the shared load balancer instance.
+	//
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Members is the list of clusters that are using the shared load balancer.
+	//
+	// +optional
+	Members []LXCSharedLoadBalancerMember `json:"members,omitempty"`
+
+	// Conditions defines current service state of the LXCSharedLoadBalancer.
+	//
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// LXCSharedLoadBalancerMember is a cluster that uses a shared load balancer.
+type LXCSharedLoadBalancerMember struct {
+	// ClusterName is the name of the member cluster.
+	ClusterName string `json:"clusterName"`
+
+	// ClusterNamespace is the namespace of the member cluster.
+	ClusterNamespace string `json:"clusterNamespace"`
+
+	// Port is the frontend port assigned to the member cluster, for the "Port" routing mode.
+	//
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// ServerName is the TLS SNI server name of the member cluster, for the "SNI" routing mode.
+	//
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=cluster-api
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="Load balancer type"
+// +kubebuilder:printcolumn:name="Routing",type="string",JSONPath=".spec.routing.mode",description="Routing mode"
+// +kubebuilder:printcolumn:name="Address",type="string",JSONPath=".status.address",description="Load balancer address"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.ready",description="Load balancer is ready"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time duration since creation of LXCSharedLoadBalancer"
+
+// LXCSharedLoadBalancer is the Schema for the lxcsharedloadbalancers API.
+//
+// LXCSharedLoadBalancer is a load balancer instance that is shared by multiple workload clusters.
+type LXCSharedLoadBalancer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LXCSharedLoadBalancerSpec   `json:"spec,omitempty"`
+	Status LXCSharedLoadBalancerStatus `json:"status,omitempty"`
+}
+
+// GetConditions returns the set of conditions for this object.
+func (c *LXCSharedLoadBalancer) GetConditions() clusterv1.Conditions {
+	return c.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (c *LXCSharedLoadBalancer) SetConditions(conditions clusterv1.Conditions) {
+	c.Status.Conditions = conditions
+}
+
+// GetLXCSecretNamespacedName returns the client.ObjectKey for the secret containing LXC credentials.
+func (c *LXCSharedLoadBalancer) GetLXCSecretNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: c.Spec.SecretRef.Namespace,
+		Name:      c.Spec.SecretRef.Name,
+	}
+}
+
+// GetInstanceName returns the instance name for the shared load balancer.
+func (c *LXCSharedLoadBalancer) GetInstanceName() string {
+	return SharedLoadBalancerInstanceName(c.Name)
+}
+
+// SharedLoadBalancerInstanceName returns the instance name for the shared load balancer with the specified name.
+func SharedLoadBalancerInstanceName(name string) string {
+	return fmt.Sprintf("%s-shared-lb", name)
+}
+
+// +kubebuilder:object:root=true
+
+// LXCSharedLoadBalancerList contains a list of LXCSharedLoadBalancer.
+type LXCSharedLoadBalancerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LXCSharedLoadBalancer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LXCSharedLoadBalancer{}, &LXCSharedLoadBalancerList{})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProviderIDFormat is the format of the provider ID of cluster machines.
+//
+// +kubebuilder:validation:Enum=Legacy;Project;ProjectMember
+type ProviderIDFormat string
+
+const (
+	// ProviderIDFormatLegacy is "lxc:///<instance>".
+	ProviderIDFormatLegacy ProviderIDFormat = "Legacy"
+
+	// ProviderIDFormatProject is "lxc://<project>/<instance>".
+	ProviderIDFormatProject ProviderIDFormat = "Project"
+
+	// ProviderIDFormatProjectMember is "lxc://<project>/<member>/<instance>".
+	ProviderIDFormatProjectMember ProviderIDFormat = "ProjectMember"
+)
+
+// providerIDScheme is the scheme of all provider IDs.
+const providerIDScheme = "lxc://"
+
+// ProviderID identifies the instance of a cluster machine.
+//
+// +kubebuilder:object:generate=false
+type ProviderID struct {
+	// Project is the project of the instance. Empty for legacy provider IDs.
+	Project string
+	// Member is the cluster member that hosted the instance when it was created, if known.
+	Member string
+	// Instance is the name of the instance.
+	Instance string
+}
+
+// String returns the provider ID in the most specific format that is possible with the known fields.
+func (p ProviderID) String() string {
+	switch {
+	case p.Project == "":
+		return fmt.Sprintf("%s/%s", providerIDScheme, p.Instance)
+	case p.Member == "":
+		return fmt.Sprintf("%s%s/%s", providerIDScheme, p.Project, p.Instance)
+	default:
+		return fmt.Sprintf("%s%s/%s/%s", providerIDScheme, p.Project, p.Member, p.Instance)
+	}
+}
+
+// IsLegacy returns true if the provider ID does not include the project of the instance.
+func (p ProviderID) IsLegacy() bool {
+	return p.Project == ""
+}
+
+// Matches returns true if both provider IDs refer to the same instance. Legacy provider IDs match instances with
+// the same name in any project. The member is ignored, as instances may be moved to a different member.
+func (p ProviderID) Matches(other ProviderID) bool {
+	if p.Instance != other.Instance {
+		return false
+	}
+	return p.IsLegacy() || other.IsLegacy() || p.Project == other.Project
+}
+
+// ParseProviderID parses a provider ID in any of the supported formats:
+//
+//   - "lxc:///<instance>"
+//   - "lxc://<project>/<instance>"
+//   - "lxc://<project>/<member>/<instance>"
+func ParseProviderID(providerID string) (ProviderID, error) {
+	rest, ok := strings.CutPrefix(providerID, providerIDScheme)
+	if !ok {
+		return ProviderID{}, fmt.Errorf("provider ID %q does not start with %q", providerID, providerIDScheme)
+	}
+
+	var parts []string
+	if legacy, ok := strings.CutPrefix(rest, "/"); ok {
+		if strings.Contains(legacy, "/") {
+			return ProviderID{}, fmt.Errorf("provider ID %q is not in the legacy format %q", providerID, "lxc:///<instance>")
+		}
+		parts = []string{"", legacy}
+	} else {
+		parts = strings.Split(rest, "/")
+	}
+
+	for _, part := range parts[1:] {
+		if part == "" {
+			return ProviderID{}, fmt.Errorf("provider ID %q has empty path segments", providerID)
+		}
+	}
+
+	switch len(parts) {
+	case 2:
+		return ProviderID{Project: parts[0], Instance: parts[1]}, nil
+	case 3:
+		return ProviderID{Project: parts[0], Member: parts[1], Instance: parts[2]}, nil
+	default:
+		return ProviderID{}, fmt.Errorf("provider ID %q is not in one of the formats %q, %q or %q", providerID, "lxc:///<instance>", "lxc://<project>/<instance>", "lxc://<project>/<member>/<instance>")
+	}
+}
@@ -0,0 +1,67 @@
+package v1alpha2
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseProviderID(t *testing.T) {
+	for _, tc := range []struct {
+		providerID  string
+		expect      ProviderID
+		expectError bool
+	}{
+		{providerID: "lxc:///c1-md-0-abcde", expect: ProviderID{Instance: "c1-md-0-abcde"}},
+		{providerID: "lxc://proj/c1-md-0-abcde", expect: ProviderID{Project: "proj", Instance: "c1-md-0-abcde"}},
+		{providerID: "lxc://proj/w01/c1-md-0-abcde", expect: ProviderID{Project: "proj", Member: "w01", Instance: "c1-md-0-abcde"}},
+		{providerID: "docker:///c1-md-0-abcde", expectError: true},
+		{providerID: "lxc:///", expectError: true},
+		{providerID: "lxc:///w01/c1-md-0-abcde", expectError: true},
+		{providerID: "lxc://proj", expectError: true},
+		{providerID: "lxc://proj/", expectError: true},
+		{providerID: "lxc://proj//c1-md-0-abcde", expectError: true},
+		{providerID: "lxc://proj/w01/c1-md-0-abcde/extra", expectError: true},
+	} {
+		t.Run(tc.providerID, func(t *testing.T) {
+			g := NewWithT(t)
+
+			providerID, err := ParseProviderID(tc.providerID)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(providerID).To(Equal(tc.expect))
+			g.Expect(providerID.String()).To(Equal(tc.providerID))
+		})
+	}
+}
+
+func TestProviderIDMatches(t *testing.T) {
+	g := NewWithT(t)
+
+	legacy := ProviderID{Instance: "c1"}
+	project := ProviderID{Project: "proj", Instance: "c1"}
+	member := ProviderID{Project: "proj", Member: "w01", Instance: "c1"}
+
+	g.Expect(legacy.Matches(project)).To(BeTrue())
+	g.Expect(project.Matches(legacy)).To(BeTrue())
+	g.Expect(project.Matches(member)).To(BeTrue())
+	g.Expect(member.Matches(ProviderID{Project: "proj", Member: "w02", Instance: "c1"})).To(BeTrue())
+	g.Expect(project.Matches(ProviderID{Project: "other", Instance: "c1"})).To(BeFalse())
+	g.Expect(legacy.Matches(ProviderID{Instance: "c2"})).To(BeFalse())
+}
+
+func TestGetExpectedProviderID(t *testing.T) {
+	g := NewWithT(t)
+
+	lxcMachine := &LXCMachine{ObjectMeta: metav1.ObjectMeta{Name: "c1"}}
+
+	g.Expect(lxcMachine.GetExpectedProviderID("", "proj", "w01")).To(Equal("lxc:///c1"))
+	g.Expect(lxcMachine.GetExpectedProviderID(ProviderIDFormatLegacy, "proj", "w01")).To(Equal("lxc:///c1"))
+	g.Expect(lxcMachine.GetExpectedProviderID(ProviderIDFormatProject, "proj", "w01")).To(Equal("lxc://proj/c1"))
+	g.Expect(lxcMachine.GetExpectedProviderID(ProviderIDFormatProjectMember, "proj", "w01")).To(Equal("lxc://proj/w01/c1"))
+	g.Expect(lxcMachine.GetExpectedProviderID(ProviderIDFormatProjectMember, "proj", "")).To(Equal("lxc://proj/c1"))
+}
@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/cluster-api/api/v1beta1"
@@ -72,6 +73,46 @@ func (in *LXCCluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCClusterControlPlaneEndpointDNS) DeepCopyInto(out *LXCClusterControlPlaneEndpointDNS) {
+	*out = *in
+	if in.RFC2136 != nil {
+		in, out := &in.RFC2136, &out.RFC2136
+		*out = new(LXCClusterDNSRFC2136)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCClusterControlPlaneEndpointDNS.
+func (in *LXCClusterControlPlaneEndpointDNS) DeepCopy() *LXCClusterControlPlaneEndpointDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCClusterControlPlaneEndpointDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCClusterDNSRFC2136) DeepCopyInto(out *LXCClusterDNSRFC2136) {
+	*out = *in
+	if in.TSIGSecretRef != nil {
+		in, out := &in.TSIGSecretRef, &out.TSIGSecretRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCClusterDNSRFC2136.
+func (in *LXCClusterDNSRFC2136) DeepCopy() *LXCClusterDNSRFC2136 {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCClusterDNSRFC2136)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LXCClusterList) DeepCopyInto(out *LXCClusterList) {
 	*out = *in
@@ -125,12 +166,12 @@ func (in *LXCClusterLoadBalancer) DeepCopyInto(out *LXCClusterLoadBalancer) {
 	if in.KubeVIP != nil {
 		in, out := &in.KubeVIP, &out.KubeVIP
 		*out = new(LXCLoadBalancerKubeVIP)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.External != nil {
 		in, out := &in.External, &out.External
 		*out = new(LXCLoadBalancerExternal)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -144,12 +185,118 @@ func (in *LXCClusterLoadBalancer) DeepCopy() *LXCClusterLoadBalancer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCClusterLoadBalancerStatus) DeepCopyInto(out *LXCClusterLoadBalancerStatus) {
+	*out = *in
+	if in.Backends != nil {
+		in, out := &in.Backends, &out.Backends
+		*out = make([]LXCLoadBalancerBackendStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastReconfigureTime != nil {
+		in, out := &in.LastReconfigureTime, &out.LastReconfigureTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ActiveSpec != nil {
+		in, out := &in.ActiveSpec, &out.ActiveSpec
+		*out = new(LXCClusterLoadBalancer)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCClusterLoadBalancerStatus.
+func (in *LXCClusterLoadBalancerStatus) DeepCopy() *LXCClusterLoadBalancerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCClusterLoadBalancerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCClusterMachineNaming) DeepCopyInto(out *LXCClusterMachineNaming) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCClusterMachineNaming.
+func (in *LXCClusterMachineNaming) DeepCopy() *LXCClusterMachineNaming {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCClusterMachineNaming)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCClusterRegistry) DeepCopyInto(out *LXCClusterRegistry) {
+	*out = *in
+	if in.Mirrors != nil {
+		in, out := &in.Mirrors, &out.Mirrors
+		*out = make([]LXCClusterRegistryMirror, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCClusterRegistry.
+func (in *LXCClusterRegistry) DeepCopy() *LXCClusterRegistry {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCClusterRegistry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCClusterRegistryMirror) DeepCopyInto(out *LXCClusterRegistryMirror) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AuthSecretRef != nil {
+		in, out := &in.AuthSecretRef, &out.AuthSecretRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCClusterRegistryMirror.
+func (in *LXCClusterRegistryMirror) DeepCopy() *LXCClusterRegistryMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCClusterRegistryMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LXCClusterSpec) DeepCopyInto(out *LXCClusterSpec) {
 	*out = *in
 	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
 	out.SecretRef = in.SecretRef
 	in.LoadBalancer.DeepCopyInto(&out.LoadBalancer)
+	if in.ControlPlaneEndpointDNS != nil {
+		in, out := &in.ControlPlaneEndpointDNS, &out.ControlPlaneEndpointDNS
+		*out = new(LXCClusterControlPlaneEndpointDNS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MachineNaming != nil {
+		in, out := &in.MachineNaming, &out.MachineNaming
+		*out = new(LXCClusterMachineNaming)
+		**out = **in
+	}
+	if in.Registry != nil {
+		in, out := &in.Registry, &out.Registry
+		*out = new(LXCClusterRegistry)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCClusterSpec.
@@ -172,6 +319,16 @@ func (in *LXCClusterStatus) DeepCopyInto(out *LXCClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LoadBalancer != nil {
+		in, out := &in.LoadBalancer, &out.LoadBalancer
+		*out = new(LXCClusterLoadBalancerStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ControlPlaneEndpointDNSAddresses != nil {
+		in, out := &in.ControlPlaneEndpointDNSAddresses, &out.ControlPlaneEndpointDNSAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.V1Beta2 != nil {
 		in, out := &in.V1Beta2, &out.V1Beta2
 		*out = new(LXCClusterV1Beta2Status)
@@ -302,9 +459,167 @@ func (in *LXCClusterV1Beta2Status) DeepCopy() *LXCClusterV1Beta2Status {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCImage) DeepCopyInto(out *LXCImage) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCImage.
+func (in *LXCImage) DeepCopy() *LXCImage {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LXCImage) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCImageList) DeepCopyInto(out *LXCImageList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LXCImage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCImageList.
+func (in *LXCImageList) DeepCopy() *LXCImageList {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCImageList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LXCImageList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCImageMemberStatus) DeepCopyInto(out *LXCImageMemberStatus) {
+	*out = *in
+	if in.LastPullTime != nil {
+		in, out := &in.LastPullTime, &out.LastPullTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCImageMemberStatus.
+func (in *LXCImageMemberStatus) DeepCopy() *LXCImageMemberStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCImageMemberStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCImageSpec) DeepCopyInto(out *LXCImageSpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	out.Image = in.Image
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCImageSpec.
+func (in *LXCImageSpec) DeepCopy() *LXCImageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCImageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCImageStatus) DeepCopyInto(out *LXCImageStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]LXCImageMemberStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastRefreshTime != nil {
+		in, out := &in.LastRefreshTime, &out.LastRefreshTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCImageStatus.
+func (in *LXCImageStatus) DeepCopy() *LXCImageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCImageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCLoadBalancerBackendStatus) DeepCopyInto(out *LXCLoadBalancerBackendStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCLoadBalancerBackendStatus.
+func (in *LXCLoadBalancerBackendStatus) DeepCopy() *LXCLoadBalancerBackendStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCLoadBalancerBackendStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LXCLoadBalancerExternal) DeepCopyInto(out *LXCLoadBalancerExternal) {
 	*out = *in
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(LXCLoadBalancerExternalWebhook)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCLoadBalancerExternal.
@@ -317,10 +632,40 @@ func (in *LXCLoadBalancerExternal) DeepCopy() *LXCLoadBalancerExternal {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCLoadBalancerExternalWebhook) DeepCopyInto(out *LXCLoadBalancerExternalWebhook) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCLoadBalancerExternalWebhook.
+func (in *LXCLoadBalancerExternalWebhook) DeepCopy() *LXCLoadBalancerExternalWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCLoadBalancerExternalWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LXCLoadBalancerInstance) DeepCopyInto(out *LXCLoadBalancerInstance) {
 	*out = *in
 	in.InstanceSpec.DeepCopyInto(&out.InstanceSpec)
+	if in.SharedLoadBalancerRef != nil {
+		in, out := &in.SharedLoadBalancerRef, &out.SharedLoadBalancerRef
+		*out = new(LXCSharedLoadBalancerRef)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCLoadBalancerInstance.
@@ -336,6 +681,16 @@ func (in *LXCLoadBalancerInstance) DeepCopy() *LXCLoadBalancerInstance {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LXCLoadBalancerKubeVIP) DeepCopyInto(out *LXCLoadBalancerKubeVIP) {
 	*out = *in
+	if in.BGP != nil {
+		in, out := &in.BGP, &out.BGP
+		*out = new(LXCLoadBalancerKubeVIPBGP)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = new(LXCLoadBalancerKubeVIPServices)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCLoadBalancerKubeVIP.
@@ -348,6 +703,56 @@ func (in *LXCLoadBalancerKubeVIP) DeepCopy() *LXCLoadBalancerKubeVIP {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCLoadBalancerKubeVIPBGP) DeepCopyInto(out *LXCLoadBalancerKubeVIPBGP) {
+	*out = *in
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]LXCLoadBalancerKubeVIPBGPPeer, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCLoadBalancerKubeVIPBGP.
+func (in *LXCLoadBalancerKubeVIPBGP) DeepCopy() *LXCLoadBalancerKubeVIPBGP {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCLoadBalancerKubeVIPBGP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCLoadBalancerKubeVIPBGPPeer) DeepCopyInto(out *LXCLoadBalancerKubeVIPBGPPeer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCLoadBalancerKubeVIPBGPPeer.
+func (in *LXCLoadBalancerKubeVIPBGPPeer) DeepCopy() *LXCLoadBalancerKubeVIPBGPPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCLoadBalancerKubeVIPBGPPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCLoadBalancerKubeVIPServices) DeepCopyInto(out *LXCLoadBalancerKubeVIPServices) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCLoadBalancerKubeVIPServices.
+func (in *LXCLoadBalancerKubeVIPServices) DeepCopy() *LXCLoadBalancerKubeVIPServices {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCLoadBalancerKubeVIPServices)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LXCLoadBalancerMachineSpec) DeepCopyInto(out *LXCLoadBalancerMachineSpec) {
 	*out = *in
@@ -426,6 +831,25 @@ func (in *LXCMachineImageSource) DeepCopy() *LXCMachineImageSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCMachineInstanceStatus) DeepCopyInto(out *LXCMachineInstanceStatus) {
+	*out = *in
+	if in.CreatedAt != nil {
+		in, out := &in.CreatedAt, &out.CreatedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCMachineInstanceStatus.
+func (in *LXCMachineInstanceStatus) DeepCopy() *LXCMachineInstanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCMachineInstanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LXCMachineList) DeepCopyInto(out *LXCMachineList) {
 	*out = *in
@@ -504,6 +928,11 @@ func (in *LXCMachineStatus) DeepCopyInto(out *LXCMachineStatus) {
 		*out = make([]v1beta1.MachineAddress, len(*in))
 		copy(*out, *in)
 	}
+	if in.Instance != nil {
+		in, out := &in.Instance, &out.Instance
+		*out = new(LXCMachineInstanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(v1beta1.Conditions, len(*in))
@@ -534,6 +963,7 @@ func (in *LXCMachineTemplate) DeepCopyInto(out *LXCMachineTemplate) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCMachineTemplate.
@@ -619,6 +1049,33 @@ func (in *LXCMachineTemplateSpec) DeepCopy() *LXCMachineTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCMachineTemplateStatus) DeepCopyInto(out *LXCMachineTemplateStatus) {
+	*out = *in
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.NodeInfo != nil {
+		in, out := &in.NodeInfo, &out.NodeInfo
+		*out = new(NodeInfo)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCMachineTemplateStatus.
+func (in *LXCMachineTemplateStatus) DeepCopy() *LXCMachineTemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCMachineTemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LXCMachineV1Beta2Status) DeepCopyInto(out *LXCMachineV1Beta2Status) {
 	*out = *in
@@ -641,6 +1098,185 @@ func (in *LXCMachineV1Beta2Status) DeepCopy() *LXCMachineV1Beta2Status {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCSharedLoadBalancer) DeepCopyInto(out *LXCSharedLoadBalancer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCSharedLoadBalancer.
+func (in *LXCSharedLoadBalancer) DeepCopy() *LXCSharedLoadBalancer {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCSharedLoadBalancer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LXCSharedLoadBalancer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCSharedLoadBalancerList) DeepCopyInto(out *LXCSharedLoadBalancerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LXCSharedLoadBalancer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCSharedLoadBalancerList.
+func (in *LXCSharedLoadBalancerList) DeepCopy() *LXCSharedLoadBalancerList {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCSharedLoadBalancerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LXCSharedLoadBalancerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCSharedLoadBalancerMember) DeepCopyInto(out *LXCSharedLoadBalancerMember) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCSharedLoadBalancerMember.
+func (in *LXCSharedLoadBalancerMember) DeepCopy() *LXCSharedLoadBalancerMember {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCSharedLoadBalancerMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCSharedLoadBalancerRef) DeepCopyInto(out *LXCSharedLoadBalancerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCSharedLoadBalancerRef.
+func (in *LXCSharedLoadBalancerRef) DeepCopy() *LXCSharedLoadBalancerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCSharedLoadBalancerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCSharedLoadBalancerRouting) DeepCopyInto(out *LXCSharedLoadBalancerRouting) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCSharedLoadBalancerRouting.
+func (in *LXCSharedLoadBalancerRouting) DeepCopy() *LXCSharedLoadBalancerRouting {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCSharedLoadBalancerRouting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCSharedLoadBalancerSpec) DeepCopyInto(out *LXCSharedLoadBalancerSpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	in.InstanceSpec.DeepCopyInto(&out.InstanceSpec)
+	out.Routing = in.Routing
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCSharedLoadBalancerSpec.
+func (in *LXCSharedLoadBalancerSpec) DeepCopy() *LXCSharedLoadBalancerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCSharedLoadBalancerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LXCSharedLoadBalancerStatus) DeepCopyInto(out *LXCSharedLoadBalancerStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]LXCSharedLoadBalancerMember, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LXCSharedLoadBalancerStatus.
+func (in *LXCSharedLoadBalancerStatus) DeepCopy() *LXCSharedLoadBalancerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LXCSharedLoadBalancerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedSecretRef) DeepCopyInto(out *NamespacedSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedSecretRef.
+func (in *NamespacedSecretRef) DeepCopy() *NamespacedSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeInfo) DeepCopyInto(out *NodeInfo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeInfo.
+func (in *NodeInfo) DeepCopy() *NodeInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretRef) DeepCopyInto(out *SecretRef) {
 	*out = *in
@@ -56,7 +56,7 @@ func launchOptionsForImage(ctx context.Context, rawImage string, env Environment
 	// handle node instances (kind instances)
 	if env.KindInstances(ctx) {
 		log.V(3).Info("Launching node instance", "image", rawImage, "type", "kind")
-		opts, err := instances.KindLaunchOptions(instances.KindLaunchOptionsInput{
+		opts, _, err := instances.KindLaunchOptions(instances.KindLaunchOptionsInput{
 			Privileged: env.Privileged(),
 		})
 		if err != nil {
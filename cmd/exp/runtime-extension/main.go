@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/spf13/pflag"
+	cliflag "k8s.io/component-base/cli/flag"
+	"k8s.io/klog/v2"
+	runtimecatalog "sigs.k8s.io/cluster-api/exp/runtime/catalog"
+	runtimehooksv1 "sigs.k8s.io/cluster-api/exp/runtime/hooks/api/v1alpha1"
+	"sigs.k8s.io/cluster-api/exp/runtime/server"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/exp/extension"
+)
+
+var (
+	setupLog = ctrl.Log.WithName("setup")
+
+	webhookPort     int
+	webhookCertDir  string
+	webhookCertName string
+	webhookKeyName  string
+)
+
+func InitFlags(fs *pflag.FlagSet) {
+	// logging flags
+	logFlags := &flag.FlagSet{}
+	klog.InitFlags(logFlags)
+	fs.AddGoFlagSet(logFlags)
+
+	fs.IntVar(&webhookPort, "webhook-port", 9443,
+		"Webhook Server port")
+
+	fs.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs/",
+		"Webhook cert dir.")
+
+	fs.StringVar(&webhookCertName, "webhook-cert-name", "tls.crt",
+		"Webhook cert name.")
+
+	fs.StringVar(&webhookKeyName, "webhook-key-name", "tls.key",
+		"Webhook key name.")
+}
+
+func main() {
+	InitFlags(pflag.CommandLine)
+	pflag.CommandLine.SetNormalizeFunc(cliflag.WordSepNormalizeFunc)
+	pflag.Parse()
+
+	ctrl.SetLogger(klog.Background())
+
+	catalog := runtimecatalog.New()
+	if err := runtimehooksv1.AddToCatalog(catalog); err != nil {
+		setupLog.Error(err, "Failed to add runtime hooks to catalog")
+		os.Exit(1)
+	}
+
+	webhookServer, err := server.New(server.Options{
+		Catalog:  catalog,
+		Port:     webhookPort,
+		CertDir:  webhookCertDir,
+		CertName: webhookCertName,
+		KeyName:  webhookKeyName,
+	})
+	if err != nil {
+		setupLog.Error(err, "Failed to create runtime extension server")
+		os.Exit(1)
+	}
+
+	handlers, err := extension.NewHandlers()
+	if err != nil {
+		setupLog.Error(err, "Failed to create runtime extension handlers")
+		os.Exit(1)
+	}
+
+	for _, h := range []server.ExtensionHandler{
+		{
+			Hook:        runtimehooksv1.GeneratePatches,
+			Name:        "generate-patches",
+			HandlerFunc: handlers.GeneratePatches,
+		},
+		{
+			Hook:        runtimehooksv1.ValidateTopology,
+			Name:        "validate-topology",
+			HandlerFunc: handlers.ValidateTopology,
+		},
+	} {
+		if err := webhookServer.AddExtensionHandler(h); err != nil {
+			setupLog.Error(err, "Failed to add extension handler", "name", h.Name)
+			os.Exit(1)
+		}
+	}
+
+	setupLog.Info("Starting runtime extension server")
+	if err := webhookServer.Start(ctrl.SetupSignalHandler()); err != nil {
+		setupLog.Error(err, "Failed to run runtime extension server")
+		os.Exit(1)
+	}
+}
@@ -47,7 +47,12 @@ import (
 
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
 	"github.com/lxc/cluster-api-provider-incus/internal/controller/lxccluster"
+	"github.com/lxc/cluster-api-provider-incus/internal/controller/lxcimage"
 	"github.com/lxc/cluster-api-provider-incus/internal/controller/lxcmachine"
+	"github.com/lxc/cluster-api-provider-incus/internal/controller/lxcmachinetemplate"
+	"github.com/lxc/cluster-api-provider-incus/internal/controller/lxcsharedloadbalancer"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	webhookv1alpha2 "github.com/lxc/cluster-api-provider-incus/internal/webhook/v1alpha2"
 )
 
 var (
@@ -67,6 +72,7 @@ var (
 	syncPeriod                  time.Duration
 	restConfigQPS               float32
 	restConfigBurst             int
+	enableWebhooks              bool
 	webhookPort                 int
 	webhookCertDir              string
 	webhookCertName             string
@@ -75,7 +81,14 @@ var (
 	managerOptions              = flags.ManagerOptions{}
 
 	// CAPN specific flags.
-	concurrency int
+	concurrency                int
+	loadBalancerSyncPeriod     time.Duration
+	machineTemplateSyncPeriod  time.Duration
+	defaultSimplestreamsServer string
+	offlineImages              bool
+	instanceTypesURL           string
+	imagePrefixesFile          string
+	sharedLBSecretNamespace    string
 )
 
 func init() {
@@ -126,6 +139,27 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&concurrency, "concurrency", 10,
 		"The number of docker machines to process simultaneously")
 
+	fs.DurationVar(&loadBalancerSyncPeriod, "load-balancer-sync-period", time.Minute,
+		"The interval at which cluster load balancer backends are checked against the control plane instances (e.g. 1m). Set to 0 to disable periodic checks")
+
+	fs.DurationVar(&machineTemplateSyncPeriod, "machine-template-sync-period", 10*time.Minute,
+		"The interval at which the capacity of machine templates is refreshed, e.g. to pick up changes to instance profiles (e.g. 10m). Set to 0 to disable periodic refreshes")
+
+	fs.StringVar(&sharedLBSecretNamespace, "shared-load-balancer-secret-namespace", "capn-system",
+		"The namespace of the credential secrets that cluster-scoped LXCSharedLoadBalancers may reference. Secrets in other namespaces are rejected")
+
+	fs.StringVar(&defaultSimplestreamsServer, "default-simplestreams-server", lxc.DefaultSimplestreamsServer,
+		"The simplestreams server for the default kubeadm and haproxy images, as well as \"capi:\" images (e.g. a local mirror for air-gapped environments)")
+
+	fs.BoolVar(&offlineImages, "offline-images", false,
+		"Resolve images against the local image store of the Incus server (aliases, fingerprints and cached images), without contacting simplestreams servers or OCI registries")
+
+	fs.StringVar(&instanceTypesURL, "instance-types-url", lxc.DefaultInstanceTypesURL,
+		"The URL of the instance type catalog used to resolve the flavor of machine templates (e.g. a local mirror for air-gapped environments). Set to empty to disable resolving instance types")
+
+	fs.StringVar(&imagePrefixesFile, "image-prefixes-file", "",
+		"Path to a YAML file with user-defined image prefixes (e.g. \"corp:\"), mapping each prefix to an image server, protocol and alias template for Incus and Canonical LXD servers")
+
 	fs.DurationVar(&syncPeriod, "sync-period", 10*time.Minute,
 		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
 
@@ -136,6 +170,9 @@ func InitFlags(fs *pflag.FlagSet) {
 		"Maximum number of queries that should be allowed in one burst from the controller client to"+
 			" the Kubernetes API server.")
 
+	fs.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the validating admission webhooks. The webhook serving certificate must be mounted in the webhook cert dir.")
+
 	fs.IntVar(&webhookPort, "webhook-port", 9443,
 		"Webhook Server port")
 
@@ -187,6 +224,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := lxc.SetDefaultSimplestreamsServer(defaultSimplestreamsServer); err != nil {
+		setupLog.Error(err, "Unable to start manager: invalid --default-simplestreams-server")
+		os.Exit(1)
+	}
+	lxc.SetOfflineImages(offlineImages)
+	if err := lxc.SetInstanceTypesURL(instanceTypesURL); err != nil {
+		setupLog.Error(err, "Unable to start manager: invalid --instance-types-url")
+		os.Exit(1)
+	}
+	if imagePrefixesFile != "" {
+		if err := lxc.LoadImagePrefixesFile(imagePrefixesFile); err != nil {
+			setupLog.Error(err, "Unable to start manager: invalid --image-prefixes-file")
+			os.Exit(1)
+		}
+	}
+
 	var watchNamespaces map[string]cache.Config
 	if watchNamespace != "" {
 		watchNamespaces = map[string]cache.Config{
@@ -254,6 +307,7 @@ func main() {
 	ctx := ctrl.SetupSignalHandler()
 
 	setupReconcilers(ctx, mgr)
+	setupWebhooks(mgr)
 	setupChecks(mgr)
 
 	setupLog.Info("starting manager")
@@ -276,8 +330,9 @@ func setupChecks(mgr ctrl.Manager) {
 
 func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 	if err := (&lxccluster.LXCClusterReconciler{
-		Client:           mgr.GetClient(),
-		WatchFilterValue: watchFilterValue,
+		Client:                 mgr.GetClient(),
+		WatchFilterValue:       watchFilterValue,
+		LoadBalancerSyncPeriod: loadBalancerSyncPeriod,
 	}).SetupWithManager(ctx, mgr, ctrl_controller.Options{}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "LXCCluster")
 		os.Exit(1)
@@ -286,11 +341,55 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 	if err := (&lxcmachine.LXCMachineReconciler{
 		Client:           mgr.GetClient(),
 		WatchFilterValue: watchFilterValue,
+		Recorder:         mgr.GetEventRecorderFor("lxcmachine-controller"),
 	}).SetupWithManager(ctx, mgr, ctrl_controller.Options{
 		MaxConcurrentReconciles: concurrency,
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "LXCMachine")
 		os.Exit(1)
 	}
+
+	if err := (&lxcmachinetemplate.LXCMachineTemplateReconciler{
+		Client:           mgr.GetClient(),
+		WatchFilterValue: watchFilterValue,
+		SyncPeriod:       machineTemplateSyncPeriod,
+	}).SetupWithManager(ctx, mgr, ctrl_controller.Options{}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LXCMachineTemplate")
+		os.Exit(1)
+	}
+
+	if err := (&lxcsharedloadbalancer.LXCSharedLoadBalancerReconciler{
+		Client:                 mgr.GetClient(),
+		WatchFilterValue:       watchFilterValue,
+		LoadBalancerSyncPeriod: loadBalancerSyncPeriod,
+		SecretNamespace:        sharedLBSecretNamespace,
+	}).SetupWithManager(ctx, mgr, ctrl_controller.Options{}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LXCSharedLoadBalancer")
+		os.Exit(1)
+	}
+
+	if err := (&lxcimage.LXCImageReconciler{
+		Client:           mgr.GetClient(),
+		WatchFilterValue: watchFilterValue,
+	}).SetupWithManager(ctx, mgr, ctrl_controller.Options{}); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LXCImage")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 }
+
+func setupWebhooks(mgr ctrl.Manager) {
+	if !enableWebhooks {
+		return
+	}
+
+	if err := webhookv1alpha2.SetupLXCClusterWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "LXCCluster")
+		os.Exit(1)
+	}
+	if err := webhookv1alpha2.SetupLXCClusterTemplateWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "LXCClusterTemplate")
+		os.Exit(1)
+	}
+	// +kubebuilder:scaffold:webhook
+}
@@ -1,16 +1,33 @@
 package cloudinit
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"reflect"
+	"slices"
 	"strings"
 
 	"sigs.k8s.io/yaml"
 )
 
 // CloudInit represents a limited subset of cloud-config that is supported.
+//
+// Values are normalized while parsing (e.g. commands are always strings, file contents are always plain text or
+// base64), such that they can be applied by a simple script.
 type CloudConfig struct {
-	WriteFiles  []File   `json:"write_files"`
-	RunCommands []string `json:"runcmd"`
+	BootCommands   []Command `json:"bootcmd"`
+	WriteFiles     []File    `json:"write_files"`
+	Users          []User    `json:"users"`
+	Mounts         []Mount   `json:"mounts"`
+	NTP            *NTP      `json:"ntp"`
+	PackageUpdate  bool      `json:"package_update"`
+	PackageUpgrade bool      `json:"package_upgrade"`
+	Packages       []Package `json:"packages"`
+	RunCommands    []Command `json:"runcmd"`
 }
 
 type File struct {
@@ -18,11 +35,49 @@ type File struct {
 	Owner       string `json:"owner"`
 	Permissions string `json:"permissions"`
 	Content     string `json:"content"`
+	// Encoding is either empty (Content is plain text) or "b64" (Content is base64).
+	Encoding string `json:"encoding"`
+	Append   bool   `json:"append"`
+	Defer    bool   `json:"defer"`
 }
 
-// Parse a cloud-init YAML manifest (only a limited subset of keys of the CloudConfig struct are allowed).
+type User struct {
+	Name              string     `json:"name"`
+	Gecos             string     `json:"gecos"`
+	HomeDir           string     `json:"homedir"`
+	Shell             string     `json:"shell"`
+	PrimaryGroup      string     `json:"primary_group"`
+	Groups            StringList `json:"groups"`
+	LockPassword      *bool      `json:"lock_passwd"`
+	Password          string     `json:"passwd"`
+	Sudo              StringList `json:"sudo"`
+	SSHAuthorizedKeys []string   `json:"ssh_authorized_keys"`
+}
+
+type NTP struct {
+	Enabled *bool    `json:"enabled"`
+	Servers []string `json:"servers"`
+	Pools   []string `json:"pools"`
+}
+
+// Command is a shell command. Commands in list form (e.g. ["echo", "hello world"]) are quoted and joined.
+type Command string
+
+// StringList is a list of strings. A single string is parsed as a list with one item, and false or null are parsed
+// as an empty list.
+type StringList []string
+
+// Mount is a mounts entry, e.g. ["/dev/sdb", "/mnt", "ext4", "defaults", "0", "2"]. Numbers are parsed as strings.
+type Mount []string
+
+// Package is a package to install. Packages in list form (e.g. ["kubelet", "1.33.0"]) are parsed as "name=version".
+type Package string
+
+// Parse a cloud-init YAML manifest (only a limited subset of keys of the CloudConfig struct are supported).
 // If the manifest starts with `## template: jinja`, then an optional replacer is applied on the manifest before parsing.
-func Parse(raw string, replacer *strings.Replacer) (CloudConfig, error) {
+//
+// Parse returns a list of warnings for unsupported keys, which are ignored.
+func Parse(raw string, replacer *strings.Replacer) (CloudConfig, []string, error) {
 	raw, isJinja := strings.CutPrefix(raw, "## template: jinja\n")
 	if isJinja && replacer != nil {
 		raw = replacer.Replace(raw)
@@ -30,13 +85,191 @@ func Parse(raw string, replacer *strings.Replacer) (CloudConfig, error) {
 
 	raw, isCloudConfig := strings.CutPrefix(raw, "#cloud-config\n")
 	if !isCloudConfig {
-		return CloudConfig{}, fmt.Errorf("missing required header #cloud-config")
+		return CloudConfig{}, nil, fmt.Errorf("missing required header #cloud-config")
+	}
+
+	var keys map[string]any
+	if err := yaml.Unmarshal([]byte(raw), &keys); err != nil {
+		return CloudConfig{}, nil, fmt.Errorf("failed parsing cloud-config YAML: %w", err)
+	}
+	var warnings []string
+	for _, key := range unsupportedKeys(keys) {
+		warnings = append(warnings, fmt.Sprintf("cloud-config key %q is not supported and will be ignored", key))
 	}
 
 	var cloudConfig CloudConfig
-	if err := yaml.UnmarshalStrict([]byte(raw), &cloudConfig, yaml.DisallowUnknownFields); err != nil {
-		return CloudConfig{}, fmt.Errorf("failed parsing cloud-config YAML: %w", err)
+	if err := yaml.Unmarshal([]byte(raw), &cloudConfig); err != nil {
+		return CloudConfig{}, nil, fmt.Errorf("failed parsing cloud-config YAML: %w", err)
+	}
+
+	for i, file := range cloudConfig.WriteFiles {
+		if err := file.normalize(); err != nil {
+			return CloudConfig{}, nil, fmt.Errorf("invalid write_files entry %q: %w", file.Path, err)
+		}
+		cloudConfig.WriteFiles[i] = file
+	}
+
+	return cloudConfig, warnings, nil
+}
+
+// unsupportedKeys returns the sorted keys that are not part of CloudConfig.
+func unsupportedKeys(keys map[string]any) []string {
+	supported := map[string]struct{}{}
+	t := reflect.TypeFor[CloudConfig]()
+	for i := range t.NumField() {
+		supported[t.Field(i).Tag.Get("json")] = struct{}{}
+	}
+
+	var unsupported []string
+	for key := range keys {
+		if _, ok := supported[key]; !ok {
+			unsupported = append(unsupported, key)
+		}
+	}
+	slices.Sort(unsupported)
+	return unsupported
+}
+
+// normalize sets default owner and permissions, and decodes file contents. Files with binary contents are encoded
+// as base64.
+func (f *File) normalize() error {
+	if f.Owner == "" {
+		f.Owner = "root:root"
+	}
+	if f.Permissions == "" {
+		f.Permissions = "0644"
+	}
+
+	var isGzip bool
+	switch strings.ToLower(f.Encoding) {
+	case "", "text/plain":
+		f.Encoding = ""
+		return nil
+	case "b64", "base64":
+	case "gz+b64", "gz+base64", "gzip+b64", "gzip+base64":
+		isGzip = true
+	default:
+		return fmt.Errorf("unsupported encoding %q", f.Encoding)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(f.Content)
+	if err != nil {
+		return fmt.Errorf("failed to decode base64 content: %w", err)
+	}
+	if isGzip {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return fmt.Errorf("failed to decompress content: %w", err)
+		}
+		if b, err = io.ReadAll(r); err != nil {
+			return fmt.Errorf("failed to decompress content: %w", err)
+		}
+	}
+
+	f.Content = base64.StdEncoding.EncodeToString(b)
+	f.Encoding = "b64"
+	return nil
+}
+
+func (u *User) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*u = User{Name: name}
+		return nil
+	}
+
+	type user User
+	if err := json.Unmarshal(b, (*user)(u)); err != nil {
+		return err
+	}
+	// groups may also be a comma separated string, e.g. "docker, wheel"
+	if len(u.Groups) == 1 {
+		groups := strings.Split(u.Groups[0], ",")
+		for i := range groups {
+			groups[i] = strings.TrimSpace(groups[i])
+		}
+		u.Groups = groups
+	}
+	return nil
+}
+
+func (c *Command) UnmarshalJSON(b []byte) error {
+	var command string
+	if err := json.Unmarshal(b, &command); err == nil {
+		*c = Command(command)
+		return nil
+	}
+
+	var args []string
+	if err := json.Unmarshal(b, &args); err != nil {
+		return fmt.Errorf("command must be a string or a list of strings: %w", err)
+	}
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+	*c = Command(strings.Join(args, " "))
+	return nil
+}
+
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	var value any
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
 	}
 
-	return cloudConfig, nil
+	switch v := value.(type) {
+	case nil, bool:
+		*l = nil
+	case string:
+		*l = StringList{v}
+	default:
+		var list []string
+		if err := json.Unmarshal(b, &list); err != nil {
+			return fmt.Errorf("value must be a string or a list of strings: %w", err)
+		}
+		*l = list
+	}
+	return nil
+}
+
+func (m *Mount) UnmarshalJSON(b []byte) error {
+	var values []any
+	if err := json.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("mount must be a list: %w", err)
+	}
+
+	*m = make(Mount, 0, len(values))
+	for _, value := range values {
+		if value == nil {
+			*m = append(*m, "")
+		} else {
+			*m = append(*m, fmt.Sprint(value))
+		}
+	}
+	return nil
+}
+
+func (p *Package) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*p = Package(name)
+		return nil
+	}
+
+	var nameVersion []string
+	if err := json.Unmarshal(b, &nameVersion); err != nil || len(nameVersion) != 2 {
+		return fmt.Errorf("package must be a string or a [name, version] list")
+	}
+	*p = Package(fmt.Sprintf("%s=%s", nameVersion[0], nameVersion[1]))
+	return nil
+}
+
+// shellQuote quotes a string for use in a shell command, if needed.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
@@ -0,0 +1,110 @@
+package cloudinit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParse(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write([]byte("compressed"))
+	_ = w.Close()
+
+	raw := `#cloud-config
+bootcmd:
+- echo boot
+- [echo, "it's me"]
+write_files:
+- path: /etc/plain
+  content: hello
+- path: /etc/b64
+  owner: user:user
+  permissions: "0600"
+  encoding: b64
+  content: ` + base64.StdEncoding.EncodeToString([]byte("decoded")) + `
+- path: /etc/gzip
+  encoding: gzip+b64
+  content: ` + base64.StdEncoding.EncodeToString(gz.Bytes()) + `
+  append: true
+  defer: true
+users:
+- default
+- name: capn
+  groups: docker, wheel
+  lock_passwd: false
+  sudo: ALL=(ALL) NOPASSWD:ALL
+  ssh_authorized_keys:
+  - ssh-ed25519 AAAA
+- name: other
+  groups: [a]
+  sudo: false
+mounts:
+- [/dev/sdb, /mnt/data, ext4, defaults, 0, 2]
+- [swap, none, swap, sw]
+ntp:
+  enabled: true
+  servers: [time.example.com]
+package_update: true
+packages:
+- curl
+- [kubelet, 1.33.0-1.1]
+runcmd:
+- kubeadm init
+disk_setup:
+  /dev/sdb: {}
+fs_setup: []
+`
+
+	g := NewWithT(t)
+
+	cloudConfig, warnings, err := Parse(raw, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(warnings).To(Equal([]string{
+		`cloud-config key "disk_setup" is not supported and will be ignored`,
+		`cloud-config key "fs_setup" is not supported and will be ignored`,
+	}))
+
+	g.Expect(cloudConfig.BootCommands).To(Equal([]Command{"echo boot", `echo 'it'\''s me'`}))
+	g.Expect(cloudConfig.WriteFiles).To(Equal([]File{
+		{Path: "/etc/plain", Owner: "root:root", Permissions: "0644", Content: "hello"},
+		{Path: "/etc/b64", Owner: "user:user", Permissions: "0600", Content: base64.StdEncoding.EncodeToString([]byte("decoded")), Encoding: "b64"},
+		{Path: "/etc/gzip", Owner: "root:root", Permissions: "0644", Content: base64.StdEncoding.EncodeToString([]byte("compressed")), Encoding: "b64", Append: true, Defer: true},
+	}))
+	lockPassword := false
+	g.Expect(cloudConfig.Users).To(Equal([]User{
+		{Name: "default"},
+		{Name: "capn", Groups: StringList{"docker", "wheel"}, LockPassword: &lockPassword, Sudo: StringList{"ALL=(ALL) NOPASSWD:ALL"}, SSHAuthorizedKeys: []string{"ssh-ed25519 AAAA"}},
+		{Name: "other", Groups: StringList{"a"}},
+	}))
+	g.Expect(cloudConfig.Mounts).To(Equal([]Mount{{"/dev/sdb", "/mnt/data", "ext4", "defaults", "0", "2"}, {"swap", "none", "swap", "sw"}}))
+	g.Expect(cloudConfig.NTP.Servers).To(Equal([]string{"time.example.com"}))
+	g.Expect(cloudConfig.PackageUpdate).To(BeTrue())
+	g.Expect(cloudConfig.Packages).To(Equal([]Package{"curl", "kubelet=1.33.0-1.1"}))
+	g.Expect(cloudConfig.RunCommands).To(Equal([]Command{"kubeadm init"}))
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  string
+	}{
+		{name: "MissingHeader", raw: "runcmd: []\n"},
+		{name: "InvalidYAML", raw: "#cloud-config\nruncmd: [\n"},
+		{name: "InvalidCommand", raw: "#cloud-config\nruncmd:\n- {a: b}\n"},
+		{name: "InvalidEncoding", raw: "#cloud-config\nwrite_files:\n- path: /a\n  encoding: zstd\n  content: a\n"},
+		{name: "InvalidBase64", raw: "#cloud-config\nwrite_files:\n- path: /a\n  encoding: b64\n  content: '!!'\n"},
+		{name: "InvalidPackage", raw: "#cloud-config\npackages:\n- [a, b, c]\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, _, err := Parse(tc.raw, nil)
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
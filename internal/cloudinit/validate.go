@@ -0,0 +1,53 @@
+package cloudinit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// userDataHeaders are the headers of the cloud-init user-data formats that are not validated further.
+var userDataHeaders = []string{
+	"#!",
+	"#include",
+	"#cloud-boothook",
+	"#part-handler",
+	"Content-Type:",
+}
+
+var (
+	// jinjaExpressionRegexp matches jinja expressions, e.g. "{{ ds.meta_data.local_hostname }}".
+	jinjaExpressionRegexp = regexp.MustCompile(`\{\{.*?\}\}`)
+	// jinjaStatementRegexp matches jinja statements and comments, e.g. "{% if true %}" or "{# comment #}".
+	jinjaStatementRegexp = regexp.MustCompile(`\{%.*?%\}|\{#.*?#\}`)
+)
+
+// Validate checks that raw is valid cloud-init user-data. User-data must start with a known header. If the user-data
+// is a cloud-config, it must also be a valid YAML object. Jinja templates (starting with `## template: jinja`) are
+// validated after replacing all jinja expressions with placeholders.
+func Validate(raw string) error {
+	raw, isJinja := strings.CutPrefix(raw, "## template: jinja\n")
+	if isJinja {
+		raw = jinjaStatementRegexp.ReplaceAllString(raw, "")
+		raw = jinjaExpressionRegexp.ReplaceAllString(raw, "jinja")
+	}
+
+	header, body, _ := strings.Cut(raw, "\n")
+	if strings.TrimSpace(header) != "#cloud-config" {
+		for _, known := range userDataHeaders {
+			if strings.HasPrefix(header, known) {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown user-data header %q, expected #cloud-config", header)
+	}
+
+	var cloudConfig map[string]any
+	if err := yaml.Unmarshal([]byte(body), &cloudConfig); err != nil {
+		return fmt.Errorf("failed parsing cloud-config YAML: %w", err)
+	}
+
+	return nil
+}
@@ -19,6 +19,7 @@ package lxccluster
 import (
 	"context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -34,6 +35,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
@@ -45,11 +47,22 @@ type LXCClusterReconciler struct {
 
 	// WatchFilterValue is the label value used to filter events prior to reconciliation.
 	WatchFilterValue string
+
+	// LoadBalancerSyncPeriod is the interval at which the load balancer backends are compared against the
+	// control plane instances of the cluster. If zero, backends are only checked when the LXCCluster or one
+	// of its control plane LXCMachines changes.
+	LoadBalancerSyncPeriod time.Duration
+
+	// imagePreflightCache caches the results of image availability checks.
+	imagePreflightCache imagePreflightCache
 }
 
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcclusters/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcclusters/finalizers,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcmachinetemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=*,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -119,7 +132,7 @@ func (r *LXCClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	// Handle non-deleted clusters
-	return ctrl.Result{}, r.reconcileNormal(ctx, cluster, lxcCluster, lxcClient)
+	return r.reconcileNormal(ctx, cluster, lxcCluster, lxcClient)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -138,11 +151,72 @@ func (r *LXCClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Ma
 			&clusterv1.Cluster{},
 			handler.EnqueueRequestsFromMapFunc(util.ClusterToInfrastructureMapFunc(ctx, infrav1.GroupVersion.WithKind("LXCCluster"), mgr.GetClient(), &infrav1.LXCCluster{})),
 			builder.WithPredicates(
-				predicates.ClusterPausedTransitions(mgr.GetScheme(), predicateLog),
+				predicates.Any(mgr.GetScheme(), predicateLog,
+					predicates.ClusterPausedTransitions(mgr.GetScheme(), predicateLog),
+					predicates.ClusterTopologyVersionChanged(mgr.GetScheme(), predicateLog),
+				),
 			),
-		).Complete(r); err != nil {
+		).
+		Watches(
+			&clusterv1.MachineDeployment{},
+			handler.EnqueueRequestsFromMapFunc(r.MachineDeploymentToLXCCluster),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&infrav1.LXCMachine{},
+			handler.EnqueueRequestsFromMapFunc(r.LXCMachineToLXCCluster),
+			builder.WithPredicates(predicates.ResourceNotPausedAndHasFilterLabel(mgr.GetScheme(), predicateLog, r.WatchFilterValue)),
+		).
+		Complete(r); err != nil {
 		return fmt.Errorf("failed setting up with a controller manager: %w", err)
 	}
 
 	return nil
 }
+
+// LXCMachineToLXCCluster is a handler.ToRequestsFunc to be used to enqueue
+// requests for reconciliation of the LXCCluster owning a control plane LXCMachine.
+func (r *LXCClusterReconciler) LXCMachineToLXCCluster(ctx context.Context, o client.Object) []ctrl.Request {
+	m, ok := o.(*infrav1.LXCMachine)
+	if !ok {
+		panic(fmt.Sprintf("Expected a LXCMachine but got a %T", o))
+	}
+
+	// only control plane machines are load balancer backends
+	if _, ok := m.Labels[clusterv1.MachineControlPlaneLabel]; !ok {
+		return nil
+	}
+	clusterName, ok := m.Labels[clusterv1.ClusterNameLabel]
+	if !ok {
+		return nil
+	}
+
+	cluster, err := util.GetClusterByName(ctx, r.Client, m.Namespace, clusterName)
+	if err != nil || cluster.Spec.InfrastructureRef == nil {
+		return nil
+	}
+
+	return []ctrl.Request{{NamespacedName: client.ObjectKey{
+		Namespace: m.Namespace,
+		Name:      cluster.Spec.InfrastructureRef.Name,
+	}}}
+}
+
+// MachineDeploymentToLXCCluster is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation of
+// the LXCCluster of a MachineDeployment, such that images are checked when the Kubernetes version changes.
+func (r *LXCClusterReconciler) MachineDeploymentToLXCCluster(ctx context.Context, o client.Object) []ctrl.Request {
+	md, ok := o.(*clusterv1.MachineDeployment)
+	if !ok {
+		panic(fmt.Sprintf("Expected a MachineDeployment but got a %T", o))
+	}
+
+	cluster, err := util.GetClusterByName(ctx, r.Client, md.Namespace, md.Spec.ClusterName)
+	if err != nil || cluster.Spec.InfrastructureRef == nil {
+		return nil
+	}
+
+	return []ctrl.Request{{NamespacedName: client.ObjectKey{
+		Namespace: md.Namespace,
+		Name:      cluster.Spec.InfrastructureRef.Name,
+	}}}
+}
@@ -14,6 +14,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/endpointdns"
 	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
 	"github.com/lxc/cluster-api-provider-incus/internal/utils"
@@ -35,10 +36,30 @@ func (r *LXCClusterReconciler) reconcileDelete(ctx context.Context, cluster *clu
 
 	// Delete the container hosting the load balancer
 	log.FromContext(ctx).Info("Deleting load balancer")
-	if err := loadbalancer.ManagerForCluster(cluster, lxcCluster, lxcClient).Delete(ctx); err != nil {
+	if err := deleteLoadBalancer(ctx, lxcCluster, func() (loadbalancer.Manager, error) {
+		lbOpts, err := loadbalancer.ManagerOptionsForCluster(ctx, r.Client, lxcCluster)
+		if err != nil {
+			return nil, err
+		}
+		return loadbalancer.ManagerForCluster(cluster, lxcCluster, lxcClient, lbOpts...), nil
+	}); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to delete the load balancer instance: %w", err)
 	}
 
+	// Delete the previous load balancer, if the cluster is deleted during a load balancer handover.
+	if err := deleteLoadBalancer(ctx, lxcCluster, func() (loadbalancer.Manager, error) {
+		return r.activeLoadBalancerManager(ctx, cluster, lxcCluster, lxcClient)
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to delete the previous load balancer: %w", err)
+	}
+
+	// Delete the DNS records of the control plane endpoint
+	if err := deregisterControlPlaneEndpointDNS(ctx, lxcCluster, func() (endpointdns.Registrar, error) {
+		return endpointdns.RegistrarForCluster(ctx, r.Client, lxcCluster, lxcClient)
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to delete the control plane endpoint DNS records: %w", err)
+	}
+
 	machines, err := utils.GetMachinesForCluster(ctx, r.Client, client.ObjectKeyFromObject(cluster))
 	if err != nil {
 		log.FromContext(ctx).Error(err, "Failed to get list of Machines for Cluster")
@@ -52,3 +73,35 @@ func (r *LXCClusterReconciler) reconcileDelete(ctx context.Context, cluster *clu
 
 	return ctrl.Result{}, nil
 }
+
+// deleteLoadBalancer deletes the load balancer of the Manager returned by getManager, if any. Non-retriable errors
+// (e.g. a missing secret, or an external load balancer webhook rejecting the request) are reported on the
+// LoadBalancerAvailable condition, but do not block the deletion of the cluster.
+func deleteLoadBalancer(ctx context.Context, lxcCluster *infrav1.LXCCluster, getManager func() (loadbalancer.Manager, error)) error {
+	lbManager, err := getManager()
+	if err == nil && lbManager != nil {
+		err = lbManager.Delete(ctx)
+	}
+	if err != nil && utils.IsTerminalError(err) {
+		log.FromContext(ctx).Error(err, "Failed to delete load balancer, resources may need to be cleaned up manually")
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerDeletionAbortedReason, clusterv1.ConditionSeverityError, "The load balancer could not be deleted and may need to be cleaned up manually. The error was: %s", err)
+		return nil
+	}
+	return err
+}
+
+// deregisterControlPlaneEndpointDNS deletes the DNS records of the Registrar returned by getRegistrar, if any.
+// Non-retriable errors (e.g. a missing TSIG secret) are reported on the ControlPlaneEndpointDNSRegistered condition,
+// but do not block the deletion of the cluster.
+func deregisterControlPlaneEndpointDNS(ctx context.Context, lxcCluster *infrav1.LXCCluster, getRegistrar func() (endpointdns.Registrar, error)) error {
+	registrar, err := getRegistrar()
+	if err == nil && registrar != nil {
+		err = registrar.Deregister(ctx)
+	}
+	if err != nil && utils.IsTerminalError(err) {
+		log.FromContext(ctx).Error(err, "Failed to delete control plane endpoint DNS records, records may need to be cleaned up manually")
+		conditions.MarkFalse(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition, infrav1.ControlPlaneEndpointDNSDeregistrationAbortedReason, clusterv1.ConditionSeverityError, "The control plane endpoint DNS records could not be deleted and may need to be cleaned up manually. The error was: %s", err)
+		return nil
+	}
+	return err
+}
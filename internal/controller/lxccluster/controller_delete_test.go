@@ -0,0 +1,123 @@
+package lxccluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/endpointdns"
+	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// deleteErrorManager is a loadbalancer.Manager that fails to delete the load balancer.
+type deleteErrorManager struct {
+	loadbalancer.Manager
+
+	err error
+}
+
+func (m *deleteErrorManager) Delete(context.Context) error {
+	return m.err
+}
+
+func TestDeleteLoadBalancer(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		noManager   bool
+		managerErr  error
+		deleteErr   error
+		expectError bool
+		expectAbort bool
+	}{
+		{name: "Deleted"},
+		{name: "NoManager", noManager: true},
+		{name: "DeleteFailed", deleteErr: fmt.Errorf("server error"), expectError: true},
+		{name: "DeleteAborted", deleteErr: utils.TerminalError(fmt.Errorf("unexpected status code 400")), expectAbort: true},
+		{name: "ManagerFailed", managerErr: fmt.Errorf("failed to retrieve webhook secret"), expectError: true},
+		{name: "SecretMissing", managerErr: utils.TerminalError(fmt.Errorf("webhook secret not found")), expectAbort: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			lxcCluster := &infrav1.LXCCluster{}
+			err := deleteLoadBalancer(context.Background(), lxcCluster, func() (loadbalancer.Manager, error) {
+				if tc.managerErr != nil {
+					return nil, tc.managerErr
+				}
+				if tc.noManager {
+					return nil, nil
+				}
+				return &deleteErrorManager{err: tc.deleteErr}, nil
+			})
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			if tc.expectAbort {
+				g.Expect(conditions.GetReason(lxcCluster, infrav1.LoadBalancerAvailableCondition)).To(Equal(infrav1.LoadBalancerDeletionAbortedReason))
+			} else {
+				g.Expect(conditions.Has(lxcCluster, infrav1.LoadBalancerAvailableCondition)).To(BeFalse())
+			}
+		})
+	}
+}
+
+// deregisterErrorRegistrar is an endpointdns.Registrar that fails to delete the DNS records.
+type deregisterErrorRegistrar struct {
+	endpointdns.Registrar
+
+	err error
+}
+
+func (r *deregisterErrorRegistrar) Deregister(context.Context) error {
+	return r.err
+}
+
+func TestDeregisterControlPlaneEndpointDNS(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		noRegistrar   bool
+		registrarErr  error
+		deregisterErr error
+		expectError   bool
+		expectAbort   bool
+	}{
+		{name: "Deregistered"},
+		{name: "NoRegistrar", noRegistrar: true},
+		{name: "DeregisterFailed", deregisterErr: fmt.Errorf("connection refused"), expectError: true},
+		{name: "DeregisterAborted", deregisterErr: utils.TerminalError(fmt.Errorf("server does not support network zones")), expectAbort: true},
+		{name: "RegistrarFailed", registrarErr: fmt.Errorf("failed to retrieve TSIG secret"), expectError: true},
+		{name: "SecretMissing", registrarErr: utils.TerminalError(fmt.Errorf("TSIG secret not found")), expectAbort: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			lxcCluster := &infrav1.LXCCluster{}
+			err := deregisterControlPlaneEndpointDNS(context.Background(), lxcCluster, func() (endpointdns.Registrar, error) {
+				if tc.registrarErr != nil {
+					return nil, tc.registrarErr
+				}
+				if tc.noRegistrar {
+					return nil, nil
+				}
+				return &deregisterErrorRegistrar{err: tc.deregisterErr}, nil
+			})
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			if tc.expectAbort {
+				g.Expect(conditions.GetReason(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition)).To(Equal(infrav1.ControlPlaneEndpointDNSDeregistrationAbortedReason))
+			} else {
+				g.Expect(conditions.Has(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition)).To(BeFalse())
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package lxccluster
+
+import (
+	"context"
+	"slices"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/endpointdns"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// reconcileControlPlaneEndpointDNS ensures that the DNS name of the control plane endpoint resolves to the load
+// balancer addresses. The DNS records are only updated when the load balancer addresses change.
+func (r *LXCClusterReconciler) reconcileControlPlaneEndpointDNS(ctx context.Context, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client, addresses []string) error {
+	if lxcCluster.Spec.ControlPlaneEndpointDNS == nil {
+		return nil
+	}
+
+	addresses = slices.Sorted(slices.Values(addresses))
+	if conditions.IsTrue(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition) && slices.Equal(addresses, lxcCluster.Status.ControlPlaneEndpointDNSAddresses) {
+		return nil
+	}
+
+	registrar, err := endpointdns.RegistrarForCluster(ctx, r.Client, lxcCluster, lxcClient)
+	if err != nil {
+		if utils.IsTerminalError(err) {
+			conditions.MarkFalse(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition, infrav1.ControlPlaneEndpointDNSRegistrationAbortedReason, clusterv1.ConditionSeverityError, "The control plane endpoint DNS records could not be registered. The error was: %s", err)
+			return err
+		}
+		conditions.MarkFalse(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition, infrav1.ControlPlaneEndpointDNSRegistrationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+		return err
+	}
+
+	log.FromContext(ctx).Info("Registering control plane endpoint DNS records", "name", lxcCluster.Spec.ControlPlaneEndpointDNS.Name, "addresses", addresses)
+	if err := registrar.Register(ctx, addresses); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to register control plane endpoint DNS records")
+		if utils.IsTerminalError(err) {
+			conditions.MarkFalse(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition, infrav1.ControlPlaneEndpointDNSRegistrationAbortedReason, clusterv1.ConditionSeverityError, "The control plane endpoint DNS records could not be registered. The error was: %s", err)
+			return err
+		}
+		conditions.MarkFalse(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition, infrav1.ControlPlaneEndpointDNSRegistrationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+		return err
+	}
+
+	lxcCluster.Status.ControlPlaneEndpointDNSAddresses = addresses
+	conditions.MarkTrue(lxcCluster, infrav1.ControlPlaneEndpointDNSRegisteredCondition)
+	return nil
+}
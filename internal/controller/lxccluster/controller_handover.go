@@ -0,0 +1,215 @@
+package lxccluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// activeLoadBalancerManager returns a Manager for the load balancer that currently serves the control plane endpoint,
+// if it is different from the load balancer described by the LXCCluster spec.
+func (r *LXCClusterReconciler) activeLoadBalancerManager(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client) (loadbalancer.Manager, error) {
+	if lxcCluster.Status.LoadBalancer == nil || lxcCluster.Status.LoadBalancer.ActiveSpec == nil {
+		return nil, nil
+	}
+	if loadbalancer.Identity(*lxcCluster.Status.LoadBalancer.ActiveSpec) == loadbalancer.Identity(lxcCluster.Spec.LoadBalancer) {
+		return nil, nil
+	}
+
+	activeLXCCluster := lxcCluster.DeepCopy()
+	activeLXCCluster.Spec.LoadBalancer = *lxcCluster.Status.LoadBalancer.ActiveSpec.DeepCopy()
+	// share the load balancer status, such that changes recorded by the active load balancer (e.g. the backends
+	// that were sent to an external load balancer webhook) are not lost.
+	activeLXCCluster.Status.LoadBalancer = lxcCluster.Status.LoadBalancer
+
+	lbOpts, err := loadbalancer.ManagerOptionsForCluster(ctx, r.Client, activeLXCCluster)
+	if err != nil {
+		return nil, err
+	}
+	return loadbalancer.ManagerForCluster(cluster, activeLXCCluster, lxcClient, lbOpts...), nil
+}
+
+// instanceLister lists the instances of the cluster project. It is implemented by *lxc.Client.
+type instanceLister interface {
+	ListInstances(ctx context.Context, filters ...lxc.ListInstanceFilter) ([]api.InstanceFull, error)
+}
+
+var _ instanceLister = &lxc.Client{}
+
+// instancesWithAddress returns the names of the instances that have the specified address configured.
+func instancesWithAddress(ctx context.Context, lister instanceLister, address string) ([]string, error) {
+	instances, err := lister.ListInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instances: %w", err)
+	}
+
+	var names []string
+	for _, instance := range instances {
+		if instance.State == nil {
+			continue
+		}
+		for _, network := range instance.State.Network {
+			if slices.ContainsFunc(network.Addresses, func(a api.InstanceStateNetworkAddress) bool { return a.Address == address }) {
+				names = append(names, instance.Name)
+				break
+			}
+		}
+	}
+	return names, nil
+}
+
+// reconcileLoadBalancerHandover hands over the control plane endpoint from the active load balancer to the load
+// balancer described by the LXCCluster spec. It returns true once the handover is complete.
+//
+// Handovers that the new load balancer cannot complete (e.g. because it cannot serve an IP address control plane
+// endpoint) are rejected before any resources are touched.
+//
+// The new load balancer is created and configured with the control plane backends, the DNS records are pointed to the
+// new load balancer addresses once it is healthy, and the active load balancer is deleted last.
+//
+// The exception is a load balancer that claims an IP address control plane endpoint itself ("ovn" and "kube-vip"),
+// which can only take over the address once the active load balancer has released it. In that case, the active load
+// balancer is deleted first, and the new load balancer is created once no instance has the endpoint address configured
+// anymore.
+func (r *LXCClusterReconciler) reconcileLoadBalancerHandover(ctx context.Context, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client, activeManager loadbalancer.Manager, lbManager loadbalancer.Manager) (bool, ctrl.Result, error) {
+	activeSpec := lxcCluster.Status.LoadBalancer.ActiveSpec
+	from, to := loadbalancer.Identity(*activeSpec), loadbalancer.Identity(lxcCluster.Spec.LoadBalancer)
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("from", from, "to", to))
+
+	if err := loadbalancer.ValidateHandover(*activeSpec, lxcCluster.Spec.LoadBalancer, lxcCluster.Spec.ControlPlaneEndpoint.Host); err != nil {
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverAbortedReason, clusterv1.ConditionSeverityError, "%s", err)
+		return false, ctrl.Result{}, nil
+	}
+
+	log.FromContext(ctx).Info("Switching load balancer")
+	conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverInProgressReason, clusterv1.ConditionSeverityInfo, "Switching load balancer from %q to %q", from, to)
+
+	endpointIP := lxcCluster.Spec.ControlPlaneEndpoint.Host
+	if net.ParseIP(endpointIP) == nil {
+		endpointIP = ""
+	}
+	releaseFirst := endpointIP != "" && loadbalancer.HandoverClaimsAddress(lxcCluster.Spec.LoadBalancer)
+
+	if !releaseFirst {
+		// Keep the active load balancer up to date until the handover is complete.
+		if synced, err := activeManager.IsSynced(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to check active load balancer backends")
+		} else if !synced {
+			if err := activeManager.Reconfigure(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "Failed to reconfigure active load balancer")
+			}
+		}
+	} else {
+		// Release the endpoint address, such that the new load balancer can take it over.
+		log.FromContext(ctx).Info("Deleting previous load balancer to release the control plane endpoint address", "endpoint", endpointIP)
+		if err := activeManager.Delete(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to delete previous load balancer")
+			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverFailedReason, clusterv1.ConditionSeverityWarning, "Failed to delete previous load balancer: %s", err)
+			return false, ctrl.Result{}, err
+		}
+
+		holders, err := instancesWithAddress(ctx, lxcClient, endpointIP)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "Failed to check control plane endpoint address")
+			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverFailedReason, clusterv1.ConditionSeverityWarning, "Failed to check control plane endpoint address: %s", err)
+			return false, ctrl.Result{}, err
+		}
+		if len(holders) > 0 {
+			log.FromContext(ctx).Info("Waiting for previous load balancer to release the control plane endpoint address", "endpoint", endpointIP, "instances", holders)
+			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverInProgressReason, clusterv1.ConditionSeverityInfo, "Waiting for instances %v to release the control plane endpoint address %s", holders, endpointIP)
+			return false, ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+	}
+
+	// Create the new load balancer.
+	lbIPs, err := lbManager.Create(ctx)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to provision new load balancer")
+		if utils.IsTerminalError(err) {
+			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverAbortedReason, clusterv1.ConditionSeverityError, "The new load balancer could not be provisioned. The error was: %s", err)
+			return false, ctrl.Result{}, nil
+		}
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverFailedReason, clusterv1.ConditionSeverityWarning, "Failed to provision new load balancer: %s", err)
+		return false, ctrl.Result{}, err
+	}
+
+	// The control plane endpoint cannot change, so the new load balancer must serve the endpoint address. This is
+	// checked by ValidateHandover, but the new load balancer is only trusted once it reports the address.
+	// DNS names are pointed to the new load balancer addresses below, or by the user if ControlPlaneEndpointDNS is not used.
+	if endpointIP != "" && !slices.Contains(lbIPs, endpointIP) {
+		log.FromContext(ctx).Info("New load balancer does not serve the control plane endpoint, deleting it", "endpoint", endpointIP, "addresses", lbIPs)
+		if err := lbManager.Delete(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to delete new load balancer")
+		}
+		if releaseFirst {
+			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverAbortedReason, clusterv1.ConditionSeverityError, "The addresses %v of the new load balancer do not include the control plane endpoint address %s. Revert spec.loadBalancer to restore the previous load balancer", lbIPs, endpointIP)
+		} else {
+			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverAbortedReason, clusterv1.ConditionSeverityError, "The addresses %v of the new load balancer do not include the control plane endpoint address %s. The previous load balancer keeps serving the control plane endpoint", lbIPs, endpointIP)
+		}
+		return false, ctrl.Result{}, nil
+	}
+
+	// Point the new load balancer to the control plane backends.
+	if err := lbManager.Reconfigure(ctx); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to reconfigure new load balancer")
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverFailedReason, clusterv1.ConditionSeverityWarning, "Failed to reconfigure new load balancer: %s", err)
+		return false, ctrl.Result{}, err
+	}
+
+	// Wait for the new load balancer to become healthy. Load balancers that do not report backends, or whose backend
+	// health cannot be retrieved (e.g. because the load balancer image lacks the tools to query it), are considered
+	// healthy once configured.
+	backends, err := lbManager.Backends(ctx)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to retrieve new load balancer backends, assuming the new load balancer is healthy")
+		backends = nil
+	}
+	healthy := slices.ContainsFunc(backends, func(b infrav1.LXCLoadBalancerBackendStatus) bool {
+		return b.Health == infrav1.LoadBalancerBackendHealthy
+	})
+	if len(backends) > 0 && !healthy {
+		log.FromContext(ctx).Info("Waiting for new load balancer to report healthy backends")
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverInProgressReason, clusterv1.ConditionSeverityInfo, "Waiting for new load balancer %q to report healthy backends", to)
+		return false, ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	if endpointIP == "" {
+		// Point the DNS name of the control plane endpoint to the new load balancer, before deleting the previous one.
+		if err := r.reconcileControlPlaneEndpointDNS(ctx, lxcCluster, lxcClient, lbIPs); err != nil {
+			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverFailedReason, clusterv1.ConditionSeverityWarning, "Failed to register control plane endpoint DNS records: %s", err)
+			if utils.IsTerminalError(err) {
+				return false, ctrl.Result{}, nil
+			}
+			return false, ctrl.Result{}, fmt.Errorf("failed to register control plane endpoint DNS records: %w", err)
+		}
+	}
+
+	if !releaseFirst {
+		// Delete the resources of the previous load balancer.
+		log.FromContext(ctx).Info("Deleting previous load balancer")
+		if err := activeManager.Delete(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to delete previous load balancer")
+			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverFailedReason, clusterv1.ConditionSeverityWarning, "Failed to delete previous load balancer: %s", err)
+			return false, ctrl.Result{}, err
+		}
+	}
+
+	lxcCluster.Status.LoadBalancer.ActiveSpec = lxcCluster.Spec.LoadBalancer.DeepCopy()
+	conditions.MarkTrue(lxcCluster, infrav1.LoadBalancerHandoverCondition)
+	log.FromContext(ctx).Info("Load balancer handover complete")
+
+	return true, ctrl.Result{}, nil
+}
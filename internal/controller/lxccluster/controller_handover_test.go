@@ -0,0 +1,189 @@
+package lxccluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// fakeInstanceLister is an instanceLister that returns a static list of instances.
+type fakeInstanceLister []api.InstanceFull
+
+func (f fakeInstanceLister) ListInstances(ctx context.Context, filters ...lxc.ListInstanceFilter) ([]api.InstanceFull, error) {
+	return f, nil
+}
+
+func TestInstancesWithAddress(t *testing.T) {
+	newInstance := func(name string, addresses ...string) api.InstanceFull {
+		network := api.InstanceStateNetwork{}
+		for _, address := range addresses {
+			network.Addresses = append(network.Addresses, api.InstanceStateNetworkAddress{Family: "inet", Address: address})
+		}
+		return api.InstanceFull{
+			Instance: api.Instance{Name: name},
+			State:    &api.InstanceState{Network: map[string]api.InstanceStateNetwork{"eth0": network}},
+		}
+	}
+
+	lister := fakeInstanceLister{
+		newInstance("c1-lb", "10.0.0.10"),
+		newInstance("c1-control-plane-1", "10.0.0.11", "10.0.0.100"),
+		newInstance("c1-control-plane-2", "10.0.0.12"),
+		{Instance: api.Instance{Name: "stopped"}},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		address string
+		expect  []string
+	}{
+		{name: "LoadBalancerInstance", address: "10.0.0.10", expect: []string{"c1-lb"}},
+		{name: "KubeVIP", address: "10.0.0.100", expect: []string{"c1-control-plane-1"}},
+		{name: "Released", address: "10.0.0.200"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			names, err := instancesWithAddress(context.Background(), lister, tc.address)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(names).To(Equal(tc.expect))
+		})
+	}
+}
+
+// handoverManager is a loadbalancer.Manager that records the calls of a load balancer handover.
+type handoverManager struct {
+	loadbalancer.Manager
+
+	name        string
+	addresses   []string
+	backendsErr error
+	calls       *[]string
+}
+
+func (m *handoverManager) Create(context.Context) ([]string, error) {
+	*m.calls = append(*m.calls, fmt.Sprintf("%s.Create", m.name))
+	return m.addresses, nil
+}
+
+func (m *handoverManager) Delete(context.Context) error {
+	*m.calls = append(*m.calls, fmt.Sprintf("%s.Delete", m.name))
+	return nil
+}
+
+func (m *handoverManager) Reconfigure(context.Context) error {
+	*m.calls = append(*m.calls, fmt.Sprintf("%s.Reconfigure", m.name))
+	return nil
+}
+
+func (m *handoverManager) IsSynced(context.Context) (bool, error) {
+	return true, nil
+}
+
+func (m *handoverManager) Backends(context.Context) ([]infrav1.LXCLoadBalancerBackendStatus, error) {
+	return nil, m.backendsErr
+}
+
+func TestReconcileLoadBalancerHandover(t *testing.T) {
+	kubeVIP := infrav1.LXCClusterLoadBalancer{KubeVIP: &infrav1.LXCLoadBalancerKubeVIP{}}
+	lxcLB := infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{}}
+	external := infrav1.LXCClusterLoadBalancer{External: &infrav1.LXCLoadBalancerExternal{}}
+
+	for _, tc := range []struct {
+		name         string
+		active, spec infrav1.LXCClusterLoadBalancer
+		endpoint     string
+		newAddresses []string
+		backendsErr  error
+
+		expectDone   bool
+		expectReason string
+		expectCalls  []string
+	}{
+		{
+			// A dedicated "lxc" load balancer cannot serve the endpoint address, so the active load balancer must be kept.
+			name:         "IP/DedicatedTarget",
+			active:       kubeVIP,
+			spec:         lxcLB,
+			endpoint:     "10.0.0.10",
+			expectReason: infrav1.LoadBalancerHandoverAbortedReason,
+		},
+		{
+			name:         "IP/ExternalTarget",
+			active:       kubeVIP,
+			spec:         external,
+			endpoint:     "10.0.0.10",
+			newAddresses: []string{"10.0.0.10"},
+			expectDone:   true,
+			expectCalls:  []string{"new.Create", "new.Reconfigure", "active.Delete"},
+		},
+		{
+			name:         "IP/TargetDoesNotServeAddress",
+			active:       kubeVIP,
+			spec:         external,
+			endpoint:     "10.0.0.10",
+			newAddresses: []string{"10.0.0.20"},
+			expectReason: infrav1.LoadBalancerHandoverAbortedReason,
+			expectCalls:  []string{"new.Create", "new.Delete"},
+		},
+		{
+			name:         "DNS/DedicatedTarget",
+			active:       kubeVIP,
+			spec:         lxcLB,
+			endpoint:     "c1.example.com",
+			newAddresses: []string{"10.0.0.20"},
+			expectDone:   true,
+			expectCalls:  []string{"new.Create", "new.Reconfigure", "active.Delete"},
+		},
+		{
+			// Backend health that cannot be retrieved does not block the handover.
+			name:         "DNS/BackendsUnavailable",
+			active:       kubeVIP,
+			spec:         lxcLB,
+			endpoint:     "c1.example.com",
+			newAddresses: []string{"10.0.0.20"},
+			backendsErr:  fmt.Errorf("failed to retrieve haproxy stats"),
+			expectDone:   true,
+			expectCalls:  []string{"new.Create", "new.Reconfigure", "active.Delete"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			lxcCluster := &infrav1.LXCCluster{
+				Spec: infrav1.LXCClusterSpec{
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: tc.endpoint, Port: 6443},
+					LoadBalancer:         tc.spec,
+				},
+				Status: infrav1.LXCClusterStatus{
+					LoadBalancer: &infrav1.LXCClusterLoadBalancerStatus{ActiveSpec: tc.active.DeepCopy()},
+				},
+			}
+
+			var calls []string
+			active := &handoverManager{name: "active", calls: &calls}
+			lbManager := &handoverManager{name: "new", addresses: tc.newAddresses, backendsErr: tc.backendsErr, calls: &calls}
+
+			done, _, err := (&LXCClusterReconciler{}).reconcileLoadBalancerHandover(context.Background(), lxcCluster, nil, active, lbManager)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(done).To(Equal(tc.expectDone))
+			g.Expect(calls).To(Equal(tc.expectCalls))
+			if tc.expectDone {
+				g.Expect(conditions.IsTrue(lxcCluster, infrav1.LoadBalancerHandoverCondition)).To(BeTrue())
+				g.Expect(lxcCluster.Status.LoadBalancer.ActiveSpec).To(Equal(tc.spec.DeepCopy()))
+			} else {
+				g.Expect(conditions.GetReason(lxcCluster, infrav1.LoadBalancerHandoverCondition)).To(Equal(tc.expectReason))
+				g.Expect(lxcCluster.Status.LoadBalancer.ActiveSpec).To(Equal(tc.active.DeepCopy()))
+			}
+		})
+	}
+}
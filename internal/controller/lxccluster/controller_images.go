@@ -0,0 +1,288 @@
+package lxccluster
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/instances"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// imagePreflightTarget is a Kubernetes version that machines of a LXCMachineTemplate are rolled out with.
+type imagePreflightTarget struct {
+	// role is "control-plane" or "worker".
+	role string
+	// version is the Kubernetes version, e.g. "v1.33.0".
+	version string
+	// template is the name of the LXCMachineTemplate.
+	template string
+}
+
+// imagePreflightCacheTTL is the duration for which the results of image availability checks are cached. Images are
+// re-checked after the TTL, as images may be published or removed in the meantime.
+const imagePreflightCacheTTL = 10 * time.Minute
+
+// imagePreflightKey identifies an image availability check. The generation of the LXCMachineTemplate changes when
+// its spec changes, so the check is repeated when the image of the template changes.
+type imagePreflightKey struct {
+	// cluster is the UID of the LXCCluster, as clusters may use different Incus servers.
+	cluster types.UID
+	// registryMirrors are the registry mirrors of the LXCCluster, as they change the source of OCI images.
+	registryMirrors string
+
+	target     imagePreflightTarget
+	generation int64
+}
+
+// imagePreflightResult is the cached result of an image availability check.
+type imagePreflightResult struct {
+	err       error
+	checkedAt time.Time
+}
+
+// imagePreflightCache caches the results of image availability checks, such that the upstream servers of the images
+// are not contacted on every reconcile of a LXCCluster. Only definitive results are cached, i.e. images that are
+// available or not available. Checks that failed (e.g. due to a network error) are retried on the next reconcile.
+type imagePreflightCache struct {
+	mu      sync.Mutex
+	results map[imagePreflightKey]imagePreflightResult
+}
+
+// get returns the cached result of a check, if any.
+func (c *imagePreflightCache) get(key imagePreflightKey) (imagePreflightResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.results[key]
+	if !ok || time.Since(result.checkedAt) >= imagePreflightCacheTTL {
+		return imagePreflightResult{}, false
+	}
+	return result, true
+}
+
+// set caches the result of a check, if it is definitive. Expired results are removed.
+func (c *imagePreflightCache) set(key imagePreflightKey, err error) {
+	if err != nil && !utils.IsTerminalError(err) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.results == nil {
+		c.results = make(map[imagePreflightKey]imagePreflightResult)
+	}
+	maps.DeleteFunc(c.results, func(_ imagePreflightKey, result imagePreflightResult) bool {
+		return time.Since(result.checkedAt) >= imagePreflightCacheTTL
+	})
+	c.results[key] = imagePreflightResult{err: err, checkedAt: time.Now()}
+}
+
+// reconcileImagesPreflight checks that the images for Kubernetes versions that are about to be rolled out are
+// available, such that a version upgrade does not create machines that fail to provision. Versions that already
+// run on machines of the cluster are not checked. Failures are surfaced on the ImagesAvailable condition, and do
+// not block the reconciliation of the LXCCluster. Results are cached (see imagePreflightCache).
+func (r *LXCClusterReconciler) reconcileImagesPreflight(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client) {
+	targets, err := r.getImagePreflightTargets(ctx, cluster)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to retrieve Kubernetes versions of cluster")
+		conditions.MarkFalse(lxcCluster, infrav1.ImagesAvailableCondition, infrav1.ImageCheckFailedReason, clusterv1.ConditionSeverityWarning, "Failed to retrieve Kubernetes versions of cluster: %s", err)
+		return
+	}
+
+	machines := &clusterv1.MachineList{}
+	if err := r.List(ctx, machines, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterNameLabel: cluster.Name}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list machines of cluster")
+		conditions.MarkFalse(lxcCluster, infrav1.ImagesAvailableCondition, infrav1.ImageCheckFailedReason, clusterv1.ConditionSeverityWarning, "Failed to list machines of cluster: %s", err)
+		return
+	}
+
+	registryMirrors := instances.RegistryMirrorEndpoints(lxcCluster.Spec.Registry)
+
+	var notAvailable, failed []string
+	for _, target := range pendingImagePreflightTargets(targets, machines.Items) {
+		lxcMachineTemplate := &infrav1.LXCMachineTemplate{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: target.template}, lxcMachineTemplate); err != nil {
+			failed = append(failed, fmt.Sprintf("failed to retrieve LXCMachineTemplate %q: %s", target.template, err))
+			continue
+		}
+
+		spec := lxcMachineTemplate.Spec.Template.Spec
+		data := instances.MachineTemplateData{
+			Architecture:     spec.Architecture,
+			InstanceType:     spec.InstanceType,
+			ClusterName:      cluster.Name,
+			ClusterNamespace: cluster.Namespace,
+			Role:             target.role,
+		}.WithVersion(target.version)
+		if data.InstanceType == "" {
+			data.InstanceType = lxc.Container
+		}
+		if data.Architecture == "" {
+			data.Architecture = lxc.KubernetesArchitecture(lxcClient.GetArchitecture())
+		}
+
+		key := imagePreflightKey{cluster: lxcCluster.UID, registryMirrors: fmt.Sprint(registryMirrors), target: target, generation: lxcMachineTemplate.Generation}
+		result, ok := r.imagePreflightCache.get(key)
+		if !ok {
+			log.FromContext(ctx).V(1).Info("Checking image availability", "template", target.template, "version", target.version)
+			result.err = checkPreflightImage(lxcClient, spec, data, registryMirrors)
+			r.imagePreflightCache.set(key, result.err)
+		}
+		if err := result.err; err != nil {
+			msg := fmt.Sprintf("image for Kubernetes version %q of LXCMachineTemplate %q: %s", target.version, target.template, err)
+			if utils.IsTerminalError(err) {
+				notAvailable = append(notAvailable, msg)
+			} else {
+				failed = append(failed, msg)
+			}
+		}
+	}
+
+	switch {
+	case len(notAvailable) > 0:
+		log.FromContext(ctx).Info("WARNING: Images for Kubernetes versions that are being rolled out are not available", "errors", notAvailable)
+		conditions.MarkFalse(lxcCluster, infrav1.ImagesAvailableCondition, infrav1.ImageNotAvailableReason, clusterv1.ConditionSeverityWarning, "Machines will fail to provision, as images are not available: %s", strings.Join(append(notAvailable, failed...), "; "))
+	case len(failed) > 0:
+		log.FromContext(ctx).Info("Failed to check availability of images", "errors", failed)
+		conditions.MarkFalse(lxcCluster, infrav1.ImagesAvailableCondition, infrav1.ImageCheckFailedReason, clusterv1.ConditionSeverityWarning, "Failed to check availability of images: %s", strings.Join(failed, "; "))
+	default:
+		conditions.MarkTrue(lxcCluster, infrav1.ImagesAvailableCondition)
+	}
+}
+
+// getImagePreflightTargets returns the Kubernetes versions and LXCMachineTemplates of the control plane and the
+// MachineDeployments of a cluster.
+func (r *LXCClusterReconciler) getImagePreflightTargets(ctx context.Context, cluster *clusterv1.Cluster) ([]imagePreflightTarget, error) {
+	var targets []imagePreflightTarget
+
+	if ref := cluster.Spec.ControlPlaneRef; ref != nil {
+		controlPlane, err := external.Get(ctx, r.Client, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve control plane %s %q: %w", ref.Kind, ref.Name, err)
+		}
+		version, _, _ := unstructured.NestedString(controlPlane.Object, "spec", "version")
+		kind, _, _ := unstructured.NestedString(controlPlane.Object, "spec", "machineTemplate", "infrastructureRef", "kind")
+		name, _, _ := unstructured.NestedString(controlPlane.Object, "spec", "machineTemplate", "infrastructureRef", "name")
+		if version != "" && kind == "LXCMachineTemplate" && name != "" {
+			targets = append(targets, imagePreflightTarget{role: "control-plane", version: version, template: name})
+		}
+	}
+
+	machineDeployments := &clusterv1.MachineDeploymentList{}
+	if err := r.List(ctx, machineDeployments, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterNameLabel: cluster.Name}); err != nil {
+		return nil, fmt.Errorf("failed to list MachineDeployments: %w", err)
+	}
+	for _, md := range machineDeployments.Items {
+		if version, ref := md.Spec.Template.Spec.Version, md.Spec.Template.Spec.InfrastructureRef; version != nil && *version != "" && ref.Kind == "LXCMachineTemplate" {
+			targets = append(targets, imagePreflightTarget{role: "worker", version: *version, template: ref.Name})
+		}
+	}
+
+	return targets, nil
+}
+
+// pendingImagePreflightTargets returns the targets with a Kubernetes version that does not run on any machine of
+// the same role yet. Duplicate targets are removed.
+func pendingImagePreflightTargets(targets []imagePreflightTarget, machines []clusterv1.Machine) []imagePreflightTarget {
+	current := map[imagePreflightTarget]struct{}{}
+	for _, machine := range machines {
+		if machine.Spec.Version == nil {
+			continue
+		}
+		role := "worker"
+		if util.IsControlPlaneMachine(&machine) {
+			role = "control-plane"
+		}
+		current[imagePreflightTarget{role: role, version: *machine.Spec.Version}] = struct{}{}
+	}
+
+	var pending []imagePreflightTarget
+	for _, target := range targets {
+		if _, ok := current[imagePreflightTarget{role: target.role, version: target.version}]; ok {
+			continue
+		}
+		if slices.Contains(pending, target) {
+			continue
+		}
+		pending = append(pending, target)
+	}
+	return pending
+}
+
+// preflightImage returns the image that machines of a LXCMachineTemplate are launched from. It follows the image
+// resolution of the LXCMachine controller: templates and `VERSION` in the image name are replaced, image prefixes
+// are parsed, and the default kubeadm (or kindest/node) image is used if no image is set.
+func preflightImage(spec infrav1.LXCMachineSpec, data instances.MachineTemplateData, serverName string, registryMirrors map[string]string) (lxc.Image, error) {
+	rendered, err := instances.RenderMachineSpec(spec, data)
+	if err != nil {
+		return lxc.Image{}, err
+	}
+
+	imageSpec := rendered.Image
+	imageSpec.Name = strings.ReplaceAll(imageSpec.Name, "VERSION", data.Version)
+
+	var image lxc.ImageFamily = lxc.Image{
+		Protocol:    imageSpec.Protocol,
+		Server:      imageSpec.Server,
+		Fingerprint: imageSpec.Fingerprint,
+		Alias:       imageSpec.Name,
+	}
+	if imageSpec.Name != "" {
+		parsed, isParsed, err := lxc.ParseImage(imageSpec.Name)
+		if err != nil {
+			return lxc.Image{}, utils.TerminalError(fmt.Errorf("failed to parse image %q: %w", imageSpec.Name, err))
+		} else if isParsed {
+			image = parsed
+		}
+	} else if imageSpec.IsZero() {
+		if rendered.InstanceType == "kind" {
+			image = lxc.KindestNodeImage(data.Version)
+		} else {
+			image = lxc.CapnImage(fmt.Sprintf("kubeadm/%s", data.Version))
+		}
+	}
+
+	resolved, err := image.For(serverName)
+	if err != nil {
+		return lxc.Image{}, err
+	}
+	return resolved.WithRegistryMirrors(registryMirrors), nil
+}
+
+// checkPreflightImage checks that the image of a LXCMachineTemplate is available for the instance type and the
+// architecture of the template. Images that are not pulled from a simplestreams server or an OCI registry (e.g.
+// local images) are not checked. In offline mode (see lxc.SetOfflineImages), the image is only resolved against the
+// local image store of the server, and the upstream server of the image is never contacted.
+func checkPreflightImage(lxcClient *lxc.Client, spec infrav1.LXCMachineSpec, data instances.MachineTemplateData, registryMirrors map[string]string) error {
+	image, err := preflightImage(spec, data, lxcClient.GetServerName(), registryMirrors)
+	if err != nil {
+		return err
+	}
+	if image.Protocol != lxc.Simplestreams && image.Protocol != lxc.OCI {
+		return nil
+	}
+
+	instanceType := api.InstanceTypeContainer
+	if spec.InstanceType == lxc.VirtualMachine {
+		instanceType = api.InstanceTypeVM
+	}
+	return lxcClient.CheckImage(image, instanceType, lxc.IncusArchitecture(spec.Architecture))
+}
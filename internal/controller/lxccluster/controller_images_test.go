@@ -0,0 +1,154 @@
+package lxccluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/instances"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestPendingImagePreflightTargets(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := func(version string, controlPlane bool) clusterv1.Machine {
+		m := clusterv1.Machine{Spec: clusterv1.MachineSpec{Version: &version}}
+		if controlPlane {
+			m.ObjectMeta = metav1.ObjectMeta{Labels: map[string]string{clusterv1.MachineControlPlaneLabel: ""}}
+		}
+		return m
+	}
+
+	targets := []imagePreflightTarget{
+		{role: "control-plane", version: "v1.33.0", template: "cp"},
+		{role: "worker", version: "v1.33.0", template: "md-0"},
+		{role: "worker", version: "v1.33.0", template: "md-0"},
+		{role: "worker", version: "v1.33.0", template: "md-1"},
+	}
+
+	g.Expect(pendingImagePreflightTargets(targets, nil)).To(Equal([]imagePreflightTarget{
+		{role: "control-plane", version: "v1.33.0", template: "cp"},
+		{role: "worker", version: "v1.33.0", template: "md-0"},
+		{role: "worker", version: "v1.33.0", template: "md-1"},
+	}))
+
+	// control plane was upgraded, workers are pending
+	g.Expect(pendingImagePreflightTargets(targets, []clusterv1.Machine{machine("v1.33.0", true), machine("v1.32.0", false)})).To(Equal([]imagePreflightTarget{
+		{role: "worker", version: "v1.33.0", template: "md-0"},
+		{role: "worker", version: "v1.33.0", template: "md-1"},
+	}))
+
+	// all machines run the target version
+	g.Expect(pendingImagePreflightTargets(targets, []clusterv1.Machine{machine("v1.33.0", true), machine("v1.33.0", false)})).To(BeEmpty())
+}
+
+func TestPreflightImage(t *testing.T) {
+	data := instances.MachineTemplateData{Architecture: "amd64", InstanceType: "container", Role: "worker"}.WithVersion("v1.33.0")
+
+	for _, tc := range []struct {
+		name              string
+		spec              infrav1.LXCMachineSpec
+		expectImage       lxc.Image
+		expectTerminalErr bool
+	}{
+		{
+			name:        "DefaultKubeadm",
+			expectImage: lxc.CapnImage("kubeadm/v1.33.0"),
+		},
+		{
+			name:        "DefaultKind",
+			spec:        infrav1.LXCMachineSpec{InstanceType: "kind"},
+			expectImage: lxc.KindestNodeImage("v1.33.0"),
+		},
+		{
+			name:        "Version",
+			spec:        infrav1.LXCMachineSpec{Image: infrav1.LXCMachineImageSource{Name: "capi:kubeadm/VERSION/debian"}},
+			expectImage: lxc.CapnImage("kubeadm/v1.33.0/debian"),
+		},
+		{
+			name:        "Template",
+			spec:        infrav1.LXCMachineSpec{Image: infrav1.LXCMachineImageSource{Name: "capi:kubeadm/v{{ .Major }}.{{ .Minor }}/{{ .Architecture }}"}},
+			expectImage: lxc.CapnImage("kubeadm/v1.33/amd64"),
+		},
+		{
+			name:        "Explicit",
+			spec:        infrav1.LXCMachineSpec{Image: infrav1.LXCMachineImageSource{Name: "kubeadm/VERSION", Server: "https://images.example.com", Protocol: "simplestreams"}},
+			expectImage: lxc.Image{Protocol: lxc.Simplestreams, Server: "https://images.example.com", Alias: "kubeadm/v1.33.0"},
+		},
+		{
+			name:        "Local",
+			spec:        infrav1.LXCMachineSpec{Image: infrav1.LXCMachineImageSource{Name: "local-kubeadm"}},
+			expectImage: lxc.Image{Alias: "local-kubeadm"},
+		},
+		{
+			name:              "UnknownPrefix",
+			spec:              infrav1.LXCMachineSpec{Image: infrav1.LXCMachineImageSource{Name: "unknown:image"}},
+			expectTerminalErr: true,
+		},
+		{
+			name:              "InvalidTemplate",
+			spec:              infrav1.LXCMachineSpec{Image: infrav1.LXCMachineImageSource{Name: "capi:kubeadm/{{ .Unknown }}"}},
+			expectTerminalErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			image, err := preflightImage(tc.spec, data, lxc.Incus, nil)
+			if tc.expectTerminalErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(image).To(Equal(tc.expectImage))
+		})
+	}
+}
+
+func TestImagePreflightCache(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &imagePreflightCache{}
+	key := imagePreflightKey{cluster: "uid", target: imagePreflightTarget{role: "worker", version: "v1.33.0", template: "md-0"}, generation: 1}
+
+	_, ok := c.get(key)
+	g.Expect(ok).To(BeFalse())
+
+	// available images are cached
+	c.set(key, nil)
+	result, ok := c.get(key)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(result.err).ToNot(HaveOccurred())
+
+	// a new generation of the template is checked again
+	newGeneration := key
+	newGeneration.generation = 2
+	_, ok = c.get(newGeneration)
+	g.Expect(ok).To(BeFalse())
+
+	// images that are not available are cached
+	c.set(newGeneration, utils.TerminalError(fmt.Errorf("image not found")))
+	result, ok = c.get(newGeneration)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(utils.IsTerminalError(result.err)).To(BeTrue())
+
+	// failed checks are not cached
+	failed := key
+	failed.target.version = "v1.34.0"
+	c.set(failed, fmt.Errorf("network error"))
+	_, ok = c.get(failed)
+	g.Expect(ok).To(BeFalse())
+
+	// expired results are checked again
+	c.results[key] = imagePreflightResult{checkedAt: time.Now().Add(-imagePreflightCacheTTL)}
+	_, ok = c.get(key)
+	g.Expect(ok).To(BeFalse())
+}
@@ -2,9 +2,14 @@ package lxccluster
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
@@ -13,19 +18,51 @@ import (
 	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
 
-func (r *LXCClusterReconciler) reconcileNormal(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client) error {
+func (r *LXCClusterReconciler) reconcileNormal(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client) (ctrl.Result, error) {
+	// Check images of upcoming Kubernetes versions, before machines are rolled out.
+	r.reconcileImagesPreflight(ctx, cluster, lxcCluster, lxcClient)
+
+	lbOpts, err := loadbalancer.ManagerOptionsForCluster(ctx, r.Client, lxcCluster)
+	if err != nil {
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerProvisioningFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+		return ctrl.Result{}, err
+	}
+	lbManager := loadbalancer.ManagerForCluster(cluster, lxcCluster, lxcClient, lbOpts...)
+
+	if lxcCluster.Status.LoadBalancer == nil {
+		lxcCluster.Status.LoadBalancer = &infrav1.LXCClusterLoadBalancerStatus{}
+	}
+
+	// Hand over the control plane endpoint if the load balancer type has changed.
+	activeManager, err := r.activeLoadBalancerManager(ctx, cluster, lxcCluster, lxcClient)
+	if err != nil {
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerHandoverCondition, infrav1.LoadBalancerHandoverFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+		return ctrl.Result{}, err
+	}
+	if activeManager != nil {
+		if done, result, err := r.reconcileLoadBalancerHandover(ctx, lxcCluster, lxcClient, activeManager, lbManager); !done || err != nil {
+			return result, err
+		}
+	}
+
+	// Use the DNS name as the control plane endpoint, such that the load balancer addresses can change.
+	if dns := lxcCluster.Spec.ControlPlaneEndpointDNS; dns != nil && lxcCluster.Spec.ControlPlaneEndpoint.Host == "" {
+		lxcCluster.Spec.ControlPlaneEndpoint.Host = strings.TrimSuffix(dns.Name, ".")
+	}
+
 	// Create the container hosting the load balancer.
 	log.FromContext(ctx).Info("Creating load balancer")
-	lbIPs, err := loadbalancer.ManagerForCluster(cluster, lxcCluster, lxcClient).Create(ctx)
+	lbIPs, err := lbManager.Create(ctx)
 	if err != nil {
 		log.FromContext(ctx).Error(err, "Failed to provision load balancer")
 		if utils.IsTerminalError(err) {
 			conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerProvisioningAbortedReason, clusterv1.ConditionSeverityError, "The cluster load balancer could not be provisioned. The error was: %s", err)
-			return nil
+			return ctrl.Result{}, nil
 		}
 		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerProvisioningFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
-		return err
+		return ctrl.Result{}, err
 	}
+	lxcCluster.Status.LoadBalancer.ActiveSpec = lxcCluster.Spec.LoadBalancer.DeepCopy()
 
 	// Surface the control plane endpoint
 	if lxcCluster.Spec.ControlPlaneEndpoint.Host == "" {
@@ -33,12 +70,105 @@ func (r *LXCClusterReconciler) reconcileNormal(ctx context.Context, cluster *clu
 		lxcCluster.Spec.ControlPlaneEndpoint.Host = lbIPs[0]
 	}
 	if lxcCluster.Spec.ControlPlaneEndpoint.Port == 0 {
-		lxcCluster.Spec.ControlPlaneEndpoint.Port = 6443
+		port := int32(6443)
+
+		// Shared load balancers assign a distinct port to each member cluster.
+		if assigner, ok := lbManager.(loadbalancer.EndpointPortAssigner); ok {
+			if port, err = assigner.ControlPlaneEndpointPort(ctx); err != nil {
+				conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerProvisioningFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+				return ctrl.Result{}, fmt.Errorf("failed to retrieve control plane endpoint port: %w", err)
+			}
+		}
+		lxcCluster.Spec.ControlPlaneEndpoint.Port = port
+	}
+
+	// Register the DNS records of the control plane endpoint
+	if err := r.reconcileControlPlaneEndpointDNS(ctx, lxcCluster, lxcClient, lbIPs); err != nil {
+		if utils.IsTerminalError(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to register control plane endpoint DNS records: %w", err)
 	}
 
 	// Mark the lxcCluster ready
 	lxcCluster.Status.Ready = true
 	conditions.MarkTrue(lxcCluster, infrav1.LoadBalancerAvailableCondition)
 
-	return nil
+	return r.reconcileLoadBalancerBackends(ctx, lxcCluster, lbManager)
+}
+
+// reconcileLoadBalancerBackends ensures that the load balancer backends match the control plane instances of the cluster.
+// This is required to recover from instance addresses changing, instances being replaced out of band, or previous
+// reconfigure operations that failed partway.
+func (r *LXCClusterReconciler) reconcileLoadBalancerBackends(ctx context.Context, lxcCluster *infrav1.LXCCluster, lbManager loadbalancer.Manager) (ctrl.Result, error) {
+	// Retrying will not help until the load balancer spec or the control plane instances change.
+	configInvalid := func(err error) (ctrl.Result, error) {
+		log.FromContext(ctx).Error(err, "Load balancer configuration is invalid")
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerBackendsSyncedCondition, infrav1.LoadBalancerConfigInvalidReason, clusterv1.ConditionSeverityError, "The load balancer configuration is invalid: %s", err)
+		return ctrl.Result{RequeueAfter: r.LoadBalancerSyncPeriod}, nil
+	}
+
+	synced, err := lbManager.IsSynced(ctx)
+	if err != nil {
+		if loadbalancer.IsConfigValidationError(err) {
+			return configInvalid(err)
+		}
+		log.FromContext(ctx).Error(err, "Failed to check load balancer backends")
+		conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerBackendsSyncedCondition, infrav1.LoadBalancerBackendsSyncFailedReason, clusterv1.ConditionSeverityWarning, "Failed to check load balancer backends: %s", err)
+		return ctrl.Result{}, fmt.Errorf("failed to check load balancer backends: %w", err)
+	}
+
+	if lxcCluster.Status.LoadBalancer == nil {
+		lxcCluster.Status.LoadBalancer = &infrav1.LXCClusterLoadBalancerStatus{}
+	}
+
+	if !synced {
+		log.FromContext(ctx).Info("Load balancer backends are out of sync, reconfiguring load balancer")
+		if err := lbManager.Reconfigure(ctx); err != nil {
+			switch {
+			case loadbalancer.IsConfigValidationError(err):
+				return configInvalid(err)
+			case loadbalancer.IsReloadError(err):
+				conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerBackendsSyncedCondition, infrav1.LoadBalancerReloadFailedReason, clusterv1.ConditionSeverityWarning, "Failed to reload load balancer, previous configuration was restored: %s", err)
+			default:
+				conditions.MarkFalse(lxcCluster, infrav1.LoadBalancerBackendsSyncedCondition, infrav1.LoadBalancerBackendsSyncFailedReason, clusterv1.ConditionSeverityWarning, "Failed to reconfigure load balancer: %s", err)
+			}
+			return ctrl.Result{}, fmt.Errorf("failed to reconfigure load balancer: %w", err)
+		}
+		now := metav1.Now()
+		lxcCluster.Status.LoadBalancer.LastReconfigureTime = &now
+	}
+
+	conditions.MarkTrue(lxcCluster, infrav1.LoadBalancerBackendsSyncedCondition)
+
+	// Surface the load balancer backends and their health.
+	// Failing to retrieve backend health is not fatal, the backends will be refreshed on the next sync.
+	if backends, err := lbManager.Backends(ctx); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to retrieve load balancer backends")
+	} else {
+		setLoadBalancerBackendsStatus(lxcCluster.Status.LoadBalancer, backends)
+	}
+
+	// Periodically re-check the load balancer backends, as instance changes are not always visible through LXCMachine objects.
+	return ctrl.Result{RequeueAfter: r.LoadBalancerSyncPeriod}, nil
+}
+
+// setLoadBalancerBackendsStatus updates the load balancer status with the list of backends and a summary of their health.
+func setLoadBalancerBackendsStatus(status *infrav1.LXCClusterLoadBalancerStatus, backends []infrav1.LXCLoadBalancerBackendStatus) {
+	slices.SortFunc(backends, func(a, b infrav1.LXCLoadBalancerBackendStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var healthy int
+	for _, backend := range backends {
+		if backend.Health == infrav1.LoadBalancerBackendHealthy {
+			healthy++
+		}
+	}
+
+	status.Backends = backends
+	status.HealthyBackends = ""
+	if len(backends) > 0 {
+		status.HealthyBackends = fmt.Sprintf("%d/%d", healthy, len(backends))
+	}
 }
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lxccluster_test
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/controller/lxccluster"
+
+	. "github.com/onsi/gomega"
+)
+
+func newScheme(g *WithT) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	return scheme
+}
+
+func TestLXCClusterReconciler_LXCMachineToLXCCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	lxcMachine := func(clusterName string, name string, controlPlane bool) *infrav1.LXCMachine {
+		labels := map[string]string{}
+		if clusterName != "" {
+			labels[clusterv1.ClusterNameLabel] = clusterName
+		}
+		if controlPlane {
+			labels[clusterv1.MachineControlPlaneLabel] = ""
+		}
+		return &infrav1.LXCMachine{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	var (
+		cluster0 = &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-0"},
+			Spec: clusterv1.ClusterSpec{
+				InfrastructureRef: &corev1.ObjectReference{
+					APIVersion: infrav1.GroupVersion.String(),
+					Kind:       "LXCCluster",
+					Name:       "lxc-cluster-0",
+				},
+			},
+		}
+
+		controlPlane0 = lxcMachine("cluster-0", "lxc-machine-0", true)
+		worker0       = lxcMachine("cluster-0", "lxc-machine-1", false)
+		noCluster     = lxcMachine("", "lxc-machine-2", true)
+		unknown       = lxcMachine("cluster-1", "lxc-machine-3", true)
+	)
+
+	c := fake.NewClientBuilder().WithScheme(newScheme(g)).WithObjects([]client.Object{cluster0, controlPlane0, worker0, noCluster, unknown}...).Build()
+	r := lxccluster.LXCClusterReconciler{
+		Client: c,
+	}
+
+	g.Expect(r.LXCMachineToLXCCluster(context.TODO(), controlPlane0)).To(ConsistOf(
+		HaveField("Name", "lxc-cluster-0"),
+	))
+	g.Expect(r.LXCMachineToLXCCluster(context.TODO(), worker0)).To(BeEmpty())
+	g.Expect(r.LXCMachineToLXCCluster(context.TODO(), noCluster)).To(BeEmpty())
+	g.Expect(r.LXCMachineToLXCCluster(context.TODO(), unknown)).To(BeEmpty())
+}
@@ -35,6 +35,6 @@ func patchLXCCluster(ctx context.Context, patchHelper *patch.Helper, lxcCluster
 	return patchHelper.Patch(
 		ctx,
 		lxcCluster,
-		patch.WithOwnedConditions{Conditions: append(infraConditions, clusterv1.ReadyCondition)},
+		patch.WithOwnedConditions{Conditions: append(infraConditions, infrav1.LoadBalancerBackendsSyncedCondition, infrav1.LoadBalancerHandoverCondition, infrav1.ControlPlaneEndpointDNSRegisteredCondition, infrav1.ImagesAvailableCondition, clusterv1.ReadyCondition)},
 	)
 }
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lxcimage
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/cluster-api/util/finalizers"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// LXCImageReconciler reconciles a LXCImage object
+type LXCImageReconciler struct {
+	client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcimages,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcimages/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcimages/finalizers,verbs=get;update;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *LXCImageReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the lxcImage instance
+	lxcImage := &infrav1.LXCImage{}
+	if err := r.Get(ctx, req.NamespacedName, lxcImage); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Fetch the lxcSecret before adding any finalizers, so that objects without a valid secretRef do not get stuck
+	lxcSecret := &corev1.Secret{}
+	if err := r.Get(ctx, lxcImage.GetLXCSecretNamespacedName(), lxcSecret); err != nil {
+		log.WithValues("secret", lxcImage.GetLXCSecretNamespacedName()).Error(err, "Failed to fetch LXC credentials secret")
+		return ctrl.Result{}, fmt.Errorf("failed to fetch LXC credentials: %w", err)
+	}
+	lxcClient, err := lxc.New(ctx, lxc.ConfigurationFromKubernetesSecret(lxcSecret))
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create incus client: %w", err)
+	}
+
+	// Add finalizer first if not set to avoid the race condition between init and delete.
+	if finalizerAdded, err := finalizers.EnsureFinalizer(ctx, r.Client, lxcImage, infrav1.ImageFinalizer); err != nil || finalizerAdded {
+		return ctrl.Result{}, err
+	}
+
+	// Initialize the patch helper
+	patchHelper, err := patch.NewHelper(lxcImage, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Always attempt to Patch the LXCImage object and status after each reconciliation.
+	defer func() {
+		if err := patchLXCImage(ctx, patchHelper, lxcImage); err != nil {
+			log.Error(err, "Failed to patch LXCImage")
+			if rerr == nil {
+				rerr = err
+			}
+		}
+	}()
+
+	// Handle deleted images
+	if !lxcImage.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, lxcImage, lxcClient)
+	}
+
+	// Handle non-deleted images
+	return r.reconcileNormal(ctx, lxcImage, lxcClient)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LXCImageReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if r.Client == nil {
+		return fmt.Errorf("required field Client must not be nil")
+	}
+
+	predicateLog := ctrl.LoggerFrom(ctx).WithValues("controller", "lxcimage")
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.LXCImage{}).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceHasFilterLabel(mgr.GetScheme(), predicateLog, r.WatchFilterValue)).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed setting up with a controller manager: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package lxcimage
+
+import (
+	"context"
+	"fmt"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+func (r *LXCImageReconciler) reconcileDelete(ctx context.Context, lxcImage *infrav1.LXCImage, lxcClient *lxc.Client) (ctrl.Result, error) {
+	conditions.MarkFalse(lxcImage, infrav1.ImagePulledCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
+
+	// Delete the images downloaded by the LXCImage, unless they are also used by other LXCImage objects.
+	otherImages, err := r.listOtherImagesOnServer(ctx, lxcImage)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := pruneImages(ctx, lxcClient, otherImages, managedImageFingerprints(lxcImage.Status.Members)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to prune images: %w", err)
+	}
+
+	// Images are deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(lxcImage, infrav1.ImageFinalizer)
+
+	return ctrl.Result{}, nil
+}
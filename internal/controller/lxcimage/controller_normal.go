@@ -0,0 +1,199 @@
+package lxcimage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+const (
+	// defaultRefreshInterval is the refresh interval of LXCImage objects without spec.refreshInterval.
+	defaultRefreshInterval = 24 * time.Hour
+
+	// imagePullTimeout is the timeout for pulling an image on a single cluster member.
+	imagePullTimeout = 30 * time.Minute
+)
+
+func (r *LXCImageReconciler) reconcileNormal(ctx context.Context, lxcImage *infrav1.LXCImage, lxcClient *lxc.Client) (ctrl.Result, error) {
+	if lxc.IsOfflineImages() {
+		conditions.MarkFalse(lxcImage, infrav1.ImagePulledCondition, infrav1.ImagePullAbortedReason, clusterv1.ConditionSeverityError, "Image pulls are disabled, as the manager is running with --offline-images")
+		return ctrl.Result{}, nil
+	}
+
+	image, instanceType, err := resolveImage(lxcImage, lxcClient.GetServerName())
+	if err != nil {
+		conditions.MarkFalse(lxcImage, infrav1.ImagePulledCondition, infrav1.ImagePullAbortedReason, clusterv1.ConditionSeverityError, "Invalid image: %s", err)
+		return ctrl.Result{}, nil
+	}
+
+	targets, err := resolveImageTargets(lxcImage, lxcClient)
+	if err != nil {
+		if utils.IsTerminalError(err) {
+			conditions.MarkFalse(lxcImage, infrav1.ImagePulledCondition, infrav1.ImagePullAbortedReason, clusterv1.ConditionSeverityError, "%s", err)
+			return ctrl.Result{}, nil
+		}
+		conditions.MarkFalse(lxcImage, infrav1.ImagePulledCondition, infrav1.ImagePullFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+		return ctrl.Result{}, err
+	}
+
+	refreshInterval := defaultRefreshInterval
+	if lxcImage.Spec.RefreshInterval != nil {
+		refreshInterval = lxcImage.Spec.RefreshInterval.Duration
+	}
+
+	// Skip pulling the image if it is up to date on all targets.
+	if isUpToDate(lxcImage, targets, refreshInterval) {
+		if refreshInterval == 0 {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{RequeueAfter: time.Until(lxcImage.Status.LastRefreshTime.Add(refreshInterval))}, nil
+	}
+
+	log.FromContext(ctx).Info("Pulling image", "image", image, "type", instanceType, "targets", len(targets))
+	members := make([]infrav1.LXCImageMemberStatus, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		members[i].Name = target.name
+		if idx := slices.IndexFunc(lxcImage.Status.Members, func(m infrav1.LXCImageMemberStatus) bool { return m.Name == target.name }); idx >= 0 {
+			members[i] = lxcImage.Status.Members[idx]
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = pullImage(ctx, target, image, instanceType, &members[i])
+		}()
+	}
+	wg.Wait()
+
+	oldFingerprints := managedImageFingerprints(lxcImage.Status.Members)
+	lxcImage.Status.Members = members
+	lxcImage.Status.Ready = false
+
+	// Prune images that were replaced after a refresh, or that were pulled on members that are no longer targets.
+	// Members that failed to pull the image keep their previous image, which is pruned after it is replaced.
+	newFingerprints := imageFingerprints(members)
+	var pruneFingerprints []string
+	for _, fingerprint := range oldFingerprints {
+		if !slices.Contains(newFingerprints, fingerprint) {
+			pruneFingerprints = append(pruneFingerprints, fingerprint)
+		}
+	}
+	if len(pruneFingerprints) > 0 {
+		otherImages, err := r.listOtherImagesOnServer(ctx, lxcImage)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := pruneImages(ctx, lxcClient, otherImages, pruneFingerprints); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to prune images: %w", err)
+		}
+	}
+
+	var failed []string
+	var terminal int
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, targets[i].name)
+			if utils.IsTerminalError(err) {
+				terminal++
+			}
+		}
+	}
+	if len(failed) > 0 {
+		if terminal == len(failed) {
+			conditions.MarkFalse(lxcImage, infrav1.ImagePulledCondition, infrav1.ImagePullAbortedReason, clusterv1.ConditionSeverityError, "Failed to pull image on %v, see .status.members for details", failed)
+			return ctrl.Result{}, nil
+		}
+		conditions.MarkFalse(lxcImage, infrav1.ImagePulledCondition, infrav1.ImagePullFailedReason, clusterv1.ConditionSeverityWarning, "Failed to pull image on %v, see .status.members for details", failed)
+		return ctrl.Result{}, fmt.Errorf("failed to pull image on %v", failed)
+	}
+
+	lxcImage.Status.Ready = true
+	lxcImage.Status.ObservedGeneration = lxcImage.Generation
+	lxcImage.Status.LastRefreshTime = &metav1.Time{Time: time.Now()}
+	conditions.MarkTrue(lxcImage, infrav1.ImagePulledCondition)
+
+	if refreshInterval == 0 {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: refreshInterval}, nil
+}
+
+// isUpToDate returns true if the image of a LXCImage was pulled on all targets for the current spec, and the refresh
+// interval has not passed.
+func isUpToDate(lxcImage *infrav1.LXCImage, targets []imageTarget, refreshInterval time.Duration) bool {
+	status := lxcImage.Status
+	if !status.Ready || status.ObservedGeneration != lxcImage.Generation || status.LastRefreshTime == nil || len(status.Members) != len(targets) {
+		return false
+	}
+	for _, target := range targets {
+		if !slices.ContainsFunc(status.Members, func(m infrav1.LXCImageMemberStatus) bool { return m.Name == target.name && m.Ready }) {
+			return false
+		}
+	}
+	return refreshInterval == 0 || time.Since(status.LastRefreshTime.Time) < refreshInterval
+}
+
+// isManagedImage returns true if an image was downloaded by the LXCImage. Images that existed before the pull are
+// only managed if they were downloaded by a previous pull of the LXCImage.
+func isManagedImage(member infrav1.LXCImageMemberStatus, existing []string, fingerprint string) bool {
+	if !slices.Contains(existing, fingerprint) {
+		return true
+	}
+	return member.Managed && member.Fingerprint == fingerprint
+}
+
+// pullImage pulls the image on a target, and updates the member status.
+func pullImage(ctx context.Context, target imageTarget, image lxc.Image, instanceType api.InstanceType, member *infrav1.LXCImageMemberStatus) error {
+	ctx, cancel := context.WithTimeout(ctx, imagePullTimeout)
+	defer cancel()
+
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("member", target.name))
+
+	err := func() error {
+		lxcClient, err := target.connect(ctx)
+		if err != nil {
+			return err
+		}
+		// Record the images that exist before the pull, such that images that were not downloaded by the LXCImage
+		// (e.g. images that were imported by users) are never deleted.
+		existing, err := lxcClient.GetImageFingerprints()
+		if err != nil {
+			return fmt.Errorf("failed to list images: %w", err)
+		}
+		pulled, err := lxcClient.PullImageForType(ctx, image, instanceType)
+		if err != nil {
+			return err
+		}
+
+		member.Managed = isManagedImage(*member, existing, pulled.Fingerprint)
+		member.Fingerprint = pulled.Fingerprint
+		member.Architecture = pulled.Architecture
+		member.LastPullTime = &metav1.Time{Time: time.Now()}
+		return nil
+	}()
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to pull image")
+		member.Ready = false
+		member.Message = err.Error()
+		return err
+	}
+
+	member.Ready = true
+	member.Message = ""
+	return nil
+}
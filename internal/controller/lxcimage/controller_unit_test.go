@@ -0,0 +1,190 @@
+package lxcimage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestResolveImage(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		spec        infrav1.LXCImageSpec
+		expect      lxc.Image
+		expectType  api.InstanceType
+		expectAbort bool
+	}{
+		{
+			name:       "Capn",
+			spec:       infrav1.LXCImageSpec{Image: infrav1.LXCMachineImageSource{Name: "capi:kubeadm/v1.33.0"}},
+			expect:     lxc.CapnImage("kubeadm/v1.33.0"),
+			expectType: api.InstanceTypeContainer,
+		},
+		{
+			name:       "VirtualMachine",
+			spec:       infrav1.LXCImageSpec{Image: infrav1.LXCMachineImageSource{Name: "ubuntu:24.04"}, InstanceType: "virtual-machine"},
+			expect:     lxc.Image{Protocol: lxc.Simplestreams, Server: lxc.DefaultIncusSimplestreamsServer, Alias: "ubuntu/24.04/cloud"},
+			expectType: api.InstanceTypeVM,
+		},
+		{
+			name:       "Kind",
+			spec:       infrav1.LXCImageSpec{Image: infrav1.LXCMachineImageSource{Name: "kind:v1.33.0"}, InstanceType: "kind"},
+			expect:     lxc.KindestNodeImage("v1.33.0"),
+			expectType: api.InstanceTypeContainer,
+		},
+		{
+			name:       "Source",
+			spec:       infrav1.LXCImageSpec{Image: infrav1.LXCMachineImageSource{Name: "haproxy", Server: "https://images.example.com", Protocol: "simplestreams"}},
+			expect:     lxc.Image{Protocol: lxc.Simplestreams, Server: "https://images.example.com", Alias: "haproxy"},
+			expectType: api.InstanceTypeContainer,
+		},
+		{
+			name:        "Version",
+			spec:        infrav1.LXCImageSpec{Image: infrav1.LXCMachineImageSource{Name: "capi:kubeadm/VERSION"}},
+			expectAbort: true,
+		},
+		{
+			name:        "Template",
+			spec:        infrav1.LXCImageSpec{Image: infrav1.LXCMachineImageSource{Name: "capi:kubeadm/{{ .Version }}"}},
+			expectAbort: true,
+		},
+		{
+			name:        "UnknownPrefix",
+			spec:        infrav1.LXCImageSpec{Image: infrav1.LXCMachineImageSource{Name: "unknown:image"}},
+			expectAbort: true,
+		},
+		{
+			name:        "LocalImage",
+			spec:        infrav1.LXCImageSpec{Image: infrav1.LXCMachineImageSource{Name: "kubeadm/v1.33.0"}},
+			expectAbort: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			image, instanceType, err := resolveImage(&infrav1.LXCImage{Spec: tc.spec}, lxc.Incus)
+			if tc.expectAbort {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(image).To(Equal(tc.expect))
+			g.Expect(instanceType).To(Equal(tc.expectType))
+		})
+	}
+}
+
+func TestIsUpToDate(t *testing.T) {
+	targets := []imageTarget{{name: "w01"}, {name: "w02"}}
+	newImage := func(modify func(*infrav1.LXCImage)) *infrav1.LXCImage {
+		lxcImage := &infrav1.LXCImage{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Status: infrav1.LXCImageStatus{
+				Ready:              true,
+				ObservedGeneration: 2,
+				LastRefreshTime:    &metav1.Time{Time: time.Now().Add(-time.Hour)},
+				Members: []infrav1.LXCImageMemberStatus{
+					{Name: "w01", Ready: true, Fingerprint: "abc"},
+					{Name: "w02", Ready: true, Fingerprint: "abc"},
+				},
+			},
+		}
+		if modify != nil {
+			modify(lxcImage)
+		}
+		return lxcImage
+	}
+
+	for _, tc := range []struct {
+		name            string
+		lxcImage        *infrav1.LXCImage
+		refreshInterval time.Duration
+		expect          bool
+	}{
+		{name: "UpToDate", lxcImage: newImage(nil), refreshInterval: 24 * time.Hour, expect: true},
+		{name: "NoRefresh", lxcImage: newImage(nil), expect: true},
+		{name: "RefreshDue", lxcImage: newImage(nil), refreshInterval: time.Minute},
+		{name: "NotReady", lxcImage: newImage(func(i *infrav1.LXCImage) { i.Status.Ready = false })},
+		{name: "SpecChanged", lxcImage: newImage(func(i *infrav1.LXCImage) { i.Generation = 3 })},
+		{name: "NewTarget", lxcImage: newImage(func(i *infrav1.LXCImage) { i.Status.Members = i.Status.Members[:1] })},
+		{name: "ReplacedTarget", lxcImage: newImage(func(i *infrav1.LXCImage) { i.Status.Members[1].Name = "w03" })},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isUpToDate(tc.lxcImage, targets, tc.refreshInterval)).To(Equal(tc.expect))
+		})
+	}
+}
+
+func TestImageFingerprints(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(imageFingerprints([]infrav1.LXCImageMemberStatus{
+		{Name: "w01", Fingerprint: "abc"},
+		{Name: "w02", Fingerprint: "def"},
+		{Name: "w03", Fingerprint: "abc"},
+		{Name: "w04"},
+	})).To(Equal([]string{"abc", "def"}))
+}
+
+func TestManagedImageFingerprints(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(managedImageFingerprints([]infrav1.LXCImageMemberStatus{
+		{Name: "w01", Fingerprint: "abc", Managed: true},
+		{Name: "w02", Fingerprint: "def"},
+		{Name: "w03", Fingerprint: "abc"},
+		{Name: "w04", Managed: true},
+	})).To(Equal([]string{"abc"}))
+}
+
+func TestIsManagedImage(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		member      infrav1.LXCImageMemberStatus
+		existing    []string
+		fingerprint string
+		expect      bool
+	}{
+		{name: "Downloaded", existing: []string{"def"}, fingerprint: "abc", expect: true},
+		{name: "AlreadyExists", existing: []string{"abc", "def"}, fingerprint: "abc"},
+		{name: "AlreadyExistsPulledByOther", member: infrav1.LXCImageMemberStatus{Fingerprint: "abc"}, existing: []string{"abc"}, fingerprint: "abc"},
+		{name: "RefreshUnchanged", member: infrav1.LXCImageMemberStatus{Fingerprint: "abc", Managed: true}, existing: []string{"abc"}, fingerprint: "abc", expect: true},
+		{name: "RefreshToExisting", member: infrav1.LXCImageMemberStatus{Fingerprint: "abc", Managed: true}, existing: []string{"abc", "def"}, fingerprint: "def"},
+		{name: "RefreshDownloaded", member: infrav1.LXCImageMemberStatus{Fingerprint: "abc"}, existing: []string{"abc"}, fingerprint: "def", expect: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isManagedImage(tc.member, tc.existing, tc.fingerprint)).To(Equal(tc.expect))
+		})
+	}
+}
+
+func TestIsSameServer(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		a, b   lxc.Configuration
+		expect bool
+	}{
+		{name: "Same", a: lxc.Configuration{ServerURL: "https://10.0.0.1:8443", Project: "p1"}, b: lxc.Configuration{ServerURL: "https://10.0.0.1:8443/", Project: "p1"}, expect: true},
+		{name: "DefaultProject", a: lxc.Configuration{ServerURL: "https://10.0.0.1:8443"}, b: lxc.Configuration{ServerURL: "https://10.0.0.1:8443", Project: "default"}, expect: true},
+		{name: "OtherProject", a: lxc.Configuration{ServerURL: "https://10.0.0.1:8443", Project: "p1"}, b: lxc.Configuration{ServerURL: "https://10.0.0.1:8443", Project: "p2"}},
+		{name: "OtherServer", a: lxc.Configuration{ServerURL: "https://10.0.0.1:8443"}, b: lxc.Configuration{ServerURL: "https://10.0.0.2:8443"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isSameServer(tc.a, tc.b)).To(Equal(tc.expect))
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package lxcimage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/shared/api"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func patchLXCImage(ctx context.Context, patchHelper *patch.Helper, lxcImage *infrav1.LXCImage) error {
+	// Always update the readyCondition by summarizing the state of other conditions.
+	conditions.SetSummary(lxcImage,
+		conditions.WithConditions(infrav1.ImagePulledCondition),
+	)
+
+	// Patch the object, ignoring conflicts on the conditions owned by this controller.
+	return patchHelper.Patch(
+		ctx,
+		lxcImage,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			infrav1.ImagePulledCondition,
+			clusterv1.ReadyCondition,
+		}},
+	)
+}
+
+// resolveImage returns the image and the instance type of the image of a LXCImage.
+func resolveImage(lxcImage *infrav1.LXCImage, serverName string) (lxc.Image, api.InstanceType, error) {
+	instanceType := api.InstanceTypeContainer
+	if t := lxcImage.Spec.InstanceType; t != "" && t != "kind" {
+		instanceType = api.InstanceType(t)
+	}
+
+	imageSpec := lxcImage.Spec.Image
+	if strings.Contains(imageSpec.Name, "VERSION") {
+		return lxc.Image{}, "", utils.TerminalError(fmt.Errorf("image name %q must not contain VERSION", imageSpec.Name))
+	}
+	if strings.Contains(imageSpec.Name, "{{") {
+		return lxc.Image{}, "", utils.TerminalError(fmt.Errorf("image name %q must not contain templates", imageSpec.Name))
+	}
+
+	var image lxc.ImageFamily = lxc.Image{
+		Protocol:    imageSpec.Protocol,
+		Server:      imageSpec.Server,
+		Fingerprint: imageSpec.Fingerprint,
+		Alias:       imageSpec.Name,
+	}
+	if imageSpec.Name != "" {
+		parsed, isParsed, err := lxc.ParseImage(imageSpec.Name)
+		if err != nil {
+			return lxc.Image{}, "", utils.TerminalError(fmt.Errorf("failed to parse image %q: %w", imageSpec.Name, err))
+		} else if isParsed {
+			image = parsed
+		}
+	}
+
+	resolved, err := image.For(serverName)
+	if err != nil {
+		return lxc.Image{}, "", err
+	}
+	if resolved.Server == "" || (resolved.Alias == "" && resolved.Fingerprint == "") {
+		return lxc.Image{}, "", utils.TerminalError(fmt.Errorf("image must have a server, and a name or fingerprint"))
+	}
+	return resolved, instanceType, nil
+}
+
+// imageTarget is a server or cluster member to pull an image on.
+type imageTarget struct {
+	name string
+	// connect returns a client for the server or cluster member.
+	connect func(ctx context.Context) (*lxc.Client, error)
+}
+
+// resolveImageTargets returns the servers or cluster members to pull the image of a LXCImage on.
+func resolveImageTargets(lxcImage *infrav1.LXCImage, lxcClient *lxc.Client) ([]imageTarget, error) {
+	if lxcClient.SupportsInstanceTarget() != nil {
+		return []imageTarget{{
+			name:    lxcClient.GetServerHostName(),
+			connect: func(context.Context) (*lxc.Client, error) { return lxcClient, nil },
+		}}, nil
+	}
+
+	members, err := lxcClient.ResolveClusterMembers(lxcImage.Spec.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]imageTarget, 0, len(members))
+	for _, member := range members {
+		targets = append(targets, imageTarget{
+			name: member.ServerName,
+			connect: func(ctx context.Context) (*lxc.Client, error) {
+				if member.Status != "Online" {
+					return nil, fmt.Errorf("cluster member is %s", member.Status)
+				}
+				return lxcClient.ForClusterMember(ctx, member)
+			},
+		})
+	}
+	return targets, nil
+}
+
+// listOtherImagesOnServer returns the other LXCImage objects that pull images on the same server and project as a
+// LXCImage. LXCImage objects with a credentials secret that cannot be retrieved are included.
+func (r *LXCImageReconciler) listOtherImagesOnServer(ctx context.Context, lxcImage *infrav1.LXCImage) ([]infrav1.LXCImage, error) {
+	lxcSecret := &corev1.Secret{}
+	if err := r.Get(ctx, lxcImage.GetLXCSecretNamespacedName(), lxcSecret); err != nil {
+		return nil, fmt.Errorf("failed to fetch LXC credentials: %w", err)
+	}
+	config := lxc.ConfigurationFromKubernetesSecret(lxcSecret)
+
+	lxcImages := &infrav1.LXCImageList{}
+	if err := r.List(ctx, lxcImages); err != nil {
+		return nil, fmt.Errorf("failed to list LXCImages: %w", err)
+	}
+
+	var otherImages []infrav1.LXCImage
+	for _, other := range lxcImages.Items {
+		if other.Name == lxcImage.Name {
+			continue
+		}
+		if other.Spec.SecretRef != lxcImage.Spec.SecretRef {
+			otherSecret := &corev1.Secret{}
+			if err := r.Get(ctx, other.GetLXCSecretNamespacedName(), otherSecret); err == nil && !isSameServer(config, lxc.ConfigurationFromKubernetesSecret(otherSecret)) {
+				continue
+			}
+		}
+		otherImages = append(otherImages, other)
+	}
+	return otherImages, nil
+}
+
+// isSameServer returns true if two configurations use the same server and project.
+func isSameServer(a, b lxc.Configuration) bool {
+	project := func(c lxc.Configuration) string {
+		if c.Project == "" {
+			return api.ProjectDefaultName
+		}
+		return c.Project
+	}
+	return strings.TrimSuffix(a.ServerURL, "/") == strings.TrimSuffix(b.ServerURL, "/") && project(a) == project(b)
+}
+
+// pruneImages deletes images from the server, unless they are also used by other LXCImage objects on the same
+// server. Images with aliases are never deleted, as they were not downloaded by the LXCImage controller.
+func pruneImages(ctx context.Context, lxcClient *lxc.Client, otherImages []infrav1.LXCImage, fingerprints []string) error {
+	for _, fingerprint := range fingerprints {
+		if slices.ContainsFunc(otherImages, func(other infrav1.LXCImage) bool {
+			return slices.ContainsFunc(other.Status.Members, func(member infrav1.LXCImageMemberStatus) bool {
+				return member.Fingerprint == fingerprint
+			})
+		}) {
+			continue
+		}
+
+		image, _, err := lxcClient.GetImage(fingerprint)
+		if err != nil {
+			if api.StatusErrorCheck(err, http.StatusNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to retrieve image %q: %w", fingerprint, err)
+		}
+		if len(image.Aliases) > 0 {
+			log.FromContext(ctx).Info("Not deleting image with aliases", "fingerprint", fingerprint)
+			continue
+		}
+
+		log.FromContext(ctx).Info("Deleting image", "fingerprint", fingerprint)
+		if err := lxcClient.WaitForOperation(ctx, "DeleteImage", func() (incus.Operation, error) {
+			return lxcClient.DeleteImage(fingerprint)
+		}); err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return fmt.Errorf("failed to delete image %q: %w", fingerprint, err)
+		}
+	}
+	return nil
+}
+
+// imageFingerprints returns the unique image fingerprints of the members of a LXCImage.
+func imageFingerprints(members []infrav1.LXCImageMemberStatus) []string {
+	var fingerprints []string
+	for _, member := range members {
+		if member.Fingerprint != "" && !slices.Contains(fingerprints, member.Fingerprint) {
+			fingerprints = append(fingerprints, member.Fingerprint)
+		}
+	}
+	return fingerprints
+}
+
+// managedImageFingerprints returns the unique fingerprints of the images that were downloaded by a LXCImage.
+func managedImageFingerprints(members []infrav1.LXCImageMemberStatus) []string {
+	return imageFingerprints(slices.DeleteFunc(slices.Clone(members), func(member infrav1.LXCImageMemberStatus) bool {
+		return !member.Managed
+	}))
+}
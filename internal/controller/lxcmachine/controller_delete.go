@@ -14,6 +14,7 @@ import (
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
 	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
 
 func (r *LXCMachineReconciler) reconcileDelete(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, machine *clusterv1.Machine, lxcMachine *infrav1.LXCMachine, lxcClient *lxc.Client) error {
@@ -32,14 +33,22 @@ func (r *LXCMachineReconciler) reconcileDelete(ctx context.Context, cluster *clu
 
 	// Delete the machine
 	log.FromContext(ctx).Info("Deleting instance")
-	if err := lxcClient.WaitForDeleteInstance(ctx, lxcMachine.Name); err != nil {
+	if err := lxcClient.WaitForDeleteInstance(ctx, lxcMachine.GetInstanceName()); err != nil {
 		return fmt.Errorf("failed to delete the instance: %w", err)
 	}
 
 	// If the deleted machine is a control-plane node, remove it from the load balancer configuration (unless the cluster is getting deleted)
 	if util.IsControlPlaneMachine(machine) && cluster.DeletionTimestamp.IsZero() {
 		log.FromContext(ctx).Info("Reconfigure load balancer after removing control plane machine")
-		if err := loadbalancer.ManagerForCluster(cluster, lxcCluster, lxcClient).Reconfigure(ctx); err != nil {
+		lbOpts, err := loadbalancer.ManagerOptionsForCluster(ctx, r.Client, lxcCluster)
+		if err == nil {
+			err = loadbalancer.ManagerForCluster(cluster, lxcCluster, lxcClient, lbOpts...).Reconfigure(ctx)
+		}
+		switch {
+		case err != nil && utils.IsTerminalError(err):
+			// Do not block deletion of the machine, the load balancer backends are synced by the LXCCluster controller.
+			log.FromContext(ctx).Error(err, "Failed to reconfigure load balancer after removing control plane node")
+		case err != nil:
 			return fmt.Errorf("failed to reconfigure load balancer after removing control plane node: %w", err)
 		}
 	}
@@ -6,13 +6,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lxc/incus/v6/shared/api"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/instances"
 	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
 	"github.com/lxc/cluster-api-provider-incus/internal/ptr"
@@ -29,7 +32,12 @@ func (r *LXCMachineReconciler) reconcileNormal(ctx context.Context, cluster *clu
 
 	// if the machine is already provisioned, return
 	if lxcMachine.Spec.ProviderID != nil {
-		state, _, err := lxcClient.GetInstanceState(lxcMachine.GetInstanceName())
+		// machines provisioned before the instance name was recorded use the LXCMachine name
+		if lxcMachine.Status.InstanceName == "" {
+			lxcMachine.Status.InstanceName = lxcMachine.Name
+		}
+
+		instance, _, err := lxcClient.GetInstanceFull(lxcMachine.GetInstanceName())
 		if err != nil {
 			if strings.Contains(err.Error(), "Instance not found") {
 				lxcMachine.Status.Ready = false
@@ -42,7 +50,8 @@ func (r *LXCMachineReconciler) reconcileNormal(ctx context.Context, cluster *clu
 		} else {
 			lxcMachine.Status.Ready = true
 			conditions.MarkTrue(lxcMachine, infrav1.InstanceProvisionedCondition)
-			r.setLXCMachineAddresses(lxcMachine, lxc.ParseHostAddresses(state))
+			r.setLXCMachineAddresses(lxcMachine, lxc.ParseHostAddresses(instance.State))
+			setLXCMachineInstanceStatus(lxcMachine, instance, lxcClient)
 			return ctrl.Result{}, nil
 		}
 	}
@@ -62,14 +71,60 @@ func (r *LXCMachineReconciler) reconcileNormal(ctx context.Context, cluster *clu
 		return ctrl.Result{}, nil
 	}
 
+	// Resolve the instance name, and record it before creating the instance such that it does not change afterwards.
+	if lxcMachine.Status.InstanceName == "" {
+		name, err := resolveInstanceName(ctx, lxcClient, lxcCluster, cluster.Name, lxcMachine)
+		if err != nil {
+			if utils.IsTerminalError(err) {
+				log.FromContext(ctx).Error(err, "Failed to resolve instance name")
+				conditions.MarkFalse(lxcMachine, infrav1.InstanceProvisionedCondition, infrav1.InstanceProvisioningAbortedReason, clusterv1.ConditionSeverityError, "Failed to resolve instance name: %s", err.Error())
+				return ctrl.Result{}, nil
+			}
+			return ctrl.Result{}, fmt.Errorf("failed to resolve instance name: %w", err)
+		}
+
+		patchHelper, err := patch.NewHelper(lxcMachine, r.Client)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		lxcMachine.Status.InstanceName = name
+		if err := patchLXCMachine(ctx, patchHelper, lxcMachine); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to patch LXCMachine: %w", err)
+		}
+	}
+
 	// Create the lxc instance hosting the machine
-	cloudInit, err := r.getBootstrapData(ctx, lxcMachine.Namespace, *dataSecretName)
+	bootstrapData, err := r.getBootstrapData(ctx, lxcMachine.Namespace, *dataSecretName)
 	if err != nil {
+		if utils.IsTerminalError(err) {
+			log.FromContext(ctx).Error(err, "Invalid bootstrap data")
+			conditions.MarkFalse(lxcMachine, infrav1.InstanceProvisionedCondition, infrav1.BootstrapDataInvalidReason, clusterv1.ConditionSeverityError, "Invalid bootstrap data: %s", err.Error())
+			return ctrl.Result{}, nil
+		}
 		return ctrl.Result{}, fmt.Errorf("failed to retrieve bootstrap data: %w", err)
 	}
+	instanceType := api.InstanceTypeContainer
+	if lxcMachine.Spec.InstanceType == string(api.InstanceTypeVM) {
+		instanceType = api.InstanceTypeVM
+	}
+	if err := instances.ValidateBootstrapData(bootstrapData, instanceType, lxcMachine.Spec.Config); err != nil {
+		log.FromContext(ctx).Error(err, "Invalid bootstrap data")
+		conditions.MarkFalse(lxcMachine, infrav1.InstanceProvisionedCondition, infrav1.BootstrapDataInvalidReason, clusterv1.ConditionSeverityError, "Invalid bootstrap data: %s", err.Error())
+		return ctrl.Result{}, nil
+	}
+	if err := validateKubeletProviderID(lxcCluster, bootstrapData); err != nil {
+		log.FromContext(ctx).Error(err, "Invalid bootstrap data")
+		conditions.MarkFalse(lxcMachine, infrav1.InstanceProvisionedCondition, infrav1.BootstrapDataInvalidReason, clusterv1.ConditionSeverityError, "Invalid bootstrap data: %s", err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	registryMirrors, err := r.getRegistryMirrors(ctx, lxcCluster)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to retrieve registry mirrors: %w", err)
+	}
 
 	log.FromContext(ctx).Info("Launching instance")
-	addresses, err := launchInstance(ctx, cluster, lxcCluster, machine, lxcMachine, lxcClient, cloudInit)
+	addresses, err := launchInstance(ctx, r.Recorder, cluster, lxcCluster, machine, lxcMachine, lxcClient, bootstrapData, registryMirrors)
 	if err != nil {
 		if utils.IsTerminalError(err) {
 			log.FromContext(ctx).Error(err, "Fatal error while creating instance spec")
@@ -87,17 +142,36 @@ func (r *LXCMachineReconciler) reconcileNormal(ctx context.Context, cluster *clu
 	r.setLXCMachineAddresses(lxcMachine, addresses)
 	conditions.MarkTrue(lxcMachine, infrav1.InstanceProvisionedCondition)
 
+	// record instance facts, these are refreshed on the next reconcile if the instance cannot be retrieved now
+	if instance, _, err := lxcClient.GetInstanceFull(lxcMachine.GetInstanceName()); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to retrieve instance")
+	} else {
+		setLXCMachineInstanceStatus(lxcMachine, instance, lxcClient)
+	}
+
 	// update load balancer
 	if util.IsControlPlaneMachine(machine) && !lxcMachine.Status.LoadBalancerConfigured {
 		log.FromContext(ctx).Info("Updating control plane load balancer")
 
-		if err := loadbalancer.ManagerForCluster(cluster, lxcCluster, lxcClient).Reconfigure(ctx); err != nil {
+		lbOpts, err := loadbalancer.ManagerOptionsForCluster(ctx, r.Client, lxcCluster)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := loadbalancer.ManagerForCluster(cluster, lxcCluster, lxcClient, lbOpts...).Reconfigure(ctx); err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to update loadbalancer configuration: %w", err)
 		}
 		lxcMachine.Status.LoadBalancerConfigured = true
 	}
 
-	lxcMachine.Spec.ProviderID = ptr.To(lxcMachine.GetExpectedProviderID())
+	// The provider ID of existing machines is kept, such that machines that were launched before the provider ID
+	// format of the cluster was changed keep matching their Node.
+	if lxcMachine.Spec.ProviderID == nil || *lxcMachine.Spec.ProviderID == "" {
+		providerID, err := expectedProviderID(lxcCluster, lxcMachine, lxcClient)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to compute provider ID: %w", err)
+		}
+		lxcMachine.Spec.ProviderID = ptr.To(providerID)
+	}
 	lxcMachine.Status.Ready = true
 
 	return ctrl.Result{}, nil
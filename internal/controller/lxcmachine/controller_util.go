@@ -4,14 +4,21 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 
+	"github.com/lxc/incus/v6/shared/api"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/instances"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
 
 func patchLXCMachine(ctx context.Context, patchHelper *patch.Helper, lxcMachine *infrav1.LXCMachine) error {
@@ -42,19 +49,49 @@ func patchLXCMachine(ctx context.Context, patchHelper *patch.Helper, lxcMachine
 	)
 }
 
-func (r *LXCMachineReconciler) getBootstrapData(ctx context.Context, namespace string, dataSecretName string) (string, error) {
+// getBootstrapData returns the bootstrap data of a machine. The format of the bootstrap data is read from the "format"
+// key of the secret, and defaults to cloud-config.
+func (r *LXCMachineReconciler) getBootstrapData(ctx context.Context, namespace string, dataSecretName string) (instances.BootstrapData, error) {
 	s := &corev1.Secret{}
 	key := client.ObjectKey{Namespace: namespace, Name: dataSecretName}
 	if err := r.Get(ctx, key, s); err != nil {
-		return "", fmt.Errorf("failed to retrieve bootstrap data secret %q: %w", dataSecretName, err)
+		return instances.BootstrapData{}, fmt.Errorf("failed to retrieve bootstrap data secret %q: %w", dataSecretName, err)
 	}
 
 	value, ok := s.Data["value"]
 	if !ok {
-		return "", fmt.Errorf("secret %q is missing value key", dataSecretName)
+		return instances.BootstrapData{}, fmt.Errorf("secret %q is missing value key", dataSecretName)
 	}
 
-	return string(value), nil
+	format, err := instances.ParseBootstrapFormat(string(s.Data["format"]))
+	if err != nil {
+		return instances.BootstrapData{}, fmt.Errorf("secret %q has invalid format: %w", dataSecretName, err)
+	}
+
+	return instances.BootstrapData{Value: string(value), Format: format}, nil
+}
+
+// getRegistryMirrors returns the registry mirrors of the cluster, with credentials from the referenced secrets.
+func (r *LXCMachineReconciler) getRegistryMirrors(ctx context.Context, lxcCluster *infrav1.LXCCluster) ([]instances.RegistryMirror, error) {
+	if lxcCluster.Spec.Registry == nil {
+		return nil, nil
+	}
+
+	mirrors := make([]instances.RegistryMirror, 0, len(lxcCluster.Spec.Registry.Mirrors))
+	for _, mirror := range lxcCluster.Spec.Registry.Mirrors {
+		resolved := instances.RegistryMirror{LXCClusterRegistryMirror: mirror}
+		if ref := mirror.AuthSecretRef; ref != nil {
+			s := &corev1.Secret{}
+			key := client.ObjectKey{Namespace: lxcCluster.Namespace, Name: ref.Name}
+			if err := r.Get(ctx, key, s); err != nil {
+				return nil, fmt.Errorf("failed to retrieve auth secret %q of registry mirror %q: %w", ref.Name, mirror.Registry, err)
+			}
+			resolved.Username = string(s.Data["username"])
+			resolved.Password = string(s.Data["password"])
+		}
+		mirrors = append(mirrors, resolved)
+	}
+	return mirrors, nil
 }
 
 func (r *LXCMachineReconciler) setLXCMachineAddresses(lxcMachine *infrav1.LXCMachine, addrs []string) {
@@ -76,3 +113,163 @@ func (r *LXCMachineReconciler) setLXCMachineAddresses(lxcMachine *infrav1.LXCMac
 		)
 	}
 }
+
+// expectedProviderID returns the provider ID of a newly launched instance, using the provider ID format of the cluster.
+func expectedProviderID(lxcCluster *infrav1.LXCCluster, lxcMachine *infrav1.LXCMachine, lxcClient *lxc.Client) (string, error) {
+	var member string
+	if lxcCluster.Spec.ProviderIDFormat == infrav1.ProviderIDFormatProjectMember {
+		instance, _, err := lxcClient.GetInstance(lxcMachine.GetInstanceName())
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve instance location: %w", err)
+		}
+		member = instanceMember(instance)
+	}
+
+	return lxcMachine.GetExpectedProviderID(lxcCluster.Spec.ProviderIDFormat, lxcClient.GetProject(), member), nil
+}
+
+// instanceChecker is the subset of lxc.Client that is used to find existing instances.
+type instanceChecker interface {
+	InstanceExists(ctx context.Context, name string) (bool, error)
+}
+
+var _ instanceChecker = &lxc.Client{}
+
+// resolveInstanceName returns the instance name of a machine that has no instance name in its status yet. Machines
+// that started provisioning before the instance name was recorded (e.g. before an upgrade) use the LXCMachine name,
+// so an instance with the LXCMachine name that exists (or is being created) is adopted instead of launching another.
+// Errors from the naming configuration of the cluster are terminal.
+func resolveInstanceName(ctx context.Context, lxcClient instanceChecker, lxcCluster *infrav1.LXCCluster, clusterName string, lxcMachine *infrav1.LXCMachine) (string, error) {
+	if exists, err := lxcClient.InstanceExists(ctx, lxcMachine.Name); err != nil {
+		return "", fmt.Errorf("failed to check for existing instance %q: %w", lxcMachine.Name, err)
+	} else if exists {
+		return lxcMachine.Name, nil
+	}
+	name, err := instances.MachineInstanceName(lxcCluster.Spec.MachineNaming, clusterName, lxcMachine)
+	if err != nil {
+		return "", utils.TerminalError(err)
+	}
+	return name, nil
+}
+
+// instanceMember returns the cluster member of an instance, or an empty string on standalone servers.
+func instanceMember(instance *api.Instance) string {
+	// Instances on standalone servers report location "none".
+	if instance.Location == "none" {
+		return ""
+	}
+	return instance.Location
+}
+
+// legacyKubeletProviderIDs are the legacy provider IDs that the cluster templates configure for the kubelet, as they
+// appear in the bootstrap data of an instance: in cloud-config, in cloud-config that is escaped for an instance
+// template, and in cloud-config that is converted for kind instances.
+var legacyKubeletProviderIDs = []string{
+	"lxc:///{{ v1.local_hostname }}",
+	`lxc:///{{ "{{" }} v1.local_hostname }}`,
+	"lxc:///{{ container.name }}",
+}
+
+// validateKubeletProviderID checks that the kubelet provider ID in the bootstrap data of a machine can be rewritten to
+// the provider ID format of the cluster (see kubeletProviderIDReplacer). Bootstrap data that does not set a provider
+// ID is accepted, as the provider ID of the Node is then set by the cloud-controller-manager.
+func validateKubeletProviderID(lxcCluster *infrav1.LXCCluster, bootstrapData instances.BootstrapData) error {
+	format := lxcCluster.Spec.ProviderIDFormat
+	if format == "" || format == infrav1.ProviderIDFormatLegacy {
+		return nil
+	}
+	for _, legacy := range legacyKubeletProviderIDs {
+		if strings.Contains(bootstrapData.Value, legacy) {
+			return nil
+		}
+	}
+	if strings.Contains(bootstrapData.Value, "provider-id") || strings.Contains(bootstrapData.Value, "providerID") {
+		return utils.TerminalError(fmt.Errorf("bootstrap data sets a kubelet provider ID other than %q, which cannot be rewritten to the %s provider ID format of the cluster", legacyKubeletProviderIDs[0], format))
+	}
+	return nil
+}
+
+// kubeletProviderIDReplacer returns a function that rewrites the legacy provider ID of the kubelet in the bootstrap
+// data of an instance to the provider ID format of the cluster, once the cluster member of the instance is known.
+// This keeps the provider ID of the Node in sync with the provider ID of the LXCMachine. It returns nil for the
+// legacy format.
+func kubeletProviderIDReplacer(lxcCluster *infrav1.LXCCluster, lxcMachine *infrav1.LXCMachine, project string) func(*api.Instance) *strings.Replacer {
+	format := lxcCluster.Spec.ProviderIDFormat
+	if format == "" || format == infrav1.ProviderIDFormatLegacy {
+		return nil
+	}
+	return func(instance *api.Instance) *strings.Replacer {
+		providerID := lxcMachine.GetExpectedProviderID(format, project, instanceMember(instance))
+
+		oldnew := make([]string, 0, 2*len(legacyKubeletProviderIDs))
+		for _, legacy := range legacyKubeletProviderIDs {
+			oldnew = append(oldnew, legacy, providerID)
+		}
+		return strings.NewReplacer(oldnew...)
+	}
+}
+
+// setLXCMachineInstanceStatus records facts about the instance of a LXCMachine. The image of an instance does not
+// change, so the image alias and server are only resolved when the image fingerprint changes.
+func setLXCMachineInstanceStatus(lxcMachine *infrav1.LXCMachine, instance *api.InstanceFull, lxcClient *lxc.Client) {
+	status := &infrav1.LXCMachineInstanceStatus{
+		ImageFingerprint: instance.Config["volatile.base_image"],
+		Architecture:     instance.Architecture,
+		InstanceType:     instance.Type,
+		CreatedAt:        &metav1.Time{Time: instance.CreatedAt},
+		Status:           instance.Status,
+	}
+	// Instances on standalone servers report location "none".
+	if instance.Location != "none" {
+		status.Location = instance.Location
+	}
+
+	if previous := lxcMachine.Status.Instance; previous != nil && previous.ImageFingerprint == status.ImageFingerprint {
+		status.ImageAlias, status.ImageServer = previous.ImageAlias, previous.ImageServer
+	} else if status.ImageFingerprint != "" {
+		// the image may have been deleted from the server since the instance was created
+		if image, _, err := lxcClient.GetImage(status.ImageFingerprint); err == nil {
+			status.ImageAlias, status.ImageServer = imageSource(image)
+		}
+	}
+
+	lxcMachine.Status.Instance = status
+}
+
+// imageSource returns the alias and server of an image. For images that were pulled from a remote server, these are
+// the alias and server that the image was pulled from. Otherwise, the first local alias of the image is returned.
+func imageSource(image *api.Image) (string, string) {
+	if source := image.UpdateSource; source != nil && source.Alias != "" {
+		return source.Alias, source.Server
+	}
+	if len(image.Aliases) > 0 {
+		return image.Aliases[0].Name, ""
+	}
+	return "", ""
+}
+
+// newMachineTemplateData returns the template data for a machine. serverArchitecture is the primary architecture of
+// the server (in Incus format, e.g. "x86_64"), used for machines that do not specify an architecture.
+func newMachineTemplateData(cluster *clusterv1.Cluster, machine *clusterv1.Machine, lxcMachine *infrav1.LXCMachine, serverArchitecture string) instances.MachineTemplateData {
+	data := instances.MachineTemplateData{
+		Architecture:     lxcMachine.Spec.Architecture,
+		InstanceType:     lxcMachine.Spec.InstanceType,
+		ClusterName:      cluster.Name,
+		ClusterNamespace: cluster.Namespace,
+		MachineName:      machine.Name,
+		Role:             "control-plane",
+	}
+	if !util.IsControlPlaneMachine(machine) {
+		data.Role = "worker"
+	}
+	if data.InstanceType == "" {
+		data.InstanceType = lxc.Container
+	}
+	if data.Architecture == "" {
+		data.Architecture = lxc.KubernetesArchitecture(serverArchitecture)
+	}
+	if v := machine.Spec.Version; v != nil {
+		data = data.WithVersion(*v)
+	}
+	return data
+}
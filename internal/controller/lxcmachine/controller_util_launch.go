@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/lxc/incus/v6/shared/api"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
@@ -17,10 +18,10 @@ import (
 	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
 
-func launchInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, machine *clusterv1.Machine, lxcMachine *infrav1.LXCMachine, lxcClient *lxc.Client, cloudInit string) ([]string, error) {
+func launchInstance(ctx context.Context, recorder record.EventRecorder, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, machine *clusterv1.Machine, lxcMachine *infrav1.LXCMachine, lxcClient *lxc.Client, bootstrapData instances.BootstrapData, registryMirrors []instances.RegistryMirror) ([]string, error) {
 	// TODO: merge the two code paths as much as possible
 	if lxcMachine.Spec.InstanceType == "kind" {
-		return launchKindInstance(ctx, cluster, lxcCluster, machine, lxcMachine, lxcClient, cloudInit)
+		return launchKindInstance(ctx, recorder, cluster, lxcCluster, machine, lxcMachine, lxcClient, bootstrapData, registryMirrors)
 	}
 
 	role := "control-plane"
@@ -43,7 +44,12 @@ func launchInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster
 		machineVersion = *v
 	}
 
-	imageSpec := lxcMachine.Spec.Image.DeepCopy()
+	spec, err := instances.RenderMachineSpec(lxcMachine.Spec, newMachineTemplateData(cluster, machine, lxcMachine, lxcClient.GetArchitecture()))
+	if err != nil {
+		return nil, err
+	}
+
+	imageSpec := spec.Image
 	if strings.Contains(imageSpec.Name, "VERSION") {
 		if machineVersion == "" {
 			return nil, utils.TerminalError(fmt.Errorf("image name %q contains VERSION but Machine %q does not have a Kubernetes version", imageSpec.Name, machine.Name))
@@ -69,7 +75,7 @@ func launchInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster
 			return nil, utils.TerminalError(fmt.Errorf("no image source specified on LXCMachineTemplate and Machine %q does not have a Kubernetes version", machine.Name))
 		}
 		kubeadmImage := lxc.CapnImage(fmt.Sprintf("kubeadm/%s", machineVersion))
-		if err := kubeadmImage.Check(instanceType); err != nil {
+		if err := lxcClient.CheckImage(kubeadmImage, instanceType, ""); err != nil {
 			if utils.IsTerminalError(err) {
 				err = fmt.Errorf("image not specified and default simplestreams server does not provide images for Kubernetes version %q. The error was: %w. Please consider using a different Kubernetes version, or build your own base image and set the image source on the LXCMachineTemplate resource", machineVersion, err)
 			}
@@ -78,6 +84,20 @@ func launchInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster
 		image = kubeadmImage
 	}
 
+	registryMirrorEndpoints := instances.RegistryMirrorEndpoints(lxcCluster.Spec.Registry)
+	architecture, target, err := resolveArchitecture(lxcMachine, lxcClient, image, instanceType, registryMirrorEndpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	var cloudInit, ignition string
+	switch bootstrapData.Format {
+	case instances.BootstrapFormatIgnition:
+		ignition = bootstrapData.Value
+	default:
+		cloudInit = bootstrapData.Value
+	}
+
 	launchOpts := instances.KubeadmLaunchOptions(instances.KubeadmLaunchOptionsInput{
 		InstanceType:      instanceType,
 		KubernetesVersion: machineVersion,
@@ -86,18 +106,24 @@ func launchInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster
 		ServerName:        lxcClient.GetServerName(),
 
 		CloudInit: cloudInit,
+		Ignition:  ignition,
+
+		RegistryMirrors: registryMirrors,
 	}).
 		WithFlavor(lxcMachine.Spec.Flavor).
-		WithProfiles(lxcMachine.Spec.Profiles).
+		WithProfiles(spec.Profiles).
 		WithDevices(devices).
-		WithConfig(lxcMachine.Spec.Config).
+		WithConfig(spec.Config).
 		WithConfig(map[string]string{
 			"user.cluster-name":      cluster.Name,
 			"user.cluster-namespace": cluster.Namespace,
 			"user.machine-name":      machine.Name,
 			"user.cluster-role":      role,
 		}).
-		WithImage(image)
+		WithImage(image).
+		WithRegistryMirrors(registryMirrorEndpoints).
+		WithArchitecture(architecture).
+		WithBootstrapReplacer(kubeletProviderIDReplacer(lxcCluster, lxcMachine, lxcClient.GetProject()))
 
 	// apply instance templates from load balancer manager
 	if util.IsControlPlaneMachine(machine) {
@@ -108,5 +134,30 @@ func launchInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster
 		}
 	}
 
-	return lxcClient.WithTarget(lxcMachine.Spec.Target).WaitForLaunchInstance(ctx, lxcMachine.GetInstanceName(), launchOpts)
+	return lxcClient.WithTarget(target).WaitForLaunchInstance(ctx, lxcMachine.GetInstanceName(), launchOpts)
+}
+
+// resolveArchitecture returns the architecture (in Incus format) and the target of the instance of a machine. If the
+// machine has an architecture, it checks that the image (from the registry mirror, for OCI images) is available for
+// the architecture, and restricts placement to cluster members with the architecture.
+func resolveArchitecture(lxcMachine *infrav1.LXCMachine, lxcClient *lxc.Client, image lxc.ImageFamily, instanceType api.InstanceType, registryMirrors map[string]string) (string, string, error) {
+	architecture := lxc.IncusArchitecture(lxcMachine.Spec.Architecture)
+	if architecture == "" {
+		return "", lxcMachine.Spec.Target, nil
+	}
+
+	target, err := lxcClient.ResolveArchitectureTarget(lxcMachine.Spec.Target, architecture)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find placement for architecture %q: %w", lxcMachine.Spec.Architecture, err)
+	}
+
+	if resolved, err := image.For(lxcClient.GetServerName()); err != nil {
+		return "", "", fmt.Errorf("failed to resolve image: %w", err)
+	} else if resolved = resolved.WithRegistryMirrors(registryMirrors); resolved.Protocol == lxc.Simplestreams || resolved.Protocol == lxc.OCI {
+		if err := lxcClient.CheckImage(resolved, instanceType, architecture); err != nil {
+			return "", "", fmt.Errorf("image is not available for architecture %q: %w", lxcMachine.Spec.Architecture, err)
+		}
+	}
+
+	return architecture, target, nil
 }
@@ -7,9 +7,12 @@ import (
 	"strings"
 
 	"github.com/lxc/incus/v6/shared/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
 	"github.com/lxc/cluster-api-provider-incus/internal/instances"
@@ -18,10 +21,17 @@ import (
 	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
 
-func launchKindInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, machine *clusterv1.Machine, lxcMachine *infrav1.LXCMachine, lxcClient *lxc.Client, cloudInit string) ([]string, error) {
+// cloudInitConversionWarningReason is the reason of the events that are recorded for warnings of the kind cloud-init
+// conversion, e.g. for cloud-init modules that are not supported on kind instances.
+const cloudInitConversionWarningReason = "CloudInitConversionWarning"
+
+func launchKindInstance(ctx context.Context, recorder record.EventRecorder, cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, machine *clusterv1.Machine, lxcMachine *infrav1.LXCMachine, lxcClient *lxc.Client, bootstrapData instances.BootstrapData, registryMirrors []instances.RegistryMirror) ([]string, error) {
 	if err := lxcClient.SupportsInstanceOCI(); err != nil {
 		return nil, utils.TerminalError(fmt.Errorf("cannot launch kind instance as OCI containers are not supported: %w", err))
 	}
+	if bootstrapData.Format != instances.BootstrapFormatCloudConfig {
+		return nil, utils.TerminalError(fmt.Errorf("bootstrap data format %q is not supported for kind instances", bootstrapData.Format))
+	}
 
 	name := lxcMachine.GetInstanceName()
 
@@ -36,12 +46,19 @@ func launchKindInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcClus
 		return nil, utils.TerminalError(fmt.Errorf("invalid .spec.devices on LXCMachine: %w", err))
 	}
 
+	registryMirrorEndpoints := instances.RegistryMirrorEndpoints(lxcCluster.Spec.Registry)
+
 	var machineVersion string
 	if v := machine.Spec.Version; v != nil {
 		machineVersion = *v
 	}
 
-	imageSpec := lxcMachine.Spec.Image.DeepCopy()
+	spec, err := instances.RenderMachineSpec(lxcMachine.Spec, newMachineTemplateData(cluster, machine, lxcMachine, lxcClient.GetArchitecture()))
+	if err != nil {
+		return nil, err
+	}
+
+	imageSpec := spec.Image
 	if strings.Contains(imageSpec.Name, "VERSION") {
 		if machineVersion == "" {
 			return nil, utils.TerminalError(fmt.Errorf("image name %q contains VERSION but Machine %q does not have a Kubernetes version", imageSpec.Name, machine.Name))
@@ -65,8 +82,8 @@ func launchKindInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcClus
 		if machineVersion == "" {
 			return nil, utils.TerminalError(fmt.Errorf("no image source specified on LXCMachineTemplate and Machine %q does not have a Kubernetes version", machine.Name))
 		}
-		kindImage := lxc.KindestNodeImage(machineVersion)
-		if err := kindImage.Check(api.InstanceTypeContainer); err != nil {
+		kindImage := lxc.KindestNodeImage(machineVersion).WithRegistryMirrors(registryMirrorEndpoints)
+		if err := lxcClient.CheckImage(kindImage, api.InstanceTypeContainer, ""); err != nil {
 			if utils.IsTerminalError(err) {
 				err = fmt.Errorf("image not specified and could not find kindest/node:%s image on DockerHub. The error was: %w. Please consider using a different Kubernetes version, or build your own base image and set the image source on the LXCMachineTemplate resource", machineVersion, err)
 			}
@@ -77,7 +94,7 @@ func launchKindInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcClus
 
 	// avoid apt install cloud-init (and run cloud-init manually) unless requested
 	aptInstallCloudInit := false
-	if v, ok := lxcMachine.Spec.Config["user.capn.x-kind-apt-install-cloud-init"]; ok {
+	if v, ok := spec.Config["user.capn.x-kind-apt-install-cloud-init"]; ok {
 		if b, err := strconv.ParseBool(v); err != nil {
 			return nil, utils.TerminalError(fmt.Errorf("failed to parse user.capn.x-kind-apt-install-cloud-init=%q as boolean: %w", v, err))
 		} else {
@@ -85,32 +102,48 @@ func launchKindInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcClus
 		}
 	}
 
-	launchOpts, err := instances.KindLaunchOptions(instances.KindLaunchOptionsInput{
+	architecture, target, err := resolveArchitecture(lxcMachine, lxcClient, image, api.InstanceTypeContainer, registryMirrorEndpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	launchOpts, warnings, err := instances.KindLaunchOptions(instances.KindLaunchOptionsInput{
 		KubernetesVersion: machineVersion,
 		Privileged:        !lxcCluster.Spec.Unprivileged,
 		SkipProfile:       lxcCluster.Spec.SkipDefaultKubeadmProfile,
 
 		PodNetworkCIDR: utils.ClusterFirstPodNetworkCIDR(cluster),
 
-		CloudInit:           cloudInit,
+		CloudInit:           bootstrapData.Value,
 		CloudInitAptInstall: aptInstallCloudInit,
+
+		RegistryMirrors: registryMirrors,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate kind instance launch options: %w", err)
 	}
+	for _, warning := range warnings {
+		log.FromContext(ctx).Info("Bootstrap data was converted for kind instance with warnings", "warning", warning)
+		if recorder != nil {
+			recorder.Event(lxcMachine, corev1.EventTypeWarning, cloudInitConversionWarningReason, warning)
+		}
+	}
 
 	launchOpts = launchOpts.
 		WithFlavor(lxcMachine.Spec.Flavor).
-		WithProfiles(lxcMachine.Spec.Profiles).
+		WithProfiles(spec.Profiles).
 		WithDevices(devices).
-		WithConfig(lxcMachine.Spec.Config).
+		WithConfig(spec.Config).
 		WithConfig(map[string]string{
 			"user.cluster-name":      cluster.Name,
 			"user.cluster-namespace": cluster.Namespace,
 			"user.machine-name":      machine.Name,
 			"user.cluster-role":      role,
 		}).
-		WithImage(image)
+		WithImage(image).
+		WithRegistryMirrors(registryMirrorEndpoints).
+		WithArchitecture(architecture).
+		WithBootstrapReplacer(kubeletProviderIDReplacer(lxcCluster, lxcMachine, lxcClient.GetProject()))
 
 	// apply instance templates from load balancer manager
 	if util.IsControlPlaneMachine(machine) {
@@ -121,5 +154,5 @@ func launchKindInstance(ctx context.Context, cluster *clusterv1.Cluster, lxcClus
 		}
 	}
 
-	return lxcClient.WithTarget(lxcMachine.Spec.Target).WaitForLaunchInstance(ctx, name, launchOpts)
+	return lxcClient.WithTarget(target).WaitForLaunchInstance(ctx, name, launchOpts)
 }
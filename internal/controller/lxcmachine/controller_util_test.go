@@ -0,0 +1,285 @@
+package lxcmachine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/instances"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestImageSource(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		image        *api.Image
+		expectAlias  string
+		expectServer string
+	}{
+		{
+			name: "Remote",
+			image: &api.Image{
+				Aliases:      []api.ImageAlias{{Name: "local"}},
+				UpdateSource: &api.ImageSource{Alias: "kubeadm/v1.33.0", Server: "https://images.example.com", Protocol: "simplestreams"},
+			},
+			expectAlias:  "kubeadm/v1.33.0",
+			expectServer: "https://images.example.com",
+		},
+		{
+			name:        "Local",
+			image:       &api.Image{Aliases: []api.ImageAlias{{Name: "kubeadm/v1.33.0"}, {Name: "other"}}},
+			expectAlias: "kubeadm/v1.33.0",
+		},
+		{
+			name:  "NoAlias",
+			image: &api.Image{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			alias, server := imageSource(tc.image)
+			g.Expect(alias).To(Equal(tc.expectAlias))
+			g.Expect(server).To(Equal(tc.expectServer))
+		})
+	}
+}
+
+func TestSetLXCMachineInstanceStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	createdAt := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	lxcMachine := &infrav1.LXCMachine{
+		Status: infrav1.LXCMachineStatus{
+			Instance: &infrav1.LXCMachineInstanceStatus{
+				ImageFingerprint: "abc",
+				ImageAlias:       "kubeadm/v1.33.0",
+				ImageServer:      "https://images.example.com",
+				Status:           "Stopped",
+			},
+		},
+	}
+	instance := &api.InstanceFull{
+		Instance: api.Instance{
+			InstancePut: api.InstancePut{
+				Architecture: "x86_64",
+				Config:       map[string]string{"volatile.base_image": "abc"},
+			},
+			CreatedAt: createdAt,
+			Location:  "none",
+			Status:    "Running",
+			Type:      "container",
+		},
+	}
+
+	// image source is kept, as the image fingerprint has not changed
+	setLXCMachineInstanceStatus(lxcMachine, instance, nil)
+	g.Expect(lxcMachine.Status.Instance).To(Equal(&infrav1.LXCMachineInstanceStatus{
+		ImageFingerprint: "abc",
+		ImageAlias:       "kubeadm/v1.33.0",
+		ImageServer:      "https://images.example.com",
+		Architecture:     "x86_64",
+		InstanceType:     "container",
+		CreatedAt:        &metav1.Time{Time: createdAt},
+		Status:           "Running",
+	}))
+
+	instance.Location = "w01"
+	setLXCMachineInstanceStatus(lxcMachine, instance, nil)
+	g.Expect(lxcMachine.Status.Instance.Location).To(Equal("w01"))
+}
+
+func TestNewMachineTemplateData(t *testing.T) {
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "ns"}}
+
+	t.Run("ControlPlane", func(t *testing.T) {
+		g := NewWithT(t)
+
+		version := "v1.33.2"
+		machine := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "m1", Labels: map[string]string{clusterv1.MachineControlPlaneLabel: ""}},
+			Spec:       clusterv1.MachineSpec{Version: &version},
+		}
+		lxcMachine := &infrav1.LXCMachine{Spec: infrav1.LXCMachineSpec{InstanceType: "virtual-machine", Architecture: "arm64"}}
+
+		g.Expect(newMachineTemplateData(cluster, machine, lxcMachine, "x86_64")).To(Equal(instances.MachineTemplateData{
+			Version:          "v1.33.2",
+			Major:            "1",
+			Minor:            "33",
+			Patch:            "2",
+			Architecture:     "arm64",
+			InstanceType:     "virtual-machine",
+			ClusterName:      "c1",
+			ClusterNamespace: "ns",
+			MachineName:      "m1",
+			Role:             "control-plane",
+		}))
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		g := NewWithT(t)
+
+		machine := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "m2"}}
+		lxcMachine := &infrav1.LXCMachine{}
+
+		g.Expect(newMachineTemplateData(cluster, machine, lxcMachine, "x86_64")).To(Equal(instances.MachineTemplateData{
+			Architecture:     "amd64",
+			InstanceType:     "container",
+			ClusterName:      "c1",
+			ClusterNamespace: "ns",
+			MachineName:      "m2",
+			Role:             "worker",
+		}))
+	})
+}
+
+func TestKubeletProviderIDReplacer(t *testing.T) {
+	lxcMachine := &infrav1.LXCMachine{ObjectMeta: metav1.ObjectMeta{Name: "c1-md-0-abcde"}}
+	bootstrapData := `kubeletExtraArgs:
+  provider-id: "lxc:///{{ v1.local_hostname }}"
+  escaped: "lxc:///{{ "{{" }} v1.local_hostname }}"
+  kind: "lxc:///{{ container.name }}"
+  hostname: "{{ v1.local_hostname }}"
+`
+
+	t.Run("Legacy", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(kubeletProviderIDReplacer(&infrav1.LXCCluster{}, lxcMachine, "proj")).To(BeNil())
+		g.Expect(kubeletProviderIDReplacer(&infrav1.LXCCluster{Spec: infrav1.LXCClusterSpec{ProviderIDFormat: infrav1.ProviderIDFormatLegacy}}, lxcMachine, "proj")).To(BeNil())
+	})
+
+	for _, tc := range []struct {
+		name     string
+		format   infrav1.ProviderIDFormat
+		location string
+		expect   string
+	}{
+		{name: "Project", format: infrav1.ProviderIDFormatProject, location: "w01", expect: "lxc://proj/c1-md-0-abcde"},
+		{name: "ProjectMember", format: infrav1.ProviderIDFormatProjectMember, location: "w01", expect: "lxc://proj/w01/c1-md-0-abcde"},
+		{name: "ProjectMemberStandalone", format: infrav1.ProviderIDFormatProjectMember, location: "none", expect: "lxc://proj/c1-md-0-abcde"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			replacer := kubeletProviderIDReplacer(&infrav1.LXCCluster{Spec: infrav1.LXCClusterSpec{ProviderIDFormat: tc.format}}, lxcMachine, "proj")
+			g.Expect(replacer).ToNot(BeNil())
+
+			g.Expect(replacer(&api.Instance{Name: "c1-md-0-abcde", Location: tc.location}).Replace(bootstrapData)).To(Equal(`kubeletExtraArgs:
+  provider-id: "` + tc.expect + `"
+  escaped: "` + tc.expect + `"
+  kind: "` + tc.expect + `"
+  hostname: "{{ v1.local_hostname }}"
+`))
+		})
+	}
+}
+
+func TestValidateKubeletProviderID(t *testing.T) {
+	project := &infrav1.LXCCluster{Spec: infrav1.LXCClusterSpec{ProviderIDFormat: infrav1.ProviderIDFormatProject}}
+	ignitionConfig := `{"ignition":{"version":"3.4.0"},"systemd":{"units":[{"name":"kubelet.service","dropins":[{"name":"10-provider-id.conf","contents":"[Service]\nEnvironment=KUBELET_EXTRA_ARGS=--provider-id=%s"}]}]}}`
+
+	for _, tc := range []struct {
+		name          string
+		lxcCluster    *infrav1.LXCCluster
+		bootstrapData instances.BootstrapData
+		expectError   bool
+	}{
+		{
+			name:          "Legacy",
+			lxcCluster:    &infrav1.LXCCluster{},
+			bootstrapData: instances.BootstrapData{Value: "kubeletExtraArgs:\n  provider-id: lxc://proj/{{ v1.local_hostname }}\n"},
+		},
+		{
+			name:          "CloudConfig",
+			lxcCluster:    project,
+			bootstrapData: instances.BootstrapData{Value: "kubeletExtraArgs:\n  provider-id: lxc:///{{ v1.local_hostname }}\n"},
+		},
+		{
+			name:          "CloudConfigOtherProviderID",
+			lxcCluster:    project,
+			bootstrapData: instances.BootstrapData{Value: "kubeletExtraArgs:\n  provider-id: lxc://proj/{{ v1.local_hostname }}\n"},
+			expectError:   true,
+		},
+		{
+			name:          "CloudConfigNoProviderID",
+			lxcCluster:    project,
+			bootstrapData: instances.BootstrapData{Value: "kubeletExtraArgs:\n  cloud-provider: external\n"},
+		},
+		{
+			name:          "Ignition",
+			lxcCluster:    project,
+			bootstrapData: instances.BootstrapData{Format: instances.BootstrapFormatIgnition, Value: fmt.Sprintf(ignitionConfig, "lxc:///{{ v1.local_hostname }}")},
+		},
+		{
+			name:          "IgnitionOtherProviderID",
+			lxcCluster:    project,
+			bootstrapData: instances.BootstrapData{Format: instances.BootstrapFormatIgnition, Value: fmt.Sprintf(ignitionConfig, "lxc:///%%H")},
+			expectError:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := validateKubeletProviderID(tc.lxcCluster, tc.bootstrapData)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+// fakeInstanceChecker is an instanceChecker with a static set of existing instances.
+type fakeInstanceChecker map[string]bool
+
+func (f fakeInstanceChecker) InstanceExists(ctx context.Context, name string) (bool, error) {
+	return f[name], nil
+}
+
+func TestResolveInstanceName(t *testing.T) {
+	lxcMachine := &infrav1.LXCMachine{ObjectMeta: metav1.ObjectMeta{Name: "c1-md-0-abcde", Namespace: "team-a"}}
+	lxcCluster := &infrav1.LXCCluster{Spec: infrav1.LXCClusterSpec{
+		MachineNaming: &infrav1.LXCClusterMachineNaming{Template: "{{ .Namespace }}-{{ .MachineName }}"},
+	}}
+
+	for _, tc := range []struct {
+		name      string
+		instances fakeInstanceChecker
+		expect    string
+	}{
+		{name: "New", expect: "team-a-c1-md-0-abcde"},
+		{
+			// The machine started provisioning with the LXCMachine name before the instance name was recorded.
+			name:      "LegacyInstance",
+			instances: fakeInstanceChecker{"c1-md-0-abcde": true},
+			expect:    "c1-md-0-abcde",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			name, err := resolveInstanceName(context.Background(), tc.instances, lxcCluster, "c1", lxcMachine)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(name).To(Equal(tc.expect))
+		})
+	}
+
+	t.Run("InvalidTemplate", func(t *testing.T) {
+		g := NewWithT(t)
+
+		invalid := &infrav1.LXCCluster{Spec: infrav1.LXCClusterSpec{MachineNaming: &infrav1.LXCClusterMachineNaming{Template: "{{ .Unknown }}"}}}
+		_, err := resolveInstanceName(context.Background(), fakeInstanceChecker{}, invalid, "c1", lxcMachine)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+	})
+}
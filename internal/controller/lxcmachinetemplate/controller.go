@@ -0,0 +1,153 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lxcmachinetemplate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// LXCMachineTemplateReconciler reconciles a LXCMachineTemplate object
+type LXCMachineTemplateReconciler struct {
+	client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// SyncPeriod is the interval at which the capacity of LXCMachineTemplates is refreshed, e.g. to pick up changes
+	// to instance profiles. If zero, the capacity is only refreshed when the LXCMachineTemplate changes.
+	SyncPeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcmachinetemplates,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcmachinetemplates/status,verbs=get;update;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *LXCMachineTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the LXCMachineTemplate instance.
+	lxcMachineTemplate := &infrav1.LXCMachineTemplate{}
+	if err := r.Get(ctx, req.NamespacedName, lxcMachineTemplate); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Fetch the Cluster. MachineDeployments and MachineSets set the Cluster as owner of their infrastructure templates.
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, lxcMachineTemplate.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cluster == nil {
+		if cluster, err = util.GetClusterFromMetadata(ctx, r.Client, lxcMachineTemplate.ObjectMeta); err != nil {
+			log.V(4).Info("LXCMachineTemplate is not owned by a Cluster yet")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	log = log.WithValues("Cluster", klog.KObj(cluster))
+	ctx = ctrl.LoggerInto(ctx, log)
+
+	if annotations.IsPaused(cluster, lxcMachineTemplate) {
+		log.V(4).Info("Cluster or LXCMachineTemplate is paused")
+		return ctrl.Result{}, nil
+	}
+
+	if cluster.Spec.InfrastructureRef == nil {
+		log.Info("Cluster infrastructureRef is not available yet")
+		return ctrl.Result{}, nil
+	}
+
+	// Fetch the LXC Cluster.
+	lxcCluster := &infrav1.LXCCluster{}
+	lxcClusterName := client.ObjectKey{
+		Namespace: lxcMachineTemplate.Namespace,
+		Name:      cluster.Spec.InfrastructureRef.Name,
+	}
+	if err := r.Get(ctx, lxcClusterName, lxcCluster); err != nil {
+		log.Info("LXCCluster is not available yet")
+		return ctrl.Result{}, nil
+	}
+
+	lxcSecret := &corev1.Secret{}
+	if err := r.Get(ctx, lxcCluster.GetLXCSecretNamespacedName(), lxcSecret); err != nil {
+		log.WithValues("secret", lxcCluster.GetLXCSecretNamespacedName()).Error(err, "Failed to fetch LXC credentials secret")
+		return ctrl.Result{}, fmt.Errorf("failed to fetch LXC credentials: %w", err)
+	}
+	lxcClient, err := lxc.New(ctx, lxc.ConfigurationFromKubernetesSecret(lxcSecret))
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create incus client: %w", err)
+	}
+
+	// Initialize the patch helper
+	patchHelper, err := patch.NewHelper(lxcMachineTemplate, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Always attempt to Patch the LXCMachineTemplate object and status after each reconciliation.
+	defer func() {
+		if err := patchHelper.Patch(ctx, lxcMachineTemplate); err != nil {
+			log.Error(err, "Failed to patch LXCMachineTemplate")
+			if rerr == nil {
+				rerr = err
+			}
+		}
+	}()
+
+	if err := r.reconcileCapacity(ctx, lxcMachineTemplate, lxcClient); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.SyncPeriod}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LXCMachineTemplateReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if r.Client == nil {
+		return fmt.Errorf("required field Client must not be nil")
+	}
+
+	predicateLog := ctrl.LoggerFrom(ctx).WithValues("controller", "lxcmachinetemplate")
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.LXCMachineTemplate{}).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceHasFilterLabel(mgr.GetScheme(), predicateLog, r.WatchFilterValue)).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed setting up with a controller manager: %w", err)
+	}
+
+	return nil
+}
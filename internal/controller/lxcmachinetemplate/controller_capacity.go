@@ -0,0 +1,172 @@
+package lxcmachinetemplate
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/units"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// reconcileCapacity updates the capacity and node info of the LXCMachineTemplate.
+func (r *LXCMachineTemplateReconciler) reconcileCapacity(ctx context.Context, lxcMachineTemplate *infrav1.LXCMachineTemplate, lxcClient *lxc.Client) error {
+	spec := lxcMachineTemplate.Spec.Template.Spec
+
+	var flavor *lxc.InstanceTypeLimits
+	if spec.Flavor != "" {
+		limits, err := lxc.ResolveFlavor(ctx, spec.Flavor)
+		if err != nil {
+			// The flavor cannot be resolved (e.g. unknown instance type, or offline mode), so the capacity is unknown.
+			// Do not requeue with backoff, as retrying does not help.
+			if utils.IsTerminalError(err) {
+				log.FromContext(ctx).Error(err, "Failed to resolve flavor, capacity is unknown", "flavor", spec.Flavor)
+				lxcMachineTemplate.Status.Capacity = nil
+				lxcMachineTemplate.Status.NodeInfo = nil
+				return nil
+			}
+			return fmt.Errorf("failed to resolve flavor %q: %w", spec.Flavor, err)
+		}
+		flavor = &limits
+	}
+
+	// Instances without profiles get the default profile.
+	profileNames := spec.Profiles
+	if len(profileNames) == 0 {
+		profileNames = []string{"default"}
+	}
+	profiles := make([]api.Profile, 0, len(profileNames))
+	for _, name := range profileNames {
+		profile, _, err := lxcClient.GetProfile(name)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve profile %q: %w", name, err)
+		}
+		profiles = append(profiles, *profile)
+	}
+
+	config := instanceLimits(spec, flavor, profiles)
+
+	// Host resources are only needed for containers without limits, and for memory limits in percentage.
+	var resources *api.Resources
+	if (spec.InstanceType != string(api.InstanceTypeVM) && (config["limits.cpu"] == "" || config["limits.memory"] == "")) || strings.HasSuffix(config["limits.memory"], "%") {
+		var err error
+		if resources, err = lxcClient.GetServerResources(); err != nil {
+			return fmt.Errorf("failed to retrieve server resources: %w", err)
+		}
+	}
+
+	capacity, err := machineCapacity(spec.InstanceType, config, resources)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).V(4).Info("Resolved capacity", "capacity", capacity)
+	lxcMachineTemplate.Status.Capacity = capacity
+	lxcMachineTemplate.Status.NodeInfo = &infrav1.NodeInfo{
+		Architecture:    spec.Architecture,
+		OperatingSystem: "linux",
+	}
+	if spec.Architecture == "" {
+		lxcMachineTemplate.Status.NodeInfo.Architecture = lxc.KubernetesArchitecture(lxcClient.GetArchitecture())
+	}
+
+	return nil
+}
+
+// instanceLimits returns the limits.cpu and limits.memory config keys of instances. Instance config takes precedence
+// over the flavor, which takes precedence over the profiles (in order).
+func instanceLimits(spec infrav1.LXCMachineSpec, flavor *lxc.InstanceTypeLimits, profiles []api.Profile) map[string]string {
+	config := map[string]string{}
+	for _, profile := range profiles {
+		maps.Copy(config, profile.Config)
+	}
+	if flavor != nil {
+		config["limits.cpu"] = flavor.CPULimit()
+		config["limits.memory"] = flavor.MemoryLimit()
+	}
+	maps.Copy(config, spec.Config)
+
+	return map[string]string{
+		"limits.cpu":    config["limits.cpu"],
+		"limits.memory": config["limits.memory"],
+	}
+}
+
+// machineCapacity returns the capacity of instances with the specified limits. Virtual machines without limits
+// default to 1 CPU and 1GiB of memory. Containers without limits can use all resources of the host.
+func machineCapacity(instanceType string, config map[string]string, resources *api.Resources) (corev1.ResourceList, error) {
+	capacity := corev1.ResourceList{}
+
+	switch cpu := config["limits.cpu"]; {
+	case cpu != "":
+		count, err := parseCPULimit(cpu)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limits.cpu %q: %w", cpu, err)
+		}
+		capacity[corev1.ResourceCPU] = *resource.NewQuantity(count, resource.DecimalSI)
+	case instanceType == string(api.InstanceTypeVM):
+		capacity[corev1.ResourceCPU] = *resource.NewQuantity(1, resource.DecimalSI)
+	case resources != nil:
+		capacity[corev1.ResourceCPU] = *resource.NewQuantity(int64(resources.CPU.Total), resource.DecimalSI)
+	}
+
+	switch memory := config["limits.memory"]; {
+	case strings.HasSuffix(memory, "%") && resources != nil:
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(memory, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limits.memory %q: %w", memory, err)
+		}
+		capacity[corev1.ResourceMemory] = *resource.NewQuantity(int64(float64(resources.Memory.Total)*percent/100), resource.BinarySI)
+	case memory != "":
+		bytes, err := units.ParseByteSizeString(memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limits.memory %q: %w", memory, err)
+		}
+		capacity[corev1.ResourceMemory] = *resource.NewQuantity(bytes, resource.BinarySI)
+	case instanceType == string(api.InstanceTypeVM):
+		capacity[corev1.ResourceMemory] = resource.MustParse("1Gi")
+	case resources != nil:
+		capacity[corev1.ResourceMemory] = *resource.NewQuantity(int64(resources.Memory.Total), resource.BinarySI)
+	}
+
+	return capacity, nil
+}
+
+// parseCPULimit returns the number of CPUs of a limits.cpu value, which is either a number of CPUs (e.g. "2"), or a
+// set of pinned CPUs (e.g. "0-3" or "0,2,4-5").
+func parseCPULimit(value string) (int64, error) {
+	if count, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return count, nil
+	}
+
+	var count int64
+	for _, part := range strings.Split(value, ",") {
+		start, end, isRange := strings.Cut(part, "-")
+		if !isRange {
+			if _, err := strconv.ParseUint(part, 10, 64); err != nil {
+				return 0, fmt.Errorf("invalid CPU %q", part)
+			}
+			count++
+			continue
+		}
+		first, err := strconv.ParseInt(start, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid CPU range %q", part)
+		}
+		last, err := strconv.ParseInt(end, 10, 64)
+		if err != nil || last < first {
+			return 0, fmt.Errorf("invalid CPU range %q", part)
+		}
+		count += last - first + 1
+	}
+	return count, nil
+}
@@ -0,0 +1,76 @@
+package lxcmachinetemplate
+
+import (
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+func TestInstanceLimits(t *testing.T) {
+	profiles := []api.Profile{
+		{ProfilePut: api.ProfilePut{Config: map[string]string{"limits.cpu": "1", "limits.memory": "1GiB"}}},
+		{ProfilePut: api.ProfilePut{Config: map[string]string{"limits.memory": "2GiB", "security.nesting": "true"}}},
+	}
+
+	t.Run("Profiles", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(instanceLimits(infrav1.LXCMachineSpec{}, nil, profiles)).To(Equal(map[string]string{"limits.cpu": "1", "limits.memory": "2GiB"}))
+	})
+
+	t.Run("Flavor", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(instanceLimits(infrav1.LXCMachineSpec{}, &lxc.InstanceTypeLimits{CPU: 2, Memory: 4}, profiles)).To(Equal(map[string]string{"limits.cpu": "2", "limits.memory": "4096MiB"}))
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		g := NewWithT(t)
+		spec := infrav1.LXCMachineSpec{Config: map[string]string{"limits.cpu": "4"}}
+		g.Expect(instanceLimits(spec, &lxc.InstanceTypeLimits{CPU: 2, Memory: 4}, profiles)).To(Equal(map[string]string{"limits.cpu": "4", "limits.memory": "4096MiB"}))
+	})
+}
+
+func TestMachineCapacity(t *testing.T) {
+	resources := &api.Resources{
+		CPU:    api.ResourcesCPU{Total: 16},
+		Memory: api.ResourcesMemory{Total: 64 * 1024 * 1024 * 1024},
+	}
+
+	for _, tc := range []struct {
+		name         string
+		instanceType string
+		config       map[string]string
+		resources    *api.Resources
+		expectCPU    string
+		expectMemory string
+		expectError  bool
+	}{
+		{name: "Limits", config: map[string]string{"limits.cpu": "2", "limits.memory": "4GiB"}, expectCPU: "2", expectMemory: "4Gi"},
+		{name: "PinnedCPUs", config: map[string]string{"limits.cpu": "0,2,4-5", "limits.memory": "512MiB"}, expectCPU: "4", expectMemory: "512Mi"},
+		{name: "MemoryPercent", config: map[string]string{"limits.cpu": "2", "limits.memory": "50%"}, resources: resources, expectCPU: "2", expectMemory: "32Gi"},
+		{name: "ContainerNoLimits", resources: resources, expectCPU: "16", expectMemory: "64Gi"},
+		{name: "VirtualMachineNoLimits", instanceType: "virtual-machine", expectCPU: "1", expectMemory: "1Gi"},
+		{name: "InvalidCPU", config: map[string]string{"limits.cpu": "3-1"}, expectError: true},
+		{name: "InvalidMemory", config: map[string]string{"limits.memory": "lots"}, expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			capacity, err := machineCapacity(tc.instanceType, tc.config, tc.resources)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(capacity).To(HaveKey(corev1.ResourceCPU))
+			g.Expect(capacity).To(HaveKey(corev1.ResourceMemory))
+			g.Expect(capacity.Cpu().Cmp(resource.MustParse(tc.expectCPU))).To(BeZero())
+			g.Expect(capacity.Memory().Cmp(resource.MustParse(tc.expectMemory))).To(BeZero())
+		})
+	}
+}
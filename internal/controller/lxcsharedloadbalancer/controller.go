@@ -0,0 +1,172 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lxcsharedloadbalancer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/finalizers"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// LXCSharedLoadBalancerReconciler reconciles a LXCSharedLoadBalancer object
+type LXCSharedLoadBalancerReconciler struct {
+	client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// LoadBalancerSyncPeriod is the interval at which the load balancer configuration is compared against the
+	// control plane instances of all member clusters. If zero, the configuration is only checked when the
+	// LXCSharedLoadBalancer or one of its member LXCClusters changes.
+	LoadBalancerSyncPeriod time.Duration
+
+	// SecretNamespace is the namespace of the secrets that LXCSharedLoadBalancers may use as credentials.
+	// LXCSharedLoadBalancers referencing secrets in other namespaces are not reconciled.
+	SecretNamespace string
+}
+
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcsharedloadbalancers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcsharedloadbalancers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=lxcsharedloadbalancers/finalizers,verbs=get;update;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *LXCSharedLoadBalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	// Fetch the sharedLB instance
+	sharedLB := &infrav1.LXCSharedLoadBalancer{}
+	if err := r.Get(ctx, req.NamespacedName, sharedLB); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// LXCSharedLoadBalancers are cluster-scoped, refuse to read credential secrets of other namespaces.
+	if secretErr := validateSecretRef(sharedLB, r.SecretNamespace); secretErr != nil {
+		log.WithValues("secret", sharedLB.GetLXCSecretNamespacedName()).Error(secretErr, "Refusing to use LXC credentials secret")
+		patchHelper, err := patch.NewHelper(sharedLB, r.Client)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkFalse(sharedLB, infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerProvisioningAbortedReason, clusterv1.ConditionSeverityError, "The shared load balancer could not be provisioned. The error was: %s", secretErr)
+		return ctrl.Result{}, patchLXCSharedLoadBalancer(ctx, patchHelper, sharedLB)
+	}
+
+	// Fetch the lxcSecret before adding any finalizers, so that objects without a valid secretRef do not get stuck
+	lxcSecret := &corev1.Secret{}
+	if err := r.Get(ctx, sharedLB.GetLXCSecretNamespacedName(), lxcSecret); err != nil {
+		log.WithValues("secret", sharedLB.GetLXCSecretNamespacedName()).Error(err, "Failed to fetch LXC credentials secret")
+		return ctrl.Result{}, fmt.Errorf("failed to fetch LXC credentials: %w", err)
+	}
+	lxcClient, err := lxc.New(ctx, lxc.ConfigurationFromKubernetesSecret(lxcSecret))
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create incus client: %w", err)
+	}
+
+	// Add finalizer first if not set to avoid the race condition between init and delete.
+	if finalizerAdded, err := finalizers.EnsureFinalizer(ctx, r.Client, sharedLB, infrav1.SharedLoadBalancerFinalizer); err != nil || finalizerAdded {
+		return ctrl.Result{}, err
+	}
+
+	// Initialize the patch helper
+	patchHelper, err := patch.NewHelper(sharedLB, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Always attempt to Patch the LXCSharedLoadBalancer object and status after each reconciliation.
+	defer func() {
+		if err := patchLXCSharedLoadBalancer(ctx, patchHelper, sharedLB); err != nil {
+			log.Error(err, "Failed to patch LXCSharedLoadBalancer")
+			if rerr == nil {
+				rerr = err
+			}
+		}
+	}()
+
+	lbManager := loadbalancer.NewSharedManager(lxcClient, sharedLB)
+
+	// Handle deleted shared load balancers
+	if !sharedLB.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, sharedLB, lbManager)
+	}
+
+	// Handle non-deleted shared load balancers
+	return r.reconcileNormal(ctx, sharedLB, lbManager)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LXCSharedLoadBalancerReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
+	if r.Client == nil {
+		return fmt.Errorf("required field Client must not be nil")
+	}
+
+	predicateLog := ctrl.LoggerFrom(ctx).WithValues("controller", "lxcsharedloadbalancer")
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&infrav1.LXCSharedLoadBalancer{}).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceHasFilterLabel(mgr.GetScheme(), predicateLog, r.WatchFilterValue)).
+		Watches(
+			&infrav1.LXCCluster{},
+			handler.EnqueueRequestsFromMapFunc(r.LXCClusterToLXCSharedLoadBalancer),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed setting up with a controller manager: %w", err)
+	}
+
+	return nil
+}
+
+// LXCClusterToLXCSharedLoadBalancer is a handler.ToRequestsFunc to be used to enqueue
+// requests for reconciliation of the LXCSharedLoadBalancer referenced by a LXCCluster.
+func (r *LXCSharedLoadBalancerReconciler) LXCClusterToLXCSharedLoadBalancer(ctx context.Context, o client.Object) []ctrl.Request {
+	c, ok := o.(*infrav1.LXCCluster)
+	if !ok {
+		panic(fmt.Sprintf("Expected a LXCCluster but got a %T", o))
+	}
+
+	var ref *infrav1.LXCSharedLoadBalancerRef
+	switch {
+	case c.Spec.LoadBalancer.LXC != nil:
+		ref = c.Spec.LoadBalancer.LXC.SharedLoadBalancerRef
+	case c.Spec.LoadBalancer.OCI != nil:
+		ref = c.Spec.LoadBalancer.OCI.SharedLoadBalancerRef
+	}
+	if ref == nil {
+		return nil
+	}
+
+	return []ctrl.Request{{NamespacedName: client.ObjectKey{Name: ref.Name}}}
+}
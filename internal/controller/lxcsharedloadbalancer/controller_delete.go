@@ -0,0 +1,43 @@
+package lxcsharedloadbalancer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func (r *LXCSharedLoadBalancerReconciler) reconcileDelete(ctx context.Context, sharedLB *infrav1.LXCSharedLoadBalancer, lbManager sharedLoadBalancerManager) (ctrl.Result, error) {
+	// Member clusters deregister themselves when they are deleted. Deleting the shared load balancer
+	// before that would break the control plane endpoint of the member clusters.
+	members, err := lbManager.Members(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to retrieve shared load balancer members: %w", err)
+	}
+	sharedLB.Status.Members = members
+	if len(members) > 0 {
+		log.FromContext(ctx).WithValues("members", len(members)).Info("Waiting for all member clusters to be deleted")
+		conditions.MarkFalse(sharedLB, infrav1.LoadBalancerAvailableCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "Waiting for %d member clusters to be deleted", len(members))
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	conditions.MarkFalse(sharedLB, infrav1.LoadBalancerAvailableCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
+
+	// Delete the container hosting the shared load balancer
+	log.FromContext(ctx).Info("Deleting shared load balancer")
+	if err := lbManager.Delete(ctx); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to delete the shared load balancer instance: %w", err)
+	}
+
+	// Shared load balancer is deleted so remove the finalizer.
+	controllerutil.RemoveFinalizer(sharedLB, infrav1.SharedLoadBalancerFinalizer)
+
+	return ctrl.Result{}, nil
+}
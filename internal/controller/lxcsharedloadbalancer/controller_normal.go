@@ -0,0 +1,59 @@
+package lxcsharedloadbalancer
+
+import (
+	"context"
+	"fmt"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func (r *LXCSharedLoadBalancerReconciler) reconcileNormal(ctx context.Context, sharedLB *infrav1.LXCSharedLoadBalancer, lbManager sharedLoadBalancerManager) (ctrl.Result, error) {
+	// Create the container hosting the shared load balancer.
+	log.FromContext(ctx).Info("Creating shared load balancer")
+	lbIPs, err := lbManager.Create(ctx)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to provision shared load balancer")
+		if utils.IsTerminalError(err) {
+			conditions.MarkFalse(sharedLB, infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerProvisioningAbortedReason, clusterv1.ConditionSeverityError, "The shared load balancer could not be provisioned. The error was: %s", err)
+			return ctrl.Result{}, nil
+		}
+		conditions.MarkFalse(sharedLB, infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerProvisioningFailedReason, clusterv1.ConditionSeverityWarning, "%s", err)
+		return ctrl.Result{}, err
+	}
+
+	// TODO(neoaggelos): care about IPv4 vs IPv6
+	sharedLB.Status.Address = lbIPs[0]
+	sharedLB.Status.Ready = true
+	conditions.MarkTrue(sharedLB, infrav1.LoadBalancerAvailableCondition)
+
+	// Member clusters register themselves with the shared load balancer, surface them in the status.
+	members, err := lbManager.Members(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to retrieve shared load balancer members: %w", err)
+	}
+	sharedLB.Status.Members = members
+
+	// Member clusters reconfigure the shared load balancer as their control plane instances change. This is
+	// a safety net for the initial configuration, and for instance changes across all member clusters.
+	synced, err := lbManager.IsSynced(ctx)
+	if err != nil {
+		conditions.MarkFalse(sharedLB, infrav1.LoadBalancerBackendsSyncedCondition, infrav1.LoadBalancerBackendsSyncFailedReason, clusterv1.ConditionSeverityWarning, "Failed to check load balancer backends: %s", err)
+		return ctrl.Result{}, fmt.Errorf("failed to check load balancer backends: %w", err)
+	}
+	if !synced {
+		log.FromContext(ctx).Info("Shared load balancer is out of sync, reconfiguring")
+		if err := lbManager.Reconfigure(ctx); err != nil {
+			conditions.MarkFalse(sharedLB, infrav1.LoadBalancerBackendsSyncedCondition, infrav1.LoadBalancerBackendsSyncFailedReason, clusterv1.ConditionSeverityWarning, "Failed to reconfigure load balancer: %s", err)
+			return ctrl.Result{}, fmt.Errorf("failed to reconfigure load balancer: %w", err)
+		}
+	}
+	conditions.MarkTrue(sharedLB, infrav1.LoadBalancerBackendsSyncedCondition)
+
+	return ctrl.Result{RequeueAfter: r.LoadBalancerSyncPeriod}, nil
+}
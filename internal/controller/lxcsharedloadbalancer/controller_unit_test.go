@@ -0,0 +1,228 @@
+package lxcsharedloadbalancer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// fakeSharedManager is a sharedLoadBalancerManager that keeps the registered member clusters in memory.
+// The load balancer is in sync if it was reconfigured after the last member cluster joined or left.
+type fakeSharedManager struct {
+	createErr error
+	deleteErr error
+
+	members    []infrav1.LXCSharedLoadBalancerMember
+	configured []infrav1.LXCSharedLoadBalancerMember
+
+	reconfigured bool
+	deleted      bool
+}
+
+func (m *fakeSharedManager) Create(context.Context) ([]string, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	return []string{"10.0.0.10"}, nil
+}
+
+func (m *fakeSharedManager) Delete(context.Context) error {
+	if m.deleteErr != nil {
+		return m.deleteErr
+	}
+	m.deleted = true
+	return nil
+}
+
+func (m *fakeSharedManager) Reconfigure(context.Context) error {
+	m.configured = slices.Clone(m.members)
+	m.reconfigured = true
+	return nil
+}
+
+func (m *fakeSharedManager) IsSynced(context.Context) (bool, error) {
+	return slices.Equal(m.configured, m.members), nil
+}
+
+func (m *fakeSharedManager) Members(context.Context) ([]infrav1.LXCSharedLoadBalancerMember, error) {
+	return slices.Clone(m.members), nil
+}
+
+func TestReconcileNormal(t *testing.T) {
+	c1 := infrav1.LXCSharedLoadBalancerMember{ClusterName: "c1", ClusterNamespace: "default", Port: 16443}
+	c2 := infrav1.LXCSharedLoadBalancerMember{ClusterName: "c2", ClusterNamespace: "default", Port: 16444}
+
+	t.Run("MembersJoinAndLeave", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := &LXCSharedLoadBalancerReconciler{LoadBalancerSyncPeriod: time.Minute}
+		sharedLB := &infrav1.LXCSharedLoadBalancer{}
+		lbManager := &fakeSharedManager{}
+
+		for _, step := range []struct {
+			name    string
+			members []infrav1.LXCSharedLoadBalancerMember
+		}{
+			{name: "NoMembers"},
+			{name: "Join", members: []infrav1.LXCSharedLoadBalancerMember{c1}},
+			{name: "JoinAnother", members: []infrav1.LXCSharedLoadBalancerMember{c1, c2}},
+			{name: "Leave", members: []infrav1.LXCSharedLoadBalancerMember{c2}},
+		} {
+			lbManager.members = step.members
+			lbManager.reconfigured = false
+
+			result, err := r.reconcileNormal(context.Background(), sharedLB, lbManager)
+			g.Expect(err).ToNot(HaveOccurred(), step.name)
+			g.Expect(result.RequeueAfter).To(Equal(time.Minute), step.name)
+			g.Expect(sharedLB.Status.Ready).To(BeTrue(), step.name)
+			g.Expect(sharedLB.Status.Address).To(Equal("10.0.0.10"), step.name)
+			g.Expect(sharedLB.Status.Members).To(Equal(step.members), step.name)
+			g.Expect(lbManager.configured).To(Equal(step.members), step.name)
+			g.Expect(conditions.IsTrue(sharedLB, infrav1.LoadBalancerBackendsSyncedCondition)).To(BeTrue(), step.name)
+		}
+
+		// nothing changed since the last member left, the load balancer is not reconfigured
+		lbManager.reconfigured = false
+		_, err := r.reconcileNormal(context.Background(), sharedLB, lbManager)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(lbManager.reconfigured).To(BeFalse())
+	})
+
+	for _, tc := range []struct {
+		name    string
+		members []infrav1.LXCSharedLoadBalancerMember
+	}{
+		{
+			name: "Port",
+			members: []infrav1.LXCSharedLoadBalancerMember{
+				{ClusterName: "c1", ClusterNamespace: "default", Port: 16443},
+				{ClusterName: "c1", ClusterNamespace: "other", Port: 16445},
+			},
+		},
+		{
+			name: "SNI",
+			members: []infrav1.LXCSharedLoadBalancerMember{
+				{ClusterName: "c1", ClusterNamespace: "default", Port: 6443, ServerName: "c1.example.com"},
+				{ClusterName: "c2", ClusterNamespace: "default", Port: 6443, ServerName: "c2.example.com"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &LXCSharedLoadBalancerReconciler{}
+			sharedLB := &infrav1.LXCSharedLoadBalancer{}
+			lbManager := &fakeSharedManager{members: tc.members}
+
+			_, err := r.reconcileNormal(context.Background(), sharedLB, lbManager)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(sharedLB.Status.Members).To(Equal(tc.members))
+			g.Expect(lbManager.reconfigured).To(BeTrue())
+		})
+	}
+
+	for _, tc := range []struct {
+		name        string
+		createErr   error
+		expectError bool
+		expectAbort bool
+	}{
+		{name: "CreateFailed", createErr: fmt.Errorf("server error"), expectError: true},
+		{name: "CreateAborted", createErr: utils.TerminalError(fmt.Errorf("server does not support OCI containers")), expectAbort: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &LXCSharedLoadBalancerReconciler{}
+			sharedLB := &infrav1.LXCSharedLoadBalancer{}
+
+			_, err := r.reconcileNormal(context.Background(), sharedLB, &fakeSharedManager{createErr: tc.createErr})
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(conditions.GetReason(sharedLB, infrav1.LoadBalancerAvailableCondition)).To(Equal(infrav1.LoadBalancerProvisioningFailedReason))
+			}
+			if tc.expectAbort {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(conditions.GetReason(sharedLB, infrav1.LoadBalancerAvailableCondition)).To(Equal(infrav1.LoadBalancerProvisioningAbortedReason))
+			}
+			g.Expect(sharedLB.Status.Ready).To(BeFalse())
+		})
+	}
+}
+
+func TestReconcileDelete(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		members       []infrav1.LXCSharedLoadBalancerMember
+		deleteErr     error
+		expectRequeue bool
+		expectError   bool
+		expectDeleted bool
+	}{
+		{
+			name:          "WaitForMembers",
+			members:       []infrav1.LXCSharedLoadBalancerMember{{ClusterName: "c1", ClusterNamespace: "default", Port: 16443}},
+			expectRequeue: true,
+		},
+		{name: "NoMembers", expectDeleted: true},
+		{name: "DeleteFailed", deleteErr: fmt.Errorf("server error"), expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &LXCSharedLoadBalancerReconciler{}
+			sharedLB := &infrav1.LXCSharedLoadBalancer{}
+			controllerutil.AddFinalizer(sharedLB, infrav1.SharedLoadBalancerFinalizer)
+			lbManager := &fakeSharedManager{members: tc.members, deleteErr: tc.deleteErr}
+
+			result, err := r.reconcileDelete(context.Background(), sharedLB, lbManager)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(result.RequeueAfter > 0).To(Equal(tc.expectRequeue))
+			g.Expect(lbManager.deleted).To(Equal(tc.expectDeleted))
+			g.Expect(controllerutil.ContainsFinalizer(sharedLB, infrav1.SharedLoadBalancerFinalizer)).To(Equal(!tc.expectDeleted))
+			g.Expect(sharedLB.Status.Members).To(Equal(tc.members))
+			g.Expect(conditions.GetReason(sharedLB, infrav1.LoadBalancerAvailableCondition)).To(Equal(clusterv1.DeletingReason))
+		})
+	}
+}
+
+func TestValidateSecretRef(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		namespace   string
+		expectAbort bool
+	}{
+		{name: "SecretNamespace", namespace: "capn-system"},
+		{name: "OtherNamespace", namespace: "tenant", expectAbort: true},
+		{name: "NoNamespace", expectAbort: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			sharedLB := &infrav1.LXCSharedLoadBalancer{Spec: infrav1.LXCSharedLoadBalancerSpec{
+				SecretRef: infrav1.NamespacedSecretRef{Name: "secret", Namespace: tc.namespace},
+			}}
+			err := validateSecretRef(sharedLB, "capn-system")
+			if tc.expectAbort {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package lxcsharedloadbalancer
+
+import (
+	"context"
+	"fmt"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// sharedLoadBalancerManager manages the load balancer instance of a LXCSharedLoadBalancer.
+type sharedLoadBalancerManager interface {
+	Create(ctx context.Context) ([]string, error)
+	Delete(ctx context.Context) error
+	Reconfigure(ctx context.Context) error
+	IsSynced(ctx context.Context) (bool, error)
+	Members(ctx context.Context) ([]infrav1.LXCSharedLoadBalancerMember, error)
+}
+
+var _ sharedLoadBalancerManager = &loadbalancer.SharedManager{}
+
+// validateSecretRef checks that the LXCSharedLoadBalancer references a secret in the allowed namespace.
+// LXCSharedLoadBalancers are cluster-scoped, so they must not be able to use the credentials of any tenant.
+func validateSecretRef(sharedLB *infrav1.LXCSharedLoadBalancer, secretNamespace string) error {
+	if sharedLB.Spec.SecretRef.Namespace != secretNamespace {
+		return utils.TerminalError(fmt.Errorf("secretRef must reference a secret in namespace %q, but namespace %q was specified", secretNamespace, sharedLB.Spec.SecretRef.Namespace))
+	}
+	return nil
+}
+
+func patchLXCSharedLoadBalancer(ctx context.Context, patchHelper *patch.Helper, sharedLB *infrav1.LXCSharedLoadBalancer) error {
+	// Always update the readyCondition by summarizing the state of other conditions.
+	conditions.SetSummary(sharedLB,
+		conditions.WithConditions(infrav1.LoadBalancerAvailableCondition, infrav1.LoadBalancerBackendsSyncedCondition),
+	)
+
+	// Patch the object, ignoring conflicts on the conditions owned by this controller.
+	return patchHelper.Patch(
+		ctx,
+		sharedLB,
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			infrav1.LoadBalancerAvailableCondition,
+			infrav1.LoadBalancerBackendsSyncedCondition,
+			clusterv1.ReadyCondition,
+		}},
+	)
+}
@@ -0,0 +1,132 @@
+package endpointdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+const (
+	// TSIGKeyNameKey is the key of the TSIG secret with the name of the TSIG key.
+	TSIGKeyNameKey = "name"
+	// TSIGSecretKey is the key of the TSIG secret with the base64-encoded TSIG key.
+	TSIGSecretKey = "secret"
+	// TSIGAlgorithmKey is the key of the TSIG secret with the TSIG algorithm. It is optional.
+	TSIGAlgorithmKey = "algorithm"
+)
+
+// Registrar manages the DNS records of the control plane endpoint of a cluster.
+type Registrar interface {
+	// Register ensures that the DNS name of the control plane endpoint resolves to the specified addresses.
+	// Implementations can indicate non-retriable failures (e.g. because of Incus not having the required extensions).
+	// Callers must check these with utils.IsTerminalError() and treat them as terminal failures.
+	Register(ctx context.Context, addresses []string) error
+	// Deregister removes the DNS records of the control plane endpoint.
+	Deregister(ctx context.Context) error
+}
+
+// RegistrarForCluster returns the proper Registrar based on the lxcCluster spec. It returns nil if the lxcCluster does
+// not have a control plane endpoint DNS configuration. A missing or incomplete TSIG secret is a terminal error, such
+// that callers do not block the deletion of the cluster on it.
+func RegistrarForCluster(ctx context.Context, c client.Client, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client) (Registrar, error) {
+	spec := lxcCluster.Spec.ControlPlaneEndpointDNS
+	if spec == nil {
+		return nil, nil
+	}
+
+	name, err := RecordName(spec.Name, spec.Zone)
+	if err != nil {
+		return nil, err
+	}
+	ttl := uint32(60)
+	if spec.TTL > 0 {
+		ttl = uint32(spec.TTL)
+	}
+
+	switch spec.Provider {
+	case infrav1.ControlPlaneEndpointDNSProviderNetworkZone:
+		return &registrarNetworkZone{
+			lxcClient:   lxcClient,
+			zone:        spec.Zone,
+			name:        name,
+			ttl:         ttl,
+			description: fmt.Sprintf("Control plane endpoint of cluster %s/%s", lxcCluster.Namespace, lxcCluster.Name),
+		}, nil
+	case infrav1.ControlPlaneEndpointDNSProviderRFC2136:
+		if spec.RFC2136 == nil {
+			return nil, fmt.Errorf("missing rfc2136 configuration")
+		}
+		r := &registrarRFC2136{
+			server:   spec.RFC2136.Server,
+			protocol: spec.RFC2136.Protocol,
+			zone:     spec.Zone,
+			fqdn:     spec.Name,
+			ttl:      ttl,
+		}
+		if ref := spec.RFC2136.TSIGSecretRef; ref != nil {
+			secret := &corev1.Secret{}
+			key := client.ObjectKey{Namespace: lxcCluster.Namespace, Name: ref.Name}
+			if err := c.Get(ctx, key, secret); err != nil {
+				if apierrors.IsNotFound(err) {
+					err = utils.TerminalError(err)
+				}
+				return nil, fmt.Errorf("failed to retrieve TSIG secret %s: %w", key, err)
+			}
+			for _, k := range []string{TSIGKeyNameKey, TSIGSecretKey} {
+				if _, ok := secret.Data[k]; !ok {
+					return nil, utils.TerminalError(fmt.Errorf("TSIG secret %s does not have key %q", key, k))
+				}
+			}
+			r.tsigKeyName = string(secret.Data[TSIGKeyNameKey])
+			r.tsigSecret = string(secret.Data[TSIGSecretKey])
+			r.tsigAlgorithm = string(secret.Data[TSIGAlgorithmKey])
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("unknown control plane endpoint DNS provider %q", spec.Provider)
+	}
+}
+
+// RecordName returns the name of the DNS record for name, relative to zone. It returns "@" if name is the zone apex.
+func RecordName(name string, zone string) (string, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+
+	switch {
+	case name == zone:
+		return "@", nil
+	case strings.HasSuffix(name, "."+zone):
+		return strings.TrimSuffix(name, "."+zone), nil
+	default:
+		return "", fmt.Errorf("name %q is not in zone %q", name, zone)
+	}
+}
+
+// splitAddresses returns the sorted IPv4 and IPv6 addresses. Invalid addresses are ignored.
+func splitAddresses(addresses []string) ([]string, []string) {
+	var ipv4, ipv6 []string
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		switch {
+		case ip == nil:
+			continue
+		case ip.To4() != nil:
+			ipv4 = append(ipv4, ip.String())
+		default:
+			ipv6 = append(ipv6, ip.String())
+		}
+	}
+	slices.Sort(ipv4)
+	slices.Sort(ipv6)
+	return slices.Compact(ipv4), slices.Compact(ipv6)
+}
@@ -0,0 +1,79 @@
+package endpointdns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// registrarNetworkZone is a Registrar that manages DNS records in an Incus network zone.
+type registrarNetworkZone struct {
+	lxcClient *lxc.Client
+
+	zone        string
+	name        string
+	ttl         uint32
+	description string
+}
+
+// Register implements Registrar.
+func (r *registrarNetworkZone) Register(ctx context.Context, addresses []string) error {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("zone", r.zone, "record", r.name))
+
+	if err := r.lxcClient.SupportsNetworkDNSRecords(); err != nil {
+		return err
+	}
+
+	var entries []api.NetworkZoneRecordEntry
+	ipv4, ipv6 := splitAddresses(addresses)
+	for _, address := range ipv4 {
+		entries = append(entries, api.NetworkZoneRecordEntry{Type: "A", TTL: uint64(r.ttl), Value: address})
+	}
+	for _, address := range ipv6 {
+		entries = append(entries, api.NetworkZoneRecordEntry{Type: "AAAA", TTL: uint64(r.ttl), Value: address})
+	}
+
+	record, etag, err := r.lxcClient.GetNetworkZoneRecord(r.zone, r.name)
+	if err != nil {
+		if !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return fmt.Errorf("failed to GetNetworkZoneRecord: %w", err)
+		}
+
+		log.FromContext(ctx).V(1).WithValues("entries", entries).Info("Creating network zone record")
+		if err := r.lxcClient.CreateNetworkZoneRecord(r.zone, api.NetworkZoneRecordsPost{
+			Name: r.name,
+			NetworkZoneRecordPut: api.NetworkZoneRecordPut{
+				Description: r.description,
+				Entries:     entries,
+				Config:      map[string]string{},
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to CreateNetworkZoneRecord: %w", err)
+		}
+		return nil
+	}
+
+	put := record.Writable()
+	put.Entries = entries
+
+	log.FromContext(ctx).V(1).WithValues("entries", entries).Info("Updating network zone record")
+	if err := r.lxcClient.UpdateNetworkZoneRecord(r.zone, r.name, put, etag); err != nil {
+		return fmt.Errorf("failed to UpdateNetworkZoneRecord: %w", err)
+	}
+	return nil
+}
+
+// Deregister implements Registrar.
+func (r *registrarNetworkZone) Deregister(ctx context.Context) error {
+	log.FromContext(ctx).V(1).WithValues("zone", r.zone, "record", r.name).Info("Deleting network zone record")
+
+	if err := r.lxcClient.DeleteNetworkZoneRecord(r.zone, r.name); err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to DeleteNetworkZoneRecord: %w", err)
+	}
+	return nil
+}
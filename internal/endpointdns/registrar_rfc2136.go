@@ -0,0 +1,89 @@
+package endpointdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// registrarRFC2136 is a Registrar that manages DNS records with RFC 2136 dynamic updates.
+type registrarRFC2136 struct {
+	server   string
+	protocol string
+
+	zone string
+	fqdn string
+	ttl  uint32
+
+	tsigKeyName   string
+	tsigSecret    string
+	tsigAlgorithm string
+}
+
+// Register implements Registrar.
+//
+// Register replaces the A and AAAA records of the control plane endpoint name in a single update.
+func (r *registrarRFC2136) Register(ctx context.Context, addresses []string) error {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("server", r.server, "zone", r.zone, "name", r.fqdn))
+
+	var rrs []dns.RR
+	ipv4, ipv6 := splitAddresses(addresses)
+	for _, address := range ipv4 {
+		rrs = append(rrs, &dns.A{Hdr: r.header(dns.TypeA, r.ttl), A: net.ParseIP(address)})
+	}
+	for _, address := range ipv6 {
+		rrs = append(rrs, &dns.AAAA{Hdr: r.header(dns.TypeAAAA, r.ttl), AAAA: net.ParseIP(address)})
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(dns.Fqdn(r.zone))
+	m.RemoveRRset([]dns.RR{&dns.A{Hdr: r.header(dns.TypeA, 0)}, &dns.AAAA{Hdr: r.header(dns.TypeAAAA, 0)}})
+	if len(rrs) > 0 {
+		m.Insert(rrs)
+	}
+
+	log.FromContext(ctx).V(1).WithValues("ipv4", ipv4, "ipv6", ipv6).Info("Sending DNS update")
+	return r.exchange(ctx, m)
+}
+
+// Deregister implements Registrar.
+func (r *registrarRFC2136) Deregister(ctx context.Context) error {
+	log.FromContext(ctx).V(1).WithValues("server", r.server, "zone", r.zone, "name", r.fqdn).Info("Removing DNS records")
+
+	m := new(dns.Msg)
+	m.SetUpdate(dns.Fqdn(r.zone))
+	m.RemoveRRset([]dns.RR{&dns.A{Hdr: r.header(dns.TypeA, 0)}, &dns.AAAA{Hdr: r.header(dns.TypeAAAA, 0)}})
+
+	return r.exchange(ctx, m)
+}
+
+func (r *registrarRFC2136) header(rrtype uint16, ttl uint32) dns.RR_Header {
+	return dns.RR_Header{Name: dns.Fqdn(r.fqdn), Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+}
+
+// exchange sends the update message to the DNS server, signing it with the TSIG key if configured.
+func (r *registrarRFC2136) exchange(ctx context.Context, m *dns.Msg) error {
+	c := &dns.Client{Net: r.protocol, Timeout: 10 * time.Second}
+	if r.tsigKeyName != "" {
+		algorithm := dns.HmacSHA256
+		if r.tsigAlgorithm != "" {
+			algorithm = dns.Fqdn(r.tsigAlgorithm)
+		}
+		keyName := dns.Fqdn(r.tsigKeyName)
+		c.TsigSecret = map[string]string{keyName: r.tsigSecret}
+		m.SetTsig(keyName, algorithm, 300, time.Now().Unix())
+	}
+
+	resp, _, err := c.ExchangeContext(ctx, m, r.server)
+	if err != nil {
+		return fmt.Errorf("failed to send DNS update to %s: %w", r.server, err)
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("DNS update rejected by %s: %s", r.server, dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
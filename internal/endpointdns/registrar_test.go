@@ -0,0 +1,205 @@
+package endpointdns
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestRecordName(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		zone        string
+		expect      string
+		expectError bool
+	}{
+		{name: "c1.k8s.example.com", zone: "k8s.example.com", expect: "c1"},
+		{name: "c1.k8s.example.com.", zone: "K8S.example.com.", expect: "c1"},
+		{name: "api.c1.k8s.example.com", zone: "k8s.example.com", expect: "api.c1"},
+		{name: "k8s.example.com", zone: "k8s.example.com", expect: "@"},
+		{name: "c1.example.com", zone: "k8s.example.com", expectError: true},
+		{name: "c1.xk8s.example.com", zone: "k8s.example.com", expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			name, err := RecordName(tc.name, tc.zone)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(name).To(Equal(tc.expect))
+			}
+		})
+	}
+}
+
+func TestRegistrarForClusterTSIGSecret(t *testing.T) {
+	lxcCluster := &infrav1.LXCCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "c1"},
+		Spec: infrav1.LXCClusterSpec{
+			ControlPlaneEndpointDNS: &infrav1.LXCClusterControlPlaneEndpointDNS{
+				Name:     "c1.k8s.example.com",
+				Zone:     "k8s.example.com",
+				Provider: infrav1.ControlPlaneEndpointDNSProviderRFC2136,
+				RFC2136: &infrav1.LXCClusterDNSRFC2136{
+					Server:        "10.0.0.53:53",
+					TSIGSecretRef: &infrav1.SecretRef{Name: "tsig"},
+				},
+			},
+		},
+	}
+	newSecret := func(data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "tsig"}, Data: data}
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithObjects(newSecret(map[string][]byte{TSIGKeyNameKey: []byte("capn."), TSIGSecretKey: []byte("c2VjcmV0")})).Build()
+		registrar, err := RegistrarForCluster(context.Background(), c, lxcCluster, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(registrar).To(BeAssignableToTypeOf(&registrarRFC2136{}))
+	})
+
+	t.Run("SecretNotFound", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := RegistrarForCluster(context.Background(), fake.NewClientBuilder().Build(), lxcCluster, nil)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+	})
+
+	t.Run("MissingKey", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithObjects(newSecret(map[string][]byte{TSIGKeyNameKey: []byte("capn.")})).Build()
+		_, err := RegistrarForCluster(context.Background(), c, lxcCluster, nil)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+	})
+}
+
+func TestSplitAddresses(t *testing.T) {
+	g := NewWithT(t)
+
+	ipv4, ipv6 := splitAddresses([]string{"10.0.0.2", "fd42::10", "invalid", "10.0.0.1", "10.0.0.2"})
+	g.Expect(ipv4).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+	g.Expect(ipv6).To(Equal([]string{"fd42::10"}))
+}
+
+func TestRegistrarRFC2136(t *testing.T) {
+	const (
+		tsigKeyName = "capn."
+		tsigSecret  = "c2VjcmV0c2VjcmV0c2VjcmV0c2VjcmV0"
+	)
+
+	// newServer starts a local DNS server that stands in for BIND or PowerDNS, and records the received updates.
+	newServer := func(t *testing.T, rcode int) (string, func() []*dns.Msg) {
+		var (
+			mu       sync.Mutex
+			messages []*dns.Msg
+		)
+
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		server := &dns.Server{
+			PacketConn: pc,
+			TsigSecret: map[string]string{tsigKeyName: tsigSecret},
+			// The default accept function rejects UPDATE messages.
+			MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+			Handler: dns.HandlerFunc(func(w dns.ResponseWriter, m *dns.Msg) {
+				resp := new(dns.Msg)
+				resp.SetRcode(m, rcode)
+				if m.IsTsig() != nil {
+					if w.TsigStatus() != nil {
+						resp.SetRcode(m, dns.RcodeNotAuth)
+					}
+					resp.SetTsig(tsigKeyName, dns.HmacSHA256, 300, int64(m.IsTsig().TimeSigned))
+				}
+
+				mu.Lock()
+				messages = append(messages, m)
+				mu.Unlock()
+
+				_ = w.WriteMsg(resp)
+			}),
+		}
+
+		started := make(chan struct{})
+		server.NotifyStartedFunc = func() { close(started) }
+		go func() { _ = server.ActivateAndServe() }()
+		<-started
+		t.Cleanup(func() { _ = server.Shutdown() })
+
+		return pc.LocalAddr().String(), func() []*dns.Msg {
+			mu.Lock()
+			defer mu.Unlock()
+			return messages
+		}
+	}
+
+	t.Run("Register", func(t *testing.T) {
+		g := NewWithT(t)
+		address, messages := newServer(t, dns.RcodeSuccess)
+
+		r := &registrarRFC2136{server: address, protocol: "udp", zone: "k8s.example.com", fqdn: "c1.k8s.example.com", ttl: 60}
+		g.Expect(r.Register(context.Background(), []string{"10.0.0.10", "fd42::10"})).To(Succeed())
+
+		g.Expect(messages()).To(HaveLen(1))
+		m := messages()[0]
+		g.Expect(m.Opcode).To(Equal(dns.OpcodeUpdate))
+		g.Expect(m.Question[0].Name).To(Equal("k8s.example.com."))
+
+		var updates []string
+		for _, rr := range m.Ns {
+			updates = append(updates, rr.String())
+		}
+		g.Expect(updates).To(Equal([]string{
+			"c1.k8s.example.com.\t0\tCLASS255\tA\t",
+			"c1.k8s.example.com.\t0\tCLASS255\tAAAA\t",
+			"c1.k8s.example.com.\t60\tIN\tA\t10.0.0.10",
+			"c1.k8s.example.com.\t60\tIN\tAAAA\tfd42::10",
+		}))
+	})
+
+	t.Run("Deregister", func(t *testing.T) {
+		g := NewWithT(t)
+		address, messages := newServer(t, dns.RcodeSuccess)
+
+		r := &registrarRFC2136{server: address, protocol: "udp", zone: "k8s.example.com", fqdn: "c1.k8s.example.com", ttl: 60}
+		g.Expect(r.Deregister(context.Background())).To(Succeed())
+
+		g.Expect(messages()).To(HaveLen(1))
+		g.Expect(messages()[0].Ns).To(HaveLen(2))
+	})
+
+	t.Run("TSIG", func(t *testing.T) {
+		g := NewWithT(t)
+		address, messages := newServer(t, dns.RcodeSuccess)
+
+		r := &registrarRFC2136{server: address, protocol: "udp", zone: "k8s.example.com", fqdn: "c1.k8s.example.com", ttl: 60, tsigKeyName: "capn", tsigSecret: tsigSecret}
+		g.Expect(r.Register(context.Background(), []string{"10.0.0.10"})).To(Succeed())
+		g.Expect(messages()[0].IsTsig()).ToNot(BeNil())
+	})
+
+	t.Run("Refused", func(t *testing.T) {
+		g := NewWithT(t)
+		address, _ := newServer(t, dns.RcodeRefused)
+
+		r := &registrarRFC2136{server: address, protocol: "udp", zone: "k8s.example.com", fqdn: "c1.k8s.example.com", ttl: 60}
+		g.Expect(r.Register(context.Background(), []string{"10.0.0.10"})).To(MatchError(ContainSubstring("REFUSED")))
+	})
+}
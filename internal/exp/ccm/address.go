@@ -0,0 +1,80 @@
+package ccm
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// addressRange is an inclusive range of IP addresses.
+type addressRange struct {
+	start netip.Addr
+	end   netip.Addr
+}
+
+// parseAddressRange parses a CIDR (e.g. "10.0.0.96/28") or an address range (e.g. "10.0.0.100-10.0.0.120").
+func parseAddressRange(value string) (addressRange, error) {
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		prefix = prefix.Masked()
+		return addressRange{start: prefix.Addr(), end: lastAddress(prefix)}, nil
+	}
+
+	startValue, endValue, ok := strings.Cut(value, "-")
+	if !ok {
+		return addressRange{}, fmt.Errorf("%q is not a CIDR or an address range", value)
+	}
+	start, err := netip.ParseAddr(strings.TrimSpace(startValue))
+	if err != nil {
+		return addressRange{}, fmt.Errorf("invalid start address: %w", err)
+	}
+	end, err := netip.ParseAddr(strings.TrimSpace(endValue))
+	if err != nil {
+		return addressRange{}, fmt.Errorf("invalid end address: %w", err)
+	}
+	if start.Is4() != end.Is4() || end.Less(start) {
+		return addressRange{}, fmt.Errorf("invalid address range %q", value)
+	}
+	return addressRange{start: start, end: end}, nil
+}
+
+// lastAddress returns the last address of a masked prefix, with all host bits set.
+func lastAddress(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// allocateAddress returns the first address of the range that is not used. Only the used addresses are iterated,
+// such that large ranges (e.g. an IPv6 /64) are handled without walking the range.
+func allocateAddress(value string, used map[string]string) (string, error) {
+	r, err := parseAddressRange(value)
+	if err != nil {
+		return "", err
+	}
+
+	inRange := make([]netip.Addr, 0, len(used))
+	for key := range used {
+		if addr, err := netip.ParseAddr(key); err == nil && !addr.Less(r.start) && !r.end.Less(addr) {
+			inRange = append(inRange, addr)
+		}
+	}
+	slices.SortFunc(inRange, netip.Addr.Compare)
+
+	candidate := r.start
+	for _, addr := range inRange {
+		if candidate.Less(addr) {
+			break
+		}
+		if addr == candidate {
+			candidate = candidate.Next()
+		}
+	}
+	if !candidate.IsValid() || r.end.Less(candidate) {
+		return "", fmt.Errorf("no free addresses left in address range %q", value)
+	}
+	return candidate.String(), nil
+}
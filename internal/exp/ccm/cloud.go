@@ -0,0 +1,90 @@
+package ccm
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	cloudprovider "k8s.io/cloud-provider"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// ProviderName is the name of the Incus cloud provider.
+const ProviderName = "incus"
+
+// cloud implements cloudprovider.Interface for workload clusters running on Incus.
+type cloud struct {
+	instances     *instances
+	loadBalancers *loadBalancers
+}
+
+var _ cloudprovider.Interface = &cloud{}
+
+// New creates the Incus cloud provider from the cloud configuration file.
+func New(ctx context.Context, configFile io.Reader) (cloudprovider.Interface, error) {
+	config, err := ReadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	lxcClient, err := lxc.New(ctx, config.lxcConfiguration())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create incus client: %w", err)
+	}
+
+	c := &cloud{
+		instances: &instances{lxcClient: lxcClient, project: lxcClient.GetProject(), providerIDFormat: config.ProviderIDFormat, region: config.Region},
+	}
+	if config.LoadBalancer != nil {
+		c.loadBalancers = newLoadBalancers(lxcClient, config.LoadBalancer)
+	}
+	return c, nil
+}
+
+// Initialize implements cloudprovider.Interface.
+func (c *cloud) Initialize(clientBuilder cloudprovider.ControllerClientBuilder, stop <-chan struct{}) {
+}
+
+// LoadBalancer implements cloudprovider.Interface.
+func (c *cloud) LoadBalancer() (cloudprovider.LoadBalancer, bool) {
+	if c.loadBalancers == nil {
+		return nil, false
+	}
+	return c.loadBalancers, true
+}
+
+// Instances implements cloudprovider.Interface.
+func (c *cloud) Instances() (cloudprovider.Instances, bool) {
+	return nil, false
+}
+
+// InstancesV2 implements cloudprovider.Interface.
+func (c *cloud) InstancesV2() (cloudprovider.InstancesV2, bool) {
+	return c.instances, true
+}
+
+// Zones implements cloudprovider.Interface.
+func (c *cloud) Zones() (cloudprovider.Zones, bool) {
+	return nil, false
+}
+
+// Clusters implements cloudprovider.Interface.
+func (c *cloud) Clusters() (cloudprovider.Clusters, bool) {
+	return nil, false
+}
+
+// Routes implements cloudprovider.Interface.
+func (c *cloud) Routes() (cloudprovider.Routes, bool) {
+	return nil, false
+}
+
+// ProviderName implements cloudprovider.Interface.
+func (c *cloud) ProviderName() string {
+	return ProviderName
+}
+
+// HasClusterID implements cloudprovider.Interface.
+func (c *cloud) HasClusterID() bool {
+	return true
+}
@@ -0,0 +1,109 @@
+package ccm
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestReadConfig(t *testing.T) {
+	t.Run("Full", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config, err := ReadConfig(strings.NewReader(`
+server: https://10.0.0.49:8443
+client-crt: CRT
+client-key: KEY
+insecure-skip-verify: true
+project: p1
+region: lab
+loadBalancer:
+  mode: ovn
+  network: ovn0
+  addressRange: 10.100.42.100-10.100.42.120
+`))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(config.lxcConfiguration().ServerURL).To(Equal("https://10.0.0.49:8443"))
+		g.Expect(config.lxcConfiguration().ClientCrt).To(Equal("CRT"))
+		g.Expect(config.lxcConfiguration().InsecureSkipVerify).To(BeTrue())
+		g.Expect(config.lxcConfiguration().Project).To(Equal("p1"))
+		g.Expect(config.Region).To(Equal("lab"))
+		g.Expect(config.LoadBalancer).To(Equal(&LoadBalancerConfig{Mode: "ovn", Network: "ovn0", AddressRange: "10.100.42.100-10.100.42.120"}))
+	})
+
+	for _, tc := range []struct {
+		name   string
+		config string
+	}{
+		{name: "NoServer", config: `project: default`},
+		{name: "InvalidMode", config: "server: unix://\nloadBalancer: {mode: haproxy, network: n}"},
+		{name: "NoNetwork", config: "server: unix://\nloadBalancer: {mode: ovn}"},
+		{name: "InvalidAddressRange", config: "server: unix://\nloadBalancer: {mode: ovn, network: n, addressRange: invalid}"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := ReadConfig(strings.NewReader(tc.config))
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
+
+func TestParseAddressRange(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		value       string
+		expectStart string
+		expectEnd   string
+	}{
+		{name: "CIDR", value: "10.0.0.96/28", expectStart: "10.0.0.96", expectEnd: "10.0.0.111"},
+		{name: "CIDRSingle", value: "10.0.0.96/32", expectStart: "10.0.0.96", expectEnd: "10.0.0.96"},
+		{name: "IPv6CIDR", value: "fd42:0:0:1::/64", expectStart: "fd42:0:0:1::", expectEnd: "fd42::1:ffff:ffff:ffff:ffff"},
+		{name: "IPv6CIDRLarge", value: "fd00::/8", expectStart: "fd00::", expectEnd: "fdff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{name: "Range", value: "10.0.0.100 - 10.0.0.120", expectStart: "10.0.0.100", expectEnd: "10.0.0.120"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r, err := parseAddressRange(tc.value)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(r.start.String()).To(Equal(tc.expectStart))
+			g.Expect(r.end.String()).To(Equal(tc.expectEnd))
+		})
+	}
+}
+
+func TestAllocateAddress(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		value       string
+		used        map[string]string
+		expect      string
+		expectError bool
+	}{
+		{name: "Range", value: "10.0.0.100-10.0.0.102", expect: "10.0.0.100"},
+		{name: "RangeUsed", value: "10.0.0.100-10.0.0.102", used: map[string]string{"10.0.0.100": "", "10.0.0.101": "c/default/svc"}, expect: "10.0.0.102"},
+		{name: "RangeExhausted", value: "10.0.0.100-10.0.0.101", used: map[string]string{"10.0.0.100": "", "10.0.0.101": ""}, expectError: true},
+		{name: "CIDR", value: "10.0.0.96/30", used: map[string]string{"10.0.0.96": ""}, expect: "10.0.0.97"},
+		{name: "IPv6", value: "fd42::10-fd42::20", used: map[string]string{"fd42::10": ""}, expect: "fd42::11"},
+		{name: "IPv6CIDR", value: "fd42:0:0:1::/64", used: map[string]string{"fd42:0:0:1::": "", "fd42:0:0:1::1": "", "fd42::1": ""}, expect: "fd42:0:0:1::2"},
+		{name: "IPv6CIDRUnmasked", value: "fd42::1234/120", used: map[string]string{"fd42::1200": ""}, expect: "fd42::1201"},
+		{name: "CIDRExhausted", value: "10.0.0.96/31", used: map[string]string{"10.0.0.96": "", "10.0.0.97": ""}, expectError: true},
+		{name: "CIDRUsedOutOfRange", value: "10.0.0.96/30", used: map[string]string{"10.0.0.95": "", "10.0.0.100": ""}, expect: "10.0.0.96"},
+		{name: "InvalidRange", value: "10.0.0.102-10.0.0.100", expectError: true},
+		{name: "MixedFamilies", value: "10.0.0.100-fd42::1", expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			address, err := allocateAddress(tc.value, tc.used)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(address).To(Equal(tc.expect))
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package ccm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+	corev1 "k8s.io/api/core/v1"
+	cloudprovider "k8s.io/cloud-provider"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// instances implements cloudprovider.InstancesV2.
+type instances struct {
+	lxcClient *lxc.Client
+
+	project          string
+	providerIDFormat infrav1.ProviderIDFormat
+	region           string
+}
+
+var _ cloudprovider.InstancesV2 = &instances{}
+
+// InstanceExists implements cloudprovider.InstancesV2.
+//
+// The node lifecycle controller deletes the Node object if the instance does not exist.
+func (i *instances) InstanceExists(ctx context.Context, node *corev1.Node) (bool, error) {
+	name, err := instanceNameForNode(node, i.project)
+	if err != nil {
+		return false, err
+	}
+	if _, _, err := i.lxcClient.GetInstance(name); err != nil {
+		if api.StatusErrorCheck(err, http.StatusNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to GetInstance: %w", err)
+	}
+	return true, nil
+}
+
+// InstanceShutdown implements cloudprovider.InstancesV2.
+func (i *instances) InstanceShutdown(ctx context.Context, node *corev1.Node) (bool, error) {
+	name, err := instanceNameForNode(node, i.project)
+	if err != nil {
+		return false, err
+	}
+	instance, _, err := i.lxcClient.GetInstance(name)
+	if err != nil {
+		return false, fmt.Errorf("failed to GetInstance: %w", err)
+	}
+	return instance.StatusCode != api.Running, nil
+}
+
+// InstanceMetadata implements cloudprovider.InstancesV2.
+func (i *instances) InstanceMetadata(ctx context.Context, node *corev1.Node) (*cloudprovider.InstanceMetadata, error) {
+	name, err := instanceNameForNode(node, i.project)
+	if err != nil {
+		return nil, err
+	}
+	log.FromContext(ctx).V(4).Info("Retrieving instance metadata", "node", node.Name, "instance", name)
+
+	instance, _, err := i.lxcClient.GetInstanceFull(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetInstanceFull: %w", err)
+	}
+
+	return instanceMetadata(node, instance, i.providerIDFormat, i.project, i.region), nil
+}
+
+// instanceMetadata returns the metadata of a node from the respective instance.
+func instanceMetadata(node *corev1.Node, instance *api.InstanceFull, providerIDFormat infrav1.ProviderIDFormat, project string, region string) *cloudprovider.InstanceMetadata {
+	// Instances on clustered servers report the cluster member they are running on.
+	var member string
+	if instance.Location != "" && instance.Location != "none" {
+		member = instance.Location
+	}
+
+	// The provider ID of a node cannot change once set, so existing provider IDs (e.g. in the legacy format) are kept.
+	providerID := infrav1.ProviderID{Instance: instance.Name}
+	switch providerIDFormat {
+	case infrav1.ProviderIDFormatProject:
+		providerID.Project = project
+	case infrav1.ProviderIDFormatProjectMember:
+		providerID.Project = project
+		providerID.Member = member
+	}
+
+	metadata := &cloudprovider.InstanceMetadata{
+		ProviderID:   providerID.String(),
+		InstanceType: instance.Type,
+		Zone:         member,
+		Region:       region,
+	}
+	if node.Spec.ProviderID != "" {
+		metadata.ProviderID = node.Spec.ProviderID
+	}
+
+	for _, address := range lxc.ParseHostAddresses(instance.State) {
+		metadata.NodeAddresses = append(metadata.NodeAddresses, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: address})
+	}
+	metadata.NodeAddresses = append(metadata.NodeAddresses, corev1.NodeAddress{Type: corev1.NodeHostName, Address: node.Name})
+
+	return metadata
+}
+
+// instanceNameForNode returns the name of the instance of a node, from its provider ID or its name. It returns an
+// error if the provider ID of the node refers to an instance in a different project.
+func instanceNameForNode(node *corev1.Node, project string) (string, error) {
+	if !strings.HasPrefix(node.Spec.ProviderID, "lxc://") {
+		return node.Name, nil
+	}
+
+	providerID, err := infrav1.ParseProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse provider ID of node %q: %w", node.Name, err)
+	}
+	if !providerID.Matches(infrav1.ProviderID{Project: project, Instance: providerID.Instance}) {
+		return "", fmt.Errorf("node %q has provider ID %q, which is not in project %q", node.Name, node.Spec.ProviderID, project)
+	}
+	return providerID.Instance, nil
+}
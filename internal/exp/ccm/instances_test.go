@@ -0,0 +1,85 @@
+package ccm
+
+import (
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cloudprovider "k8s.io/cloud-provider"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func TestInstanceNameForNode(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		providerID  string
+		expectName  string
+		expectError bool
+	}{
+		{name: "NoProviderID", expectName: "node"},
+		{name: "OtherProvider", providerID: "other:///instance", expectName: "node"},
+		{name: "Legacy", providerID: "lxc:///instance", expectName: "instance"},
+		{name: "Project", providerID: "lxc://proj/instance", expectName: "instance"},
+		{name: "ProjectMember", providerID: "lxc://proj/w01/instance", expectName: "instance"},
+		{name: "OtherProject", providerID: "lxc://other/instance", expectError: true},
+		{name: "Invalid", providerID: "lxc://proj", expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			name, err := instanceNameForNode(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}, Spec: corev1.NodeSpec{ProviderID: tc.providerID}}, "proj")
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(name).To(Equal(tc.expectName))
+			}
+		})
+	}
+}
+
+func TestInstanceMetadata(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "c1-md-0-abcde"}}
+	state := &api.InstanceState{
+		Network: map[string]api.InstanceStateNetwork{
+			"lo":   {Type: "loopback", Addresses: []api.InstanceStateNetworkAddress{{Family: "inet", Address: "127.0.0.1", Netmask: "8", Scope: "local"}}},
+			"eth0": {HostName: "veth1234", Addresses: []api.InstanceStateNetworkAddress{{Family: "inet", Address: "10.0.0.10", Netmask: "24", Scope: "global"}}},
+		},
+	}
+
+	t.Run("Standalone", func(t *testing.T) {
+		g := NewWithT(t)
+
+		metadata := instanceMetadata(node, &api.InstanceFull{Instance: api.Instance{Name: "c1-md-0-abcde", Type: "container", Location: "none"}, State: state}, infrav1.ProviderIDFormatLegacy, "proj", "")
+		g.Expect(metadata).To(Equal(&cloudprovider.InstanceMetadata{
+			ProviderID:   "lxc:///c1-md-0-abcde",
+			InstanceType: "container",
+			NodeAddresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: corev1.NodeHostName, Address: "c1-md-0-abcde"},
+			},
+		}))
+	})
+
+	t.Run("Clustered", func(t *testing.T) {
+		g := NewWithT(t)
+
+		metadata := instanceMetadata(node, &api.InstanceFull{Instance: api.Instance{Name: "c1-md-0-abcde", Type: "virtual-machine", Location: "w02"}, State: state}, infrav1.ProviderIDFormatProjectMember, "proj", "lab")
+		g.Expect(metadata.ProviderID).To(Equal("lxc://proj/w02/c1-md-0-abcde"))
+		g.Expect(metadata.Zone).To(Equal("w02"))
+		g.Expect(metadata.Region).To(Equal("lab"))
+		g.Expect(metadata.InstanceType).To(Equal("virtual-machine"))
+	})
+
+	t.Run("ExistingProviderID", func(t *testing.T) {
+		g := NewWithT(t)
+
+		node := node.DeepCopy()
+		node.Spec.ProviderID = "lxc:///c1-md-0-abcde"
+		metadata := instanceMetadata(node, &api.InstanceFull{Instance: api.Instance{Name: "c1-md-0-abcde", Type: "container"}, State: state}, infrav1.ProviderIDFormatProject, "proj", "")
+		g.Expect(metadata.ProviderID).To(Equal("lxc:///c1-md-0-abcde"))
+	})
+}
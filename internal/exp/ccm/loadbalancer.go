@@ -0,0 +1,212 @@
+package ccm
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	cloudprovider "k8s.io/cloud-provider"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// serviceConfigKey is the config key of network forwards and network load balancers, with the Service they belong to.
+const serviceConfigKey = "user.ccm.service"
+
+// serviceTarget is a node that receives traffic for Services of type LoadBalancer.
+type serviceTarget struct {
+	name    string
+	address string
+}
+
+// serviceLoadBalancerBackend manages the Incus resources that implement Services of type LoadBalancer.
+type serviceLoadBalancerBackend interface {
+	// list returns the listen addresses that are in use on the network, mapped to the Service they belong to.
+	// Listen addresses not managed by the cloud-controller-manager are mapped to an empty string.
+	list(ctx context.Context) (map[string]string, error)
+	// ensure creates (if exists is false) or updates the load balancer with the specified listen address.
+	ensure(ctx context.Context, listenAddress string, key string, ports []corev1.ServicePort, targets []serviceTarget, exists bool) error
+	// delete deletes the load balancer with the specified listen address.
+	delete(ctx context.Context, listenAddress string) error
+}
+
+// loadBalancers implements cloudprovider.LoadBalancer.
+type loadBalancers struct {
+	lxcClient *lxc.Client
+	config    *LoadBalancerConfig
+
+	backend serviceLoadBalancerBackend
+}
+
+var _ cloudprovider.LoadBalancer = &loadBalancers{}
+
+func newLoadBalancers(lxcClient *lxc.Client, config *LoadBalancerConfig) *loadBalancers {
+	l := &loadBalancers{lxcClient: lxcClient, config: config}
+	switch config.Mode {
+	case LoadBalancerModeOVN:
+		l.backend = &ovnBackend{lxcClient: lxcClient, network: config.Network}
+	default:
+		l.backend = &networkForwardBackend{lxcClient: lxcClient, network: config.Network}
+	}
+	return l
+}
+
+// GetLoadBalancer implements cloudprovider.LoadBalancer.
+func (l *loadBalancers) GetLoadBalancer(ctx context.Context, clusterName string, service *corev1.Service) (*corev1.LoadBalancerStatus, bool, error) {
+	used, err := l.backend.list(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	address := findListenAddress(used, serviceKey(clusterName, service))
+	if address == "" {
+		return nil, false, nil
+	}
+	return loadBalancerStatus(address), true, nil
+}
+
+// GetLoadBalancerName implements cloudprovider.LoadBalancer.
+func (l *loadBalancers) GetLoadBalancerName(ctx context.Context, clusterName string, service *corev1.Service) string {
+	return cloudprovider.DefaultLoadBalancerName(service)
+}
+
+// EnsureLoadBalancer implements cloudprovider.LoadBalancer.
+func (l *loadBalancers) EnsureLoadBalancer(ctx context.Context, clusterName string, service *corev1.Service, nodes []*corev1.Node) (*corev1.LoadBalancerStatus, error) {
+	key := serviceKey(clusterName, service)
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("service", key, "network", l.config.Network, "mode", l.config.Mode))
+
+	if l.config.Mode == LoadBalancerModeOVN {
+		if err := l.lxcClient.SupportsNetworkLoadBalancers(); err != nil {
+			return nil, fmt.Errorf("server does not support network load balancers: %w", err)
+		}
+	}
+
+	for _, port := range service.Spec.Ports {
+		if port.Protocol != corev1.ProtocolTCP && port.Protocol != corev1.ProtocolUDP {
+			return nil, fmt.Errorf("port %q uses unsupported protocol %q", port.Name, port.Protocol)
+		}
+		if port.NodePort == 0 {
+			return nil, fmt.Errorf("port %q does not have a node port", port.Name)
+		}
+	}
+
+	used, err := l.backend.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	address := findListenAddress(used, key)
+	exists := address != ""
+
+	// Move the load balancer if the Service requests a different address.
+	if requested := service.Spec.LoadBalancerIP; exists && requested != "" && requested != address {
+		log.FromContext(ctx).Info("Service requests a different address, re-creating load balancer", "address", address, "requested", requested)
+		if err := l.backend.delete(ctx, address); err != nil {
+			return nil, err
+		}
+		delete(used, address)
+		exists = false
+	}
+	if !exists {
+		if address, err = l.allocateListenAddress(service, key, used); err != nil {
+			return nil, err
+		}
+	}
+
+	targets, err := serviceTargets(nodes, address)
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).V(2).Info("Ensuring load balancer", "address", address, "targets", targets)
+	if err := l.backend.ensure(ctx, address, key, service.Spec.Ports, targets, exists); err != nil {
+		return nil, err
+	}
+
+	return loadBalancerStatus(address), nil
+}
+
+// UpdateLoadBalancer implements cloudprovider.LoadBalancer.
+func (l *loadBalancers) UpdateLoadBalancer(ctx context.Context, clusterName string, service *corev1.Service, nodes []*corev1.Node) error {
+	_, err := l.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+	return err
+}
+
+// EnsureLoadBalancerDeleted implements cloudprovider.LoadBalancer.
+func (l *loadBalancers) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *corev1.Service) error {
+	used, err := l.backend.list(ctx)
+	if err != nil {
+		return err
+	}
+	if address := findListenAddress(used, serviceKey(clusterName, service)); address != "" {
+		return l.backend.delete(ctx, address)
+	}
+	return nil
+}
+
+// allocateListenAddress returns the listen address for a new load balancer. This is either the address requested
+// by the Service, or the first free address of the configured address range.
+func (l *loadBalancers) allocateListenAddress(service *corev1.Service, key string, used map[string]string) (string, error) {
+	if requested := service.Spec.LoadBalancerIP; requested != "" {
+		if _, err := netip.ParseAddr(requested); err != nil {
+			return "", fmt.Errorf("invalid .spec.loadBalancerIP %q: %w", requested, err)
+		}
+		if owner, ok := used[requested]; ok && owner != key {
+			return "", fmt.Errorf("address %s is already in use on network %s", requested, l.config.Network)
+		}
+		return requested, nil
+	}
+	if l.config.AddressRange == "" {
+		return "", fmt.Errorf("no address range is configured, Service must set .spec.loadBalancerIP")
+	}
+	return allocateAddress(l.config.AddressRange, used)
+}
+
+// serviceKey is the value of the serviceConfigKey config key for a Service.
+func serviceKey(clusterName string, service *corev1.Service) string {
+	return fmt.Sprintf("%s/%s/%s", clusterName, service.Namespace, service.Name)
+}
+
+// findListenAddress returns the listen address of the load balancer of a Service, or an empty string.
+func findListenAddress(used map[string]string, key string) string {
+	for address, owner := range used {
+		if owner == key {
+			return address
+		}
+	}
+	return ""
+}
+
+// serviceTargets returns the internal addresses of the nodes, in the same address family as the listen address.
+func serviceTargets(nodes []*corev1.Node, listenAddress string) ([]serviceTarget, error) {
+	listen, err := netip.ParseAddr(listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %w", listenAddress, err)
+	}
+
+	var targets []serviceTarget
+	for _, node := range nodes {
+		for _, address := range node.Status.Addresses {
+			if address.Type != corev1.NodeInternalIP {
+				continue
+			}
+			if addr, err := netip.ParseAddr(address.Address); err == nil && addr.Is4() == listen.Is4() {
+				targets = append(targets, serviceTarget{name: node.Name, address: address.Address})
+				break
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no nodes with an internal address in the same address family as %s", listenAddress)
+	}
+
+	slices.SortFunc(targets, func(a, b serviceTarget) int { return strings.Compare(a.name, b.name) })
+	return targets, nil
+}
+
+func loadBalancerStatus(address string) *corev1.LoadBalancerStatus {
+	return &corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: address}}}
+}
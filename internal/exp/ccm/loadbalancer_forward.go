@@ -0,0 +1,76 @@
+package ccm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// networkForwardBackend implements Services of type LoadBalancer with Incus network forwards.
+//
+// Network forwards can only forward each port to a single target address, so all traffic is sent to the first node.
+type networkForwardBackend struct {
+	lxcClient *lxc.Client
+
+	network string
+}
+
+func (b *networkForwardBackend) list(ctx context.Context) (map[string]string, error) {
+	forwards, err := b.lxcClient.GetNetworkForwards(b.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetNetworkForwards: %w", err)
+	}
+	used := make(map[string]string, len(forwards))
+	for _, forward := range forwards {
+		used[forward.ListenAddress] = forward.Config[serviceConfigKey]
+	}
+	return used, nil
+}
+
+func (b *networkForwardBackend) ensure(ctx context.Context, listenAddress string, key string, ports []corev1.ServicePort, targets []serviceTarget, exists bool) error {
+	put := networkForwardConfig(key, ports, targets)
+	if exists {
+		if err := b.lxcClient.UpdateNetworkForward(b.network, listenAddress, put, ""); err != nil {
+			return fmt.Errorf("failed to UpdateNetworkForward: %w", err)
+		}
+		return nil
+	}
+
+	if err := b.lxcClient.CreateNetworkForward(b.network, api.NetworkForwardsPost{ListenAddress: listenAddress, NetworkForwardPut: put}); err != nil {
+		return fmt.Errorf("failed to CreateNetworkForward: %w", err)
+	}
+	return nil
+}
+
+func (b *networkForwardBackend) delete(ctx context.Context, listenAddress string) error {
+	if err := b.lxcClient.DeleteNetworkForward(b.network, listenAddress); err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to DeleteNetworkForward: %w", err)
+	}
+	return nil
+}
+
+// networkForwardConfig returns the network forward configuration for a Service.
+func networkForwardConfig(key string, ports []corev1.ServicePort, targets []serviceTarget) api.NetworkForwardPut {
+	put := api.NetworkForwardPut{
+		Description: fmt.Sprintf("Service %s", key),
+		Config:      map[string]string{serviceConfigKey: key},
+		Ports:       make([]api.NetworkForwardPort, 0, len(ports)),
+	}
+	for _, port := range ports {
+		put.Ports = append(put.Ports, api.NetworkForwardPort{
+			Description:   port.Name,
+			Protocol:      strings.ToLower(string(port.Protocol)),
+			ListenPort:    strconv.Itoa(int(port.Port)),
+			TargetPort:    strconv.Itoa(int(port.NodePort)),
+			TargetAddress: targets[0].address,
+		})
+	}
+	return put
+}
@@ -0,0 +1,90 @@
+package ccm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// ovnBackend implements Services of type LoadBalancer with Incus network load balancers.
+// It requires an OVN network.
+type ovnBackend struct {
+	lxcClient *lxc.Client
+
+	network string
+}
+
+func (b *ovnBackend) list(ctx context.Context) (map[string]string, error) {
+	lbs, err := b.lxcClient.GetNetworkLoadBalancers(b.network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetNetworkLoadBalancers: %w", err)
+	}
+	used := make(map[string]string, len(lbs))
+	for _, lb := range lbs {
+		used[lb.ListenAddress] = lb.Config[serviceConfigKey]
+	}
+	return used, nil
+}
+
+func (b *ovnBackend) ensure(ctx context.Context, listenAddress string, key string, ports []corev1.ServicePort, targets []serviceTarget, exists bool) error {
+	put := ovnLoadBalancerConfig(key, ports, targets)
+	if exists {
+		if err := b.lxcClient.UpdateNetworkLoadBalancer(b.network, listenAddress, put, ""); err != nil {
+			return fmt.Errorf("failed to UpdateNetworkLoadBalancer: %w", err)
+		}
+		return nil
+	}
+
+	if err := b.lxcClient.CreateNetworkLoadBalancer(b.network, api.NetworkLoadBalancersPost{ListenAddress: listenAddress, NetworkLoadBalancerPut: put}); err != nil {
+		return fmt.Errorf("failed to CreateNetworkLoadBalancer: %w", err)
+	}
+	return nil
+}
+
+func (b *ovnBackend) delete(ctx context.Context, listenAddress string) error {
+	if err := b.lxcClient.DeleteNetworkLoadBalancer(b.network, listenAddress); err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to DeleteNetworkLoadBalancer: %w", err)
+	}
+	return nil
+}
+
+// ovnLoadBalancerConfig returns the network load balancer configuration for a Service. Each node is added as a
+// backend for each Service port, targeting the respective node port.
+func ovnLoadBalancerConfig(key string, ports []corev1.ServicePort, targets []serviceTarget) api.NetworkLoadBalancerPut {
+	put := api.NetworkLoadBalancerPut{
+		Description: fmt.Sprintf("Service %s", key),
+		Config:      map[string]string{serviceConfigKey: key},
+		Backends:    make([]api.NetworkLoadBalancerBackend, 0, len(ports)*len(targets)),
+		Ports:       make([]api.NetworkLoadBalancerPort, 0, len(ports)),
+	}
+	for _, port := range ports {
+		lbPort := api.NetworkLoadBalancerPort{
+			Description:   port.Name,
+			Protocol:      strings.ToLower(string(port.Protocol)),
+			ListenPort:    strconv.Itoa(int(port.Port)),
+			TargetBackend: make([]string, 0, len(targets)),
+		}
+		for _, target := range targets {
+			// TCP and UDP ports of a Service can share the same node port, and therefore the same backend.
+			name := fmt.Sprintf("%s-%d", target.name, port.NodePort)
+			if !slices.ContainsFunc(put.Backends, func(b api.NetworkLoadBalancerBackend) bool { return b.Name == name }) {
+				put.Backends = append(put.Backends, api.NetworkLoadBalancerBackend{
+					Name:          name,
+					TargetAddress: target.address,
+					TargetPort:    strconv.Itoa(int(port.NodePort)),
+				})
+			}
+			lbPort.TargetBackend = append(lbPort.TargetBackend, name)
+		}
+		put.Ports = append(put.Ports, lbPort)
+	}
+	return put
+}
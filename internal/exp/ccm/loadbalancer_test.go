@@ -0,0 +1,100 @@
+package ccm
+
+import (
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newNode(name string, addresses ...string) *corev1.Node {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	for _, address := range addresses {
+		node.Status.Addresses = append(node.Status.Addresses, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: address})
+	}
+	return node
+}
+
+func TestServiceTargets(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNode("w2", "10.0.0.12", "fd42::12"),
+		newNode("w1", "fd42::11", "10.0.0.11"),
+		newNode("w3"),
+	}
+
+	t.Run("IPv4", func(t *testing.T) {
+		g := NewWithT(t)
+
+		targets, err := serviceTargets(nodes, "10.100.0.1")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(targets).To(Equal([]serviceTarget{{name: "w1", address: "10.0.0.11"}, {name: "w2", address: "10.0.0.12"}}))
+	})
+
+	t.Run("IPv6", func(t *testing.T) {
+		g := NewWithT(t)
+
+		targets, err := serviceTargets(nodes, "fd42:100::1")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(targets).To(Equal([]serviceTarget{{name: "w1", address: "fd42::11"}, {name: "w2", address: "fd42::12"}}))
+	})
+
+	t.Run("NoNodes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := serviceTargets([]*corev1.Node{nodes[2]}, "10.100.0.1")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestFindListenAddress(t *testing.T) {
+	g := NewWithT(t)
+
+	used := map[string]string{"10.0.0.100": "", "10.0.0.101": "c1/default/svc1", "10.0.0.102": "c1/default/svc2"}
+	g.Expect(findListenAddress(used, "c1/default/svc2")).To(Equal("10.0.0.102"))
+	g.Expect(findListenAddress(used, "c1/default/svc3")).To(BeEmpty())
+}
+
+func TestLoadBalancerConfig(t *testing.T) {
+	ports := []corev1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, NodePort: 30080},
+		{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53, NodePort: 30053},
+		{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53, NodePort: 30053},
+	}
+	targets := []serviceTarget{{name: "w1", address: "10.0.0.11"}, {name: "w2", address: "10.0.0.12"}}
+
+	t.Run("NetworkForward", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(networkForwardConfig("c1/default/svc", ports, targets)).To(Equal(api.NetworkForwardPut{
+			Description: "Service c1/default/svc",
+			Config:      map[string]string{"user.ccm.service": "c1/default/svc"},
+			Ports: []api.NetworkForwardPort{
+				{Description: "http", Protocol: "tcp", ListenPort: "80", TargetPort: "30080", TargetAddress: "10.0.0.11"},
+				{Description: "dns", Protocol: "udp", ListenPort: "53", TargetPort: "30053", TargetAddress: "10.0.0.11"},
+				{Description: "dns-tcp", Protocol: "tcp", ListenPort: "53", TargetPort: "30053", TargetAddress: "10.0.0.11"},
+			},
+		}))
+	})
+
+	t.Run("OVN", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(ovnLoadBalancerConfig("c1/default/svc", ports, targets)).To(Equal(api.NetworkLoadBalancerPut{
+			Description: "Service c1/default/svc",
+			Config:      map[string]string{"user.ccm.service": "c1/default/svc"},
+			Backends: []api.NetworkLoadBalancerBackend{
+				{Name: "w1-30080", TargetAddress: "10.0.0.11", TargetPort: "30080"},
+				{Name: "w2-30080", TargetAddress: "10.0.0.12", TargetPort: "30080"},
+				{Name: "w1-30053", TargetAddress: "10.0.0.11", TargetPort: "30053"},
+				{Name: "w2-30053", TargetAddress: "10.0.0.12", TargetPort: "30053"},
+			},
+			Ports: []api.NetworkLoadBalancerPort{
+				{Description: "http", Protocol: "tcp", ListenPort: "80", TargetBackend: []string{"w1-30080", "w2-30080"}},
+				{Description: "dns", Protocol: "udp", ListenPort: "53", TargetBackend: []string{"w1-30053", "w2-30053"}},
+				{Description: "dns-tcp", Protocol: "tcp", ListenPort: "53", TargetBackend: []string{"w1-30053", "w2-30053"}},
+			},
+		}))
+	})
+}
@@ -0,0 +1,75 @@
+package extension
+
+import (
+	"context"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	runtimehooksv1 "sigs.k8s.io/cluster-api/exp/runtime/hooks/api/v1alpha1"
+	"sigs.k8s.io/cluster-api/exp/runtime/topologymutation"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+// Handlers implements the topology mutation hooks of the Runtime SDK for ClusterClasses that use
+// LXCClusterTemplate and LXCMachineTemplate objects.
+type Handlers struct {
+	decoder runtime.Decoder
+}
+
+// NewHandlers returns new topology mutation hook handlers.
+func NewHandlers() (*Handlers, error) {
+	scheme := runtime.NewScheme()
+	if err := infrav1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add infrastructure types to scheme: %w", err)
+	}
+	return &Handlers{
+		decoder: serializer.NewCodecFactory(scheme).UniversalDecoder(infrav1.GroupVersion),
+	}, nil
+}
+
+// GeneratePatches implements the GeneratePatches hook. It patches LXCClusterTemplate and LXCMachineTemplate objects
+// based on the cluster variables. Other templates are not changed.
+func (h *Handlers) GeneratePatches(ctx context.Context, req *runtimehooksv1.GeneratePatchesRequest, resp *runtimehooksv1.GeneratePatchesResponse) {
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("GeneratePatches is called")
+
+	topologymutation.WalkTemplates(ctx, h.decoder, req, resp, func(ctx context.Context, obj runtime.Object, variables map[string]apiextensionsv1.JSON, holderRef runtimehooksv1.HolderReference) error {
+		v, err := ParseVariables(variables)
+		if err != nil {
+			return err
+		}
+		if err := v.Validate(); err != nil {
+			return err
+		}
+
+		switch template := obj.(type) {
+		case *infrav1.LXCClusterTemplate:
+			patchLXCClusterTemplate(template, v)
+		case *infrav1.LXCMachineTemplate:
+			patchLXCMachineTemplate(template, v)
+		}
+		return nil
+	})
+}
+
+// ValidateTopology implements the ValidateTopology hook. It checks that the cluster variables are valid.
+func (h *Handlers) ValidateTopology(ctx context.Context, req *runtimehooksv1.ValidateTopologyRequest, resp *runtimehooksv1.ValidateTopologyResponse) {
+	log := ctrl.LoggerFrom(ctx)
+	log.Info("ValidateTopology is called")
+
+	v, err := ParseVariables(topologymutation.ToMap(req.Variables))
+	if err == nil {
+		err = v.Validate()
+	}
+	if err != nil {
+		resp.Status = runtimehooksv1.ResponseStatusFailure
+		resp.Message = err.Error()
+		return
+	}
+
+	resp.Status = runtimehooksv1.ResponseStatusSuccess
+}
@@ -0,0 +1,157 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	runtimehooksv1 "sigs.k8s.io/cluster-api/exp/runtime/hooks/api/v1alpha1"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func variable(name string, value string) runtimehooksv1.Variable {
+	return runtimehooksv1.Variable{Name: name, Value: apiextensionsv1.JSON{Raw: []byte(value)}}
+}
+
+func TestParseVariables(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		g := NewWithT(t)
+
+		v, err := ParseVariables(nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(v).To(Equal(Variables{LoadBalancerType: "lxc", InstanceType: "container", Privileged: true, ImageChannel: "stable"}))
+		g.Expect(v.Validate()).ToNot(Succeed())
+	})
+
+	for _, tc := range []struct {
+		name      string
+		variables []runtimehooksv1.Variable
+		expectErr bool
+	}{
+		{name: "Minimal", variables: []runtimehooksv1.Variable{variable("secretRef", `"lxc-secret"`)}},
+		{name: "KubeVIP", variables: []runtimehooksv1.Variable{variable("secretRef", `"s"`), variable("loadBalancerType", `"kube-vip"`), variable("loadBalancerAddress", `"10.0.0.10"`)}},
+		{name: "KubeVIPNoAddress", variables: []runtimehooksv1.Variable{variable("secretRef", `"s"`), variable("loadBalancerType", `"kube-vip"`)}, expectErr: true},
+		{name: "OVNNoNetwork", variables: []runtimehooksv1.Variable{variable("secretRef", `"s"`), variable("loadBalancerType", `"ovn"`), variable("loadBalancerAddress", `"10.0.0.10"`)}, expectErr: true},
+		{name: "UnknownLoadBalancer", variables: []runtimehooksv1.Variable{variable("secretRef", `"s"`), variable("loadBalancerType", `"haproxy"`)}, expectErr: true},
+		{name: "UnknownInstanceType", variables: []runtimehooksv1.Variable{variable("secretRef", `"s"`), variable("instanceType", `"vm"`)}, expectErr: true},
+		{name: "UnknownImageChannel", variables: []runtimehooksv1.Variable{variable("secretRef", `"s"`), variable("imageChannel", `"edge"`)}, expectErr: true},
+		{name: "KindStaging", variables: []runtimehooksv1.Variable{variable("secretRef", `"s"`), variable("instanceType", `"kind"`), variable("imageChannel", `"staging"`)}, expectErr: true},
+		{name: "InvalidPrivileged", variables: []runtimehooksv1.Variable{variable("secretRef", `"s"`), variable("privileged", `"yes"`)}, expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			h, err := NewHandlers()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			resp := &runtimehooksv1.ValidateTopologyResponse{}
+			h.ValidateTopology(context.Background(), &runtimehooksv1.ValidateTopologyRequest{Variables: tc.variables}, resp)
+			if tc.expectErr {
+				g.Expect(resp.Status).To(Equal(runtimehooksv1.ResponseStatusFailure))
+				g.Expect(resp.Message).ToNot(BeEmpty())
+			} else {
+				g.Expect(resp.Status).To(Equal(runtimehooksv1.ResponseStatusSuccess))
+			}
+		})
+	}
+}
+
+func TestGeneratePatches(t *testing.T) {
+	g := NewWithT(t)
+
+	h, err := NewHandlers()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	rawObject := func(obj runtime.Object) runtime.RawExtension {
+		b, err := json.Marshal(obj)
+		g.Expect(err).ToNot(HaveOccurred())
+		return runtime.RawExtension{Raw: b}
+	}
+
+	lxcClusterTemplate := &infrav1.LXCClusterTemplate{Spec: infrav1.LXCClusterTemplateSpec{Template: infrav1.LXCClusterTemplateResource{
+		Spec: infrav1.LXCClusterSpec{LoadBalancer: infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{}}},
+	}}}
+	lxcClusterTemplate.SetGroupVersionKind(infrav1.GroupVersion.WithKind("LXCClusterTemplate"))
+	lxcMachineTemplate := &infrav1.LXCMachineTemplate{Spec: infrav1.LXCMachineTemplateSpec{Template: infrav1.LXCMachineTemplateResource{
+		Spec: infrav1.LXCMachineSpec{InstanceType: "container", Profiles: []string{"default"}},
+	}}}
+	lxcMachineTemplate.SetGroupVersionKind(infrav1.GroupVersion.WithKind("LXCMachineTemplate"))
+
+	req := &runtimehooksv1.GeneratePatchesRequest{
+		Variables: []runtimehooksv1.Variable{
+			variable("secretRef", `"lxc-secret"`),
+			variable("loadBalancerType", `"kube-vip"`),
+			variable("loadBalancerAddress", `"10.0.0.10"`),
+			variable("instanceType", `"virtual-machine"`),
+			variable("privileged", `false`),
+			variable("imageChannel", `"staging"`),
+		},
+		Items: []runtimehooksv1.GeneratePatchesRequestItem{
+			{
+				UID:             "cluster",
+				HolderReference: runtimehooksv1.HolderReference{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster", Name: "c1"},
+				Object:          rawObject(lxcClusterTemplate),
+			},
+			{
+				UID:             "machine",
+				HolderReference: runtimehooksv1.HolderReference{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment", Name: "c1-md-0"},
+				Object:          rawObject(lxcMachineTemplate),
+			},
+		},
+	}
+	resp := &runtimehooksv1.GeneratePatchesResponse{}
+	h.GeneratePatches(context.Background(), req, resp)
+	g.Expect(resp.Status).To(Equal(runtimehooksv1.ResponseStatusSuccess), resp.Message)
+	g.Expect(resp.Items).To(HaveLen(2))
+
+	type operation struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	operations := func(item runtimehooksv1.GeneratePatchesResponseItem) map[string]string {
+		g.Expect(item.PatchType).To(Equal(runtimehooksv1.JSONPatchType))
+		var ops []operation
+		g.Expect(json.Unmarshal(item.Patch, &ops)).To(Succeed())
+		result := make(map[string]string, len(ops))
+		for _, op := range ops {
+			result[op.Path] = string(op.Value)
+		}
+		return result
+	}
+
+	g.Expect(resp.Items[0].UID).To(BeEquivalentTo("cluster"))
+	g.Expect(operations(resp.Items[0])).To(And(
+		HaveKeyWithValue("/spec/template/spec/secretRef/name", `"lxc-secret"`),
+		HaveKeyWithValue("/spec/template/spec/unprivileged", `true`),
+		HaveKeyWithValue("/spec/template/spec/loadBalancer/kubeVIP", `{}`),
+		HaveKey("/spec/template/spec/loadBalancer/lxc"),
+		HaveKeyWithValue("/spec/template/spec/controlPlaneEndpoint/host", `"10.0.0.10"`),
+		HaveKeyWithValue("/spec/template/spec/controlPlaneEndpoint/port", `6443`),
+	))
+
+	g.Expect(resp.Items[1].UID).To(BeEquivalentTo("machine"))
+	g.Expect(operations(resp.Items[1])).To(And(
+		HaveKeyWithValue("/spec/template/spec/instanceType", `"virtual-machine"`),
+		HaveKeyWithValue("/spec/template/spec/image/name", `"capi-stg:kubeadm/VERSION"`),
+		Not(HaveKey("/spec/template/spec/profiles")),
+	))
+}
+
+func TestPatchLXCClusterTemplateKeepsLoadBalancer(t *testing.T) {
+	g := NewWithT(t)
+
+	template := &infrav1.LXCClusterTemplate{}
+	template.Spec.Template.Spec.LoadBalancer.LXC = &infrav1.LXCLoadBalancerInstance{InstanceSpec: infrav1.LXCLoadBalancerMachineSpec{Flavor: "c1-m1"}}
+
+	patchLXCClusterTemplate(template, Variables{SecretRef: "s", LoadBalancerType: "lxc", Privileged: true})
+	g.Expect(template.Spec.Template.Spec.LoadBalancer.LXC.InstanceSpec.Flavor).To(Equal("c1-m1"))
+
+	patchLXCClusterTemplate(template, Variables{SecretRef: "s", LoadBalancerType: "ovn", OVNNetworkName: "ovn0", LoadBalancerAddress: "10.0.0.10", Privileged: true})
+	g.Expect(template.Spec.Template.Spec.LoadBalancer).To(Equal(infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0"}}))
+}
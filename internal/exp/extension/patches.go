@@ -0,0 +1,61 @@
+package extension
+
+import (
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+// patchLXCClusterTemplate sets the credentials, the load balancer and the privileged mode of a LXCClusterTemplate.
+// The existing load balancer configuration is kept if it already has the requested type.
+func patchLXCClusterTemplate(template *infrav1.LXCClusterTemplate, v Variables) {
+	spec := &template.Spec.Template.Spec
+
+	spec.SecretRef.Name = v.SecretRef
+	spec.Unprivileged = !v.Privileged
+
+	lb := spec.LoadBalancer
+	switch v.LoadBalancerType {
+	case "lxc":
+		if lb.LXC == nil {
+			lb = infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{}}
+		}
+	case "oci":
+		if lb.OCI == nil {
+			lb = infrav1.LXCClusterLoadBalancer{OCI: &infrav1.LXCLoadBalancerInstance{}}
+		}
+	case "kube-vip":
+		if lb.KubeVIP == nil {
+			lb = infrav1.LXCClusterLoadBalancer{KubeVIP: &infrav1.LXCLoadBalancerKubeVIP{}}
+		}
+	case "ovn":
+		if lb.OVN == nil {
+			lb = infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{}}
+		}
+		lb.OVN.NetworkName = v.OVNNetworkName
+	case "external":
+		if lb.External == nil {
+			lb = infrav1.LXCClusterLoadBalancer{External: &infrav1.LXCLoadBalancerExternal{}}
+		}
+	}
+	spec.LoadBalancer = lb
+
+	if v.LoadBalancerAddress != "" {
+		spec.ControlPlaneEndpoint.Host = v.LoadBalancerAddress
+		if spec.ControlPlaneEndpoint.Port == 0 {
+			spec.ControlPlaneEndpoint.Port = 6443
+		}
+	}
+}
+
+// patchLXCMachineTemplate sets the instance type and the image of a LXCMachineTemplate.
+func patchLXCMachineTemplate(template *infrav1.LXCMachineTemplate, v Variables) {
+	spec := &template.Spec.Template.Spec
+
+	spec.InstanceType = v.InstanceType
+
+	switch {
+	case v.Image != "":
+		spec.Image = infrav1.LXCMachineImageSource{Name: v.Image}
+	case v.ImageChannel == ImageChannelStaging:
+		spec.Image = infrav1.LXCMachineImageSource{Name: "capi-stg:kubeadm/VERSION"}
+	}
+}
@@ -0,0 +1,113 @@
+package extension
+
+import (
+	"fmt"
+	"slices"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/cluster-api/exp/runtime/topologymutation"
+)
+
+const (
+	// ImageChannelStable uses the default kubeadm images (or kindest/node images for kind instances).
+	ImageChannelStable = "stable"
+
+	// ImageChannelStaging uses kubeadm images from the staging simplestreams server.
+	ImageChannelStaging = "staging"
+)
+
+var (
+	loadBalancerTypes = []string{"lxc", "oci", "kube-vip", "ovn", "external"}
+	instanceTypes     = []string{"container", "virtual-machine", "kind"}
+	imageChannels     = []string{ImageChannelStable, ImageChannelStaging}
+)
+
+// Variables are the ClusterClass variables that are handled by the extension.
+type Variables struct {
+	// SecretRef is the name of the secret with the infrastructure credentials. Variable "secretRef".
+	SecretRef string
+
+	// LoadBalancerType is one of "lxc" (default), "oci", "kube-vip", "ovn" or "external". Variable "loadBalancerType".
+	LoadBalancerType string
+	// LoadBalancerAddress is the control plane endpoint address, required for the "kube-vip", "ovn" and "external"
+	// load balancer types. Variable "loadBalancerAddress".
+	LoadBalancerAddress string
+	// OVNNetworkName is the name of the OVN network, required for the "ovn" load balancer type.
+	// Variable "ovnNetworkName".
+	OVNNetworkName string
+
+	// InstanceType is one of "container" (default), "virtual-machine" or "kind". Variable "instanceType".
+	InstanceType string
+	// Privileged launches privileged containers (default true). Variable "privileged".
+	Privileged bool
+	// ImageChannel is one of "stable" (default) or "staging". Variable "imageChannel".
+	ImageChannel string
+	// Image overrides the image name of the cluster machines. Variable "image".
+	Image string
+}
+
+// ParseVariables parses the variables of a template. Variables that are not set use their default values.
+func ParseVariables(variables map[string]apiextensionsv1.JSON) (Variables, error) {
+	v := Variables{
+		LoadBalancerType: "lxc",
+		InstanceType:     "container",
+		Privileged:       true,
+		ImageChannel:     ImageChannelStable,
+	}
+
+	for name, into := range map[string]*string{
+		"secretRef":           &v.SecretRef,
+		"loadBalancerType":    &v.LoadBalancerType,
+		"loadBalancerAddress": &v.LoadBalancerAddress,
+		"ovnNetworkName":      &v.OVNNetworkName,
+		"instanceType":        &v.InstanceType,
+		"imageChannel":        &v.ImageChannel,
+		"image":               &v.Image,
+	} {
+		if value, err := topologymutation.GetStringVariable(variables, name); err == nil {
+			if value != "" {
+				*into = value
+			}
+		} else if !topologymutation.IsNotFoundError(err) {
+			return Variables{}, fmt.Errorf("invalid variable %q: %w", name, err)
+		}
+	}
+
+	if value, err := topologymutation.GetBoolVariable(variables, "privileged"); err == nil {
+		v.Privileged = value
+	} else if !topologymutation.IsNotFoundError(err) {
+		return Variables{}, fmt.Errorf("invalid variable %q: %w", "privileged", err)
+	}
+
+	return v, nil
+}
+
+// Validate checks that the combination of variables is valid.
+func (v Variables) Validate() error {
+	if v.SecretRef == "" {
+		return fmt.Errorf("variable %q is required", "secretRef")
+	}
+	if !slices.Contains(loadBalancerTypes, v.LoadBalancerType) {
+		return fmt.Errorf("variable %q must be one of %v, got %q", "loadBalancerType", loadBalancerTypes, v.LoadBalancerType)
+	}
+	if !slices.Contains(instanceTypes, v.InstanceType) {
+		return fmt.Errorf("variable %q must be one of %v, got %q", "instanceType", instanceTypes, v.InstanceType)
+	}
+	if !slices.Contains(imageChannels, v.ImageChannel) {
+		return fmt.Errorf("variable %q must be one of %v, got %q", "imageChannel", imageChannels, v.ImageChannel)
+	}
+
+	switch v.LoadBalancerType {
+	case "kube-vip", "ovn", "external":
+		if v.LoadBalancerAddress == "" {
+			return fmt.Errorf("variable %q is required for load balancer type %q", "loadBalancerAddress", v.LoadBalancerType)
+		}
+	}
+	if v.LoadBalancerType == "ovn" && v.OVNNetworkName == "" {
+		return fmt.Errorf("variable %q is required for load balancer type %q", "ovnNetworkName", v.LoadBalancerType)
+	}
+	if v.InstanceType == "kind" && v.ImageChannel == ImageChannelStaging && v.Image == "" {
+		return fmt.Errorf("image channel %q is not available for instance type %q", ImageChannelStaging, v.InstanceType)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package instances
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/cloudinit"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/static"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// BootstrapFormat is the format of bootstrap data, as set in the "format" key of the bootstrap data secret.
+type BootstrapFormat string
+
+const (
+	// BootstrapFormatCloudConfig is cloud-init cloud-config bootstrap data.
+	BootstrapFormatCloudConfig BootstrapFormat = "cloud-config"
+	// BootstrapFormatIgnition is Ignition bootstrap data.
+	BootstrapFormatIgnition BootstrapFormat = "ignition"
+)
+
+// IgnitionFirmwareConfigName is the QEMU firmware config (fw_cfg) entry that virtual machines read their Ignition config from.
+const IgnitionFirmwareConfigName = "opt/org.flatcar-linux/config"
+
+// IgnitionSeedFilePath is the path of the Ignition config seed file that is created on containers.
+const IgnitionSeedFilePath = "/usr/share/oem/config.ign"
+
+// IgnitionSeedConfigKey is the instance config key that enables Ignition bootstrap data for containers. Ignition does
+// not run in containers by default, so it must be set to "true" only for images that run Ignition with the seed file.
+const IgnitionSeedConfigKey = "user.capn.ignition-seed"
+
+// CloudInitSeedDirectory is the NoCloud seed directory that cloud-init user-data is created in for containers, if it
+// is too large to be passed through the "cloud-init.user-data" config key.
+const CloudInitSeedDirectory = "/var/lib/cloud/seed/nocloud-net"
+
+// CloudInitIncludeFilePath is the file that cloud-init user-data is created in for virtual machines, if it is too
+// large to be passed through the "cloud-init.user-data" config key.
+const CloudInitIncludeFilePath = "/var/lib/cloud/seed/capn/user-data"
+
+// MaxCloudInitConfigSize is the maximum size of cloud-init user-data that is passed through the "cloud-init.user-data"
+// config key. Larger user-data is created as a NoCloud seed file instead, as large config values are stored in the
+// Incus database and returned on every instance API request.
+const MaxCloudInitConfigSize = 64 * 1024
+
+// maxIgnitionFirmwareConfigSize is the maximum size of the QEMU arguments that pass the Ignition config of virtual
+// machines. It is limited by the maximum length of a single command line argument on Linux (MAX_ARG_STRLEN).
+const maxIgnitionFirmwareConfigSize = 128*1024 - 1
+
+// BootstrapData is the bootstrap data of a machine.
+type BootstrapData struct {
+	// Value is the bootstrap data.
+	Value string
+	// Format is the format of the bootstrap data.
+	Format BootstrapFormat
+}
+
+// ParseBootstrapFormat parses the format of bootstrap data. An empty format defaults to cloud-config.
+func ParseBootstrapFormat(format string) (BootstrapFormat, error) {
+	switch BootstrapFormat(format) {
+	case "", BootstrapFormatCloudConfig:
+		return BootstrapFormatCloudConfig, nil
+	case BootstrapFormatIgnition:
+		return BootstrapFormatIgnition, nil
+	default:
+		return "", utils.TerminalError(fmt.Errorf("unsupported bootstrap data format %q, must be one of [%s %s]", format, BootstrapFormatCloudConfig, BootstrapFormatIgnition))
+	}
+}
+
+// ValidateBootstrapData checks that bootstrap data is valid and can be delivered to instances of the specified type,
+// with the specified instance config. It returns a terminal error if the bootstrap data is invalid.
+func ValidateBootstrapData(data BootstrapData, instanceType api.InstanceType, instanceConfig map[string]string) error {
+	switch data.Format {
+	case BootstrapFormatIgnition:
+		var config struct {
+			Ignition *struct {
+				Version string `json:"version"`
+			} `json:"ignition"`
+		}
+		if err := json.Unmarshal([]byte(data.Value), &config); err != nil {
+			return utils.TerminalError(fmt.Errorf("failed to parse Ignition config: %w", err))
+		}
+		if config.Ignition == nil || config.Ignition.Version == "" {
+			return utils.TerminalError(fmt.Errorf("invalid Ignition config: missing ignition.version"))
+		}
+		if size := len(ignitionFirmwareConfigArgs(data.Value)); instanceType == api.InstanceTypeVM && size > maxIgnitionFirmwareConfigSize {
+			return utils.TerminalError(fmt.Errorf("ignition config is too large for virtual-machine instances (%d bytes after escaping, maximum is %d bytes)", size, maxIgnitionFirmwareConfigSize))
+		}
+		if instanceType != api.InstanceTypeVM {
+			v, ok := instanceConfig[IgnitionSeedConfigKey]
+			if !ok {
+				return utils.TerminalError(fmt.Errorf("ignition bootstrap data is not supported for container instances, unless the image runs Ignition with %s and %s is set to \"true\"", IgnitionSeedFilePath, IgnitionSeedConfigKey))
+			}
+			if enabled, err := strconv.ParseBool(v); err != nil {
+				return utils.TerminalError(fmt.Errorf("failed to parse %s=%q as boolean: %w", IgnitionSeedConfigKey, v, err))
+			} else if !enabled {
+				return utils.TerminalError(fmt.Errorf("ignition bootstrap data is not supported for container instances, as %s is %q", IgnitionSeedConfigKey, v))
+			}
+		}
+	default:
+		if err := cloudinit.Validate(data.Value); err != nil {
+			return utils.TerminalError(fmt.Errorf("invalid cloud-init user-data: %w", err))
+		}
+	}
+
+	return nil
+}
+
+// withCloudInit delivers cloud-init user-data to instances. User-data is passed through the "cloud-init.user-data"
+// config key, unless it is larger than MaxCloudInitConfigSize.
+//
+// Larger user-data is created as a seed file using instance templates. For containers, the NoCloud seed directory is
+// used. Virtual machines also read user-data from the Incus config drive, which takes precedence over the seed
+// directory, so the "cloud-init.user-data" config key includes the seed file instead.
+func withCloudInit(opts *lxc.LaunchOptions, instanceType api.InstanceType, cloudInit string) *lxc.LaunchOptions {
+	switch {
+	case len(cloudInit) <= MaxCloudInitConfigSize:
+		return opts.
+			WithConfig(map[string]string{
+				"cloud-init.user-data": cloudInit,
+			}).
+			WithBootstrapConfigKeys("cloud-init.user-data")
+	case instanceType == api.InstanceTypeVM:
+		return opts.
+			WithConfig(map[string]string{
+				"cloud-init.user-data": fmt.Sprintf("#include\nfile://%s\n", CloudInitIncludeFilePath),
+			}).
+			WithInstanceTemplates(map[string]string{
+				CloudInitIncludeFilePath: escapeInstanceTemplate(cloudInit),
+			}).
+			WithBootstrapPaths(CloudInitIncludeFilePath)
+	default:
+		return opts.
+			WithInstanceTemplates(map[string]string{
+				CloudInitSeedDirectory + "/meta-data": static.CloudInitMetaDataTemplate(),
+				CloudInitSeedDirectory + "/user-data": escapeInstanceTemplate(cloudInit),
+			}).
+			WithBootstrapPaths(CloudInitSeedDirectory + "/user-data")
+	}
+}
+
+// escapeInstanceTemplate escapes text so that it is rendered unchanged as an instance template. Instance templates
+// are pongo2 templates, so tag delimiters (e.g. from jinja cloud-config) are replaced with string literals.
+func escapeInstanceTemplate(text string) string {
+	return strings.NewReplacer(
+		"{{", `{{ "{{" }}`,
+		"{%", `{{ "{%" }}`,
+		"{#", `{{ "{#" }}`,
+	).Replace(text)
+}
+
+// withIgnition delivers an Ignition config to instances.
+//
+// For virtual machines, the config is passed through a QEMU firmware config (fw_cfg) entry, which is appended to the
+// "raw.qemu" config key of the instance. For containers, the config is created as a seed file, and the image must run
+// Ignition with that file on first boot (see IgnitionSeedConfigKey).
+func withIgnition(opts *lxc.LaunchOptions, instanceType api.InstanceType, config string) *lxc.LaunchOptions {
+	if instanceType == api.InstanceTypeVM {
+		return opts.
+			WithAppendConfig(map[string]string{
+				"raw.qemu": ignitionFirmwareConfigArgs(config),
+			}).
+			WithBootstrapConfigKeys("raw.qemu")
+	}
+
+	return opts.
+		WithDirectories("/usr/share/oem").
+		WithCreateFiles(map[string]string{
+			IgnitionSeedFilePath: config,
+		}).
+		WithBootstrapPaths(IgnitionSeedFilePath)
+}
+
+// ignitionFirmwareConfigArgs returns the QEMU arguments to pass an Ignition config through fw_cfg.
+//
+// Commas in QEMU option values are escaped by doubling them, and the option is single-quoted, as Incus splits the
+// "raw.qemu" config key with shell quoting rules.
+func ignitionFirmwareConfigArgs(config string) string {
+	value := fmt.Sprintf("name=%s,string=%s", IgnitionFirmwareConfigName, strings.ReplaceAll(config, ",", ",,"))
+	return fmt.Sprintf("-fw_cfg '%s'", strings.ReplaceAll(value, "'", `'\''`))
+}
@@ -0,0 +1,92 @@
+package instances
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestParseBootstrapFormat(t *testing.T) {
+	for _, tc := range []struct {
+		format      string
+		expect      BootstrapFormat
+		expectError bool
+	}{
+		{format: "", expect: BootstrapFormatCloudConfig},
+		{format: "cloud-config", expect: BootstrapFormatCloudConfig},
+		{format: "ignition", expect: BootstrapFormatIgnition},
+		{format: "Ignition", expectError: true},
+		{format: "butane", expectError: true},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			g := NewWithT(t)
+
+			format, err := ParseBootstrapFormat(tc.format)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(format).To(Equal(tc.expect))
+		})
+	}
+}
+
+func TestIgnitionFirmwareConfigArgs(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(ignitionFirmwareConfigArgs(`{"ignition":{"version":"3.4.0"},"storage":{"files":[{"path":"/etc/motd","contents":{"source":"data:,it's%20me"}}]}}`)).To(Equal(
+		`-fw_cfg 'name=opt/org.flatcar-linux/config,string={"ignition":{"version":"3.4.0"},,"storage":{"files":[{"path":"/etc/motd",,"contents":{"source":"data:,,it'\''s%20me"}}]}}'`,
+	))
+}
+
+func TestValidateBootstrapData(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		data         BootstrapData
+		instanceType api.InstanceType
+		config       map[string]string
+		expectError  bool
+	}{
+		{name: "CloudConfig", data: BootstrapData{Value: "#cloud-config\nruncmd:\n- echo hi\n"}},
+		{name: "CloudConfigEmpty", data: BootstrapData{Value: "#cloud-config\n"}},
+		{name: "CloudConfigJinja", data: BootstrapData{Value: "## template: jinja\n#cloud-config\nhostname: {{ ds.meta_data.local_hostname }}\n{% if true %}\nruncmd: [\"echo {{ v1.instance_id }}\"]\n{% endif %}\n"}},
+		{name: "Script", data: BootstrapData{Value: "#!/bin/sh\necho hi\n"}},
+		{name: "MissingHeader", data: BootstrapData{Value: "runcmd:\n- echo hi\n"}, expectError: true},
+		{name: "InvalidYAML", data: BootstrapData{Value: "#cloud-config\nruncmd: [\n"}, expectError: true},
+		{name: "NotAnObject", data: BootstrapData{Value: "#cloud-config\n- echo hi\n"}, expectError: true},
+		{name: "Ignition", data: BootstrapData{Format: BootstrapFormatIgnition, Value: `{"ignition":{"version":"3.4.0"}}`}, instanceType: api.InstanceTypeVM},
+		{name: "IgnitionInvalidJSON", data: BootstrapData{Format: BootstrapFormatIgnition, Value: `{"ignition":`}, expectError: true},
+		{name: "IgnitionMissingVersion", data: BootstrapData{Format: BootstrapFormatIgnition, Value: `{"storage":{}}`}, expectError: true},
+		{name: "IgnitionTooLargeForVM", data: BootstrapData{Format: BootstrapFormatIgnition, Value: `{"ignition":{"version":"3.4.0"},"x":"` + strings.Repeat("a", 128*1024) + `"}`}, instanceType: api.InstanceTypeVM, expectError: true},
+		{name: "IgnitionLargeContainer", data: BootstrapData{Format: BootstrapFormatIgnition, Value: `{"ignition":{"version":"3.4.0"},"x":"` + strings.Repeat("a", 128*1024) + `"}`}, instanceType: api.InstanceTypeContainer, config: map[string]string{IgnitionSeedConfigKey: "true"}},
+		{name: "IgnitionContainerNotEnabled", data: BootstrapData{Format: BootstrapFormatIgnition, Value: `{"ignition":{"version":"3.4.0"}}`}, instanceType: api.InstanceTypeContainer, expectError: true},
+		{name: "IgnitionContainerDisabled", data: BootstrapData{Format: BootstrapFormatIgnition, Value: `{"ignition":{"version":"3.4.0"}}`}, instanceType: api.InstanceTypeContainer, config: map[string]string{IgnitionSeedConfigKey: "false"}, expectError: true},
+		{name: "IgnitionContainerInvalidConfig", data: BootstrapData{Format: BootstrapFormatIgnition, Value: `{"ignition":{"version":"3.4.0"}}`}, instanceType: api.InstanceTypeContainer, config: map[string]string{IgnitionSeedConfigKey: "yes please"}, expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := ValidateBootstrapData(tc.data, tc.instanceType, tc.config)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestEscapeInstanceTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(escapeInstanceTemplate("a: '{{ ds.meta_data.local_hostname }}'\n{% if x %}{# y #}{% endif %}\n")).To(Equal(
+		`a: '{{ "{{" }} ds.meta_data.local_hostname }}'` + "\n" + `{{ "{%" }} if x %}{{ "{#" }} y #}{{ "{%" }} endif %}` + "\n",
+	))
+}
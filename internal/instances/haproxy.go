@@ -10,11 +10,7 @@ import (
 func HaproxyLXCLaunchOptions() *lxc.LaunchOptions {
 	return (&lxc.LaunchOptions{}).
 		WithInstanceType(api.InstanceTypeContainer).
-		WithImage(lxc.Image{
-			Protocol: lxc.Simplestreams,
-			Server:   lxc.DefaultSimplestreamsServer,
-			Alias:    "haproxy",
-		})
+		WithImage(lxc.CapnImage("haproxy"))
 }
 
 // HaproxyOCILaunchOptions launches OCI haproxy load balancer containers.
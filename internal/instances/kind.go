@@ -23,10 +23,13 @@ type KindLaunchOptionsInput struct {
 
 	CloudInit           string
 	CloudInitAptInstall bool
+
+	RegistryMirrors []RegistryMirror
 }
 
-// KindLaunchOptions launches kindest/node nodes.
-func KindLaunchOptions(in KindLaunchOptionsInput) (*lxc.LaunchOptions, error) {
+// KindLaunchOptions launches kindest/node nodes. It returns a list of warnings for cloud-config that is not supported,
+// and will be ignored.
+func KindLaunchOptions(in KindLaunchOptionsInput) (*lxc.LaunchOptions, []string, error) {
 	opts := (&lxc.LaunchOptions{}).
 		WithInstanceType(api.InstanceTypeContainer).
 		WithImage(lxc.KindestNodeImage(in.KubernetesVersion)).
@@ -43,12 +46,15 @@ func KindLaunchOptions(in KindLaunchOptionsInput) (*lxc.LaunchOptions, error) {
 			"/init": "/usr/local/bin/entrypoint",
 		})
 
+	var warnings []string
+
 	// add cloud-init configuration as nocloud-net datasource in the instance
 	if len(in.CloudInit) > 0 {
 		opts = opts.
 			WithConfig(map[string]string{
 				"cloud-init.user-data": in.CloudInit,
 			}).
+			WithBootstrapConfigKeys("cloud-init.user-data").
 			WithInstanceTemplates(map[string]string{
 				// inject cloud-init into instance.
 				"/var/lib/cloud/seed/nocloud-net/meta-data": static.CloudInitMetaDataTemplate(),
@@ -68,21 +74,24 @@ func KindLaunchOptions(in KindLaunchOptionsInput) (*lxc.LaunchOptions, error) {
 			// - marshal to JSON
 			// - embed to instance at /hack/cloud-init.json
 			// - instance will run using the kind-cloud-init.py script (see internal/embed/kind-cloud-init.py)
-			cloudConfig, err := cloudinit.Parse(in.CloudInit, strings.NewReplacer(
+			cloudConfig, cloudConfigWarnings, err := cloudinit.Parse(in.CloudInit, strings.NewReplacer(
 				"{{ v1.local_hostname }}", "{{ container.name }}",
 			))
 			if err != nil {
-				return nil, utils.TerminalError(fmt.Errorf("failed to parse instance cloud-config, please report this bug to https://github.com/lxc/cluster-api-provider-incus/issues: %w", err))
+				return nil, nil, utils.TerminalError(fmt.Errorf("failed to parse instance cloud-config, please report this bug to https://github.com/lxc/cluster-api-provider-incus/issues: %w", err))
 			}
 
 			b, err := json.Marshal(cloudConfig)
 			if err != nil {
-				return nil, utils.TerminalError(fmt.Errorf("failed to generate JSON cloud-config for instance, please report this bug to github.com/lxc/cluster-api-provider-incus/issues: %w", err))
+				return nil, nil, utils.TerminalError(fmt.Errorf("failed to generate JSON cloud-config for instance, please report this bug to github.com/lxc/cluster-api-provider-incus/issues: %w", err))
 			}
 
-			opts = opts.WithInstanceTemplates(map[string]string{
-				"/hack/cloud-init.json": string(b),
-			})
+			opts = opts.
+				WithInstanceTemplates(map[string]string{
+					"/hack/cloud-init.json": string(b),
+				}).
+				WithBootstrapPaths("/hack/cloud-init.json")
+			warnings = append(warnings, cloudConfigWarnings...)
 		}
 	}
 
@@ -95,6 +104,9 @@ func KindLaunchOptions(in KindLaunchOptionsInput) (*lxc.LaunchOptions, error) {
 		})
 	}
 
+	// add containerd registry mirrors
+	opts = withRegistryMirrors(opts, api.InstanceTypeContainer, in.RegistryMirrors)
+
 	// apply profile for Kubernetes to run in LXC containers
 	if !in.SkipProfile {
 		profile := static.DefaultKindProfile(in.Privileged)
@@ -103,5 +115,5 @@ func KindLaunchOptions(in KindLaunchOptionsInput) (*lxc.LaunchOptions, error) {
 			WithDevices(profile.Devices)
 	}
 
-	return opts, nil
+	return opts, warnings, nil
 }
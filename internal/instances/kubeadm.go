@@ -20,6 +20,9 @@ type KubeadmLaunchOptionsInput struct {
 	ServerName string
 
 	CloudInit string
+	Ignition  string
+
+	RegistryMirrors []RegistryMirror
 }
 
 // KubeadmLaunchOptions launches kubeadm nodes.
@@ -36,11 +39,17 @@ func KubeadmLaunchOptions(in KubeadmLaunchOptionsInput) *lxc.LaunchOptions {
 
 	// add cloud-init
 	if len(in.CloudInit) > 0 {
-		opts = opts.WithConfig(map[string]string{
-			"cloud-init.user-data": in.CloudInit,
-		})
+		opts = withCloudInit(opts, in.InstanceType, in.CloudInit)
 	}
 
+	// add ignition
+	if len(in.Ignition) > 0 {
+		opts = withIgnition(opts, in.InstanceType, in.Ignition)
+	}
+
+	// add containerd registry mirrors
+	opts = withRegistryMirrors(opts, in.InstanceType, in.RegistryMirrors)
+
 	// apply profile for Kubernetes to run in LXC containers
 	if in.InstanceType == api.InstanceTypeContainer && !in.SkipProfile {
 		profile := static.DefaultKubeadmProfile(in.Privileged, in.ServerName)
@@ -0,0 +1,112 @@
+package instances
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/version"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// MachineTemplateData is the data available to Go templates in the image name, config values and profiles of a
+// LXCMachine.
+type MachineTemplateData struct {
+	// Version is the Kubernetes version of the machine, e.g. "v1.33.0".
+	Version string
+	// Major, Minor and Patch are the components of the Kubernetes version, e.g. "1", "33" and "0".
+	Major string
+	Minor string
+	Patch string
+
+	// Architecture is the architecture of the machine, in Kubernetes format (e.g. "amd64"). If the LXCMachine does
+	// not specify an architecture, this is the primary architecture of the server.
+	Architecture string
+	// InstanceType is the instance type of the machine, one of "container", "virtual-machine" or "kind".
+	InstanceType string
+
+	// ClusterName and ClusterNamespace are the name and namespace of the cluster.
+	ClusterName      string
+	ClusterNamespace string
+	// MachineName is the name of the Machine.
+	MachineName string
+	// Role is the role of the machine, one of "control-plane" or "worker".
+	Role string
+}
+
+// WithVersion returns a copy of the template data with the Kubernetes version (e.g. "v1.33.0") and its components.
+// The components are empty if the version cannot be parsed.
+func (d MachineTemplateData) WithVersion(v string) MachineTemplateData {
+	d.Version, d.Major, d.Minor, d.Patch = v, "", "", ""
+	if parsed, err := version.ParseGeneric(v); err == nil {
+		d.Major = strconv.FormatUint(uint64(parsed.Major()), 10)
+		d.Minor = strconv.FormatUint(uint64(parsed.Minor()), 10)
+		d.Patch = strconv.FormatUint(uint64(parsed.Patch()), 10)
+	}
+	return d
+}
+
+// renderMachineTemplate renders a Go template (e.g. "kubeadm/v{{ .Major }}.{{ .Minor }}") with the machine template
+// data. Values that do not contain "{{" are returned unchanged.
+func renderMachineTemplate(field string, value string, data MachineTemplateData) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	tmpl, err := template.New(field).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", utils.TerminalError(fmt.Errorf("invalid template in %s %q: %w", field, value, err))
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", utils.TerminalError(fmt.Errorf("failed to render template in %s %q: %w", field, value, err))
+	}
+	return b.String(), nil
+}
+
+// untemplatedConfigKeyPrefixes are prefixes of config keys with values that are passed to the server as-is. Their
+// values commonly contain cloud-init jinja (e.g. "{{ v1.local_hostname }}") or raw configuration that uses the
+// same delimiters as Go templates.
+var untemplatedConfigKeyPrefixes = []string{"cloud-init.", "raw.", "user.user-data", "user.vendor-data", "user.meta-data", "user.network-config"}
+
+// isTemplatedConfigKey returns true if Go templates in the value of a config key are rendered.
+func isTemplatedConfigKey(key string) bool {
+	for _, prefix := range untemplatedConfigKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// RenderMachineSpec returns a copy of the LXCMachine spec, with templates in the image name rendered with the machine
+// template data. Templates in config values and profiles are only rendered if the spec opts in with RenderTemplates,
+// and values of cloud-init and raw config keys are never rendered.
+func RenderMachineSpec(spec infrav1.LXCMachineSpec, data MachineTemplateData) (*infrav1.LXCMachineSpec, error) {
+	rendered := spec.DeepCopy()
+
+	var err error
+	if rendered.Image.Name, err = renderMachineTemplate(".spec.image.name", rendered.Image.Name, data); err != nil {
+		return nil, err
+	}
+	if !rendered.RenderTemplates {
+		return rendered, nil
+	}
+	for key, value := range rendered.Config {
+		if !isTemplatedConfigKey(key) {
+			continue
+		}
+		if rendered.Config[key], err = renderMachineTemplate(fmt.Sprintf(".spec.config[%q]", key), value, data); err != nil {
+			return nil, err
+		}
+	}
+	for idx, profile := range rendered.Profiles {
+		if rendered.Profiles[idx], err = renderMachineTemplate(fmt.Sprintf(".spec.profiles[%d]", idx), profile, data); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
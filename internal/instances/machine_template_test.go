@@ -0,0 +1,120 @@
+package instances
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestMachineTemplateDataWithVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	data := MachineTemplateData{ClusterName: "c1"}
+	g.Expect(data.WithVersion("v1.33.2")).To(Equal(MachineTemplateData{ClusterName: "c1", Version: "v1.33.2", Major: "1", Minor: "33", Patch: "2"}))
+	g.Expect(data.WithVersion("invalid")).To(Equal(MachineTemplateData{ClusterName: "c1", Version: "invalid"}))
+}
+
+func TestRenderMachineSpec(t *testing.T) {
+	data := MachineTemplateData{
+		Version:      "v1.33.2",
+		Major:        "1",
+		Minor:        "33",
+		Patch:        "2",
+		Architecture: "amd64",
+		InstanceType: "container",
+		ClusterName:  "c1",
+		MachineName:  "m1",
+		Role:         "worker",
+	}
+
+	for _, tc := range []struct {
+		name              string
+		spec              infrav1.LXCMachineSpec
+		expectSpec        infrav1.LXCMachineSpec
+		expectTerminalErr bool
+	}{
+		{
+			name:       "NoTemplates",
+			spec:       infrav1.LXCMachineSpec{Image: infrav1.LXCMachineImageSource{Name: "capi:kubeadm/VERSION"}, Profiles: []string{"default"}, Config: map[string]string{"user.a": "b"}},
+			expectSpec: infrav1.LXCMachineSpec{Image: infrav1.LXCMachineImageSource{Name: "capi:kubeadm/VERSION"}, Profiles: []string{"default"}, Config: map[string]string{"user.a": "b"}},
+		},
+		{
+			name: "Templates",
+			spec: infrav1.LXCMachineSpec{
+				Image:           infrav1.LXCMachineImageSource{Name: "corp:kubeadm/v{{ .Major }}.{{ .Minor }}/{{ .Architecture }}"},
+				Profiles:        []string{"default", "k8s-{{ .Role }}"},
+				Config:          map[string]string{"user.owner": "{{ .ClusterName }}/{{ .MachineName }}", "user.a": "b"},
+				RenderTemplates: true,
+			},
+			expectSpec: infrav1.LXCMachineSpec{
+				Image:           infrav1.LXCMachineImageSource{Name: "corp:kubeadm/v1.33/amd64"},
+				Profiles:        []string{"default", "k8s-worker"},
+				Config:          map[string]string{"user.owner": "c1/m1", "user.a": "b"},
+				RenderTemplates: true,
+			},
+		},
+		{
+			// Existing config values and profiles with a literal "{{" are not rendered, unless the spec opts in.
+			name: "TemplatesDisabled",
+			spec: infrav1.LXCMachineSpec{
+				Image:    infrav1.LXCMachineImageSource{Name: "corp:kubeadm/v{{ .Major }}.{{ .Minor }}"},
+				Profiles: []string{"k8s-{{ .Role }}"},
+				Config:   map[string]string{"user.motd": "{{ not a template", "environment.GREETING": "{{ .Unknown }}"},
+			},
+			expectSpec: infrav1.LXCMachineSpec{
+				Image:    infrav1.LXCMachineImageSource{Name: "corp:kubeadm/v1.33"},
+				Profiles: []string{"k8s-{{ .Role }}"},
+				Config:   map[string]string{"user.motd": "{{ not a template", "environment.GREETING": "{{ .Unknown }}"},
+			},
+		},
+		{
+			name: "CloudInitJinja",
+			spec: infrav1.LXCMachineSpec{
+				Config: map[string]string{
+					"cloud-init.user-data":   "## template: jinja\n#cloud-config\nhostname: {{ v1.local_hostname }}\n",
+					"cloud-init.vendor-data": "#cloud-config\nfqdn: {{ ds.meta_data.hostname }}\n",
+					"user.user-data":         "#cloud-config\nhostname: {{ v1.local_hostname }}\n",
+					"raw.lxc":                "lxc.apparmor.profile={{ unconfined }}",
+					"user.owner":             "{{ .ClusterName }}",
+				},
+				RenderTemplates: true,
+			},
+			expectSpec: infrav1.LXCMachineSpec{
+				Config: map[string]string{
+					"cloud-init.user-data":   "## template: jinja\n#cloud-config\nhostname: {{ v1.local_hostname }}\n",
+					"cloud-init.vendor-data": "#cloud-config\nfqdn: {{ ds.meta_data.hostname }}\n",
+					"user.user-data":         "#cloud-config\nhostname: {{ v1.local_hostname }}\n",
+					"raw.lxc":                "lxc.apparmor.profile={{ unconfined }}",
+					"user.owner":             "c1",
+				},
+				RenderTemplates: true,
+			},
+		},
+		{
+			name:              "InvalidTemplate",
+			spec:              infrav1.LXCMachineSpec{Profiles: []string{"k8s-{{ .Role "}, RenderTemplates: true},
+			expectTerminalErr: true,
+		},
+		{
+			name:              "UnknownVariable",
+			spec:              infrav1.LXCMachineSpec{Config: map[string]string{"user.a": "{{ .Unknown }}"}, RenderTemplates: true},
+			expectTerminalErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			spec, err := RenderMachineSpec(tc.spec, data)
+			if tc.expectTerminalErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(*spec).To(Equal(tc.expectSpec))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package instances
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+// maxInstanceNameLength is the maximum length of instance names.
+const maxInstanceNameLength = 63
+
+// instanceNameRegexp matches valid instance names, which must also be valid hostnames.
+var instanceNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// MachineNameData is the data that is available to machine naming templates.
+type MachineNameData struct {
+	// MachineName is the name of the LXCMachine.
+	MachineName string
+	// ClusterName is the name of the Cluster.
+	ClusterName string
+	// Namespace is the namespace of the Cluster.
+	Namespace string
+	// NamespaceHash is the first 5 characters of the hex-encoded sha256 sum of the namespace.
+	NamespaceHash string
+}
+
+// MachineInstanceName returns the instance name for a machine, using the naming configuration of the cluster.
+// An error is returned if the naming template is invalid, or does not produce a valid instance name.
+func MachineInstanceName(naming *infrav1.LXCClusterMachineNaming, clusterName string, lxcMachine *infrav1.LXCMachine) (string, error) {
+	if naming == nil {
+		return lxcMachine.Name, nil
+	}
+
+	data := MachineNameData{
+		MachineName:   lxcMachine.Name,
+		ClusterName:   clusterName,
+		Namespace:     lxcMachine.Namespace,
+		NamespaceHash: shortHash(lxcMachine.Namespace),
+	}
+
+	name := data.MachineName
+	if naming.Template != "" {
+		var err error
+		if name, err = renderMachineNameTemplate(naming.Template, data); err != nil {
+			return "", err
+		}
+	}
+
+	if naming.NamespaceHashPrefix {
+		name = fmt.Sprintf("%s-%s", data.NamespaceHash, name)
+	}
+
+	// Truncate long names, and append a hash of the full name to keep them unique.
+	if len(name) > maxInstanceNameLength {
+		name = fmt.Sprintf("%s-%s", strings.TrimRight(name[:maxInstanceNameLength-6], "-"), shortHash(name))
+	}
+
+	if !instanceNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid instance name %q, must consist of lowercase alphanumeric characters or '-', and must start and end with an alphanumeric character", name)
+	}
+
+	return name, nil
+}
+
+// renderMachineNameTemplate renders a machine naming template.
+func renderMachineNameTemplate(text string, data MachineNameData) (string, error) {
+	t, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse naming template: %w", err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render naming template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// ValidateMachineNamingTemplate checks that the template of a machine naming configuration can be rendered. It does
+// not check the rendered instance names, as they depend on the cluster (see ValidateMachineNaming).
+func ValidateMachineNamingTemplate(naming *infrav1.LXCClusterMachineNaming) error {
+	if naming == nil || naming.Template == "" {
+		return nil
+	}
+	_, err := renderMachineNameTemplate(naming.Template, MachineNameData{})
+	return err
+}
+
+// ValidateMachineNaming validates a machine naming configuration for a cluster, by rendering the instance name of
+// a sample machine of the cluster.
+func ValidateMachineNaming(naming *infrav1.LXCClusterMachineNaming, clusterName string, namespace string) error {
+	lxcMachine := &infrav1.LXCMachine{}
+	lxcMachine.Name = fmt.Sprintf("%s-md-0-abcde-fghij", clusterName)
+	lxcMachine.Namespace = namespace
+
+	_, err := MachineInstanceName(naming, clusterName, lxcMachine)
+	return err
+}
+
+// shortHash returns the first 5 characters of the hex-encoded sha256 sum of value.
+func shortHash(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:3])[:5]
+}
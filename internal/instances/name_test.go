@@ -0,0 +1,82 @@
+package instances
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func TestMachineInstanceName(t *testing.T) {
+	lxcMachine := &infrav1.LXCMachine{ObjectMeta: metav1.ObjectMeta{Name: "c1-control-plane-abcde", Namespace: "team-a"}}
+	namespaceHash := shortHash("team-a")
+
+	for _, tc := range []struct {
+		name        string
+		naming      *infrav1.LXCClusterMachineNaming
+		expect      string
+		expectError bool
+	}{
+		{name: "Default", expect: "c1-control-plane-abcde"},
+		{name: "Empty", naming: &infrav1.LXCClusterMachineNaming{}, expect: "c1-control-plane-abcde"},
+		{name: "NamespaceHashPrefix", naming: &infrav1.LXCClusterMachineNaming{NamespaceHashPrefix: true}, expect: namespaceHash + "-c1-control-plane-abcde"},
+		{name: "Template", naming: &infrav1.LXCClusterMachineNaming{Template: "{{ .Namespace }}-{{ .MachineName }}"}, expect: "team-a-c1-control-plane-abcde"},
+		{name: "TemplateWithHash", naming: &infrav1.LXCClusterMachineNaming{Template: "k8s-{{ .MachineName }}-{{ .NamespaceHash }}"}, expect: "k8s-c1-control-plane-abcde-" + namespaceHash},
+		{name: "TemplateParseError", naming: &infrav1.LXCClusterMachineNaming{Template: "{{ .MachineName "}, expectError: true},
+		{name: "TemplateUnknownField", naming: &infrav1.LXCClusterMachineNaming{Template: "{{ .Unknown }}"}, expectError: true},
+		{name: "TemplateInvalidName", naming: &infrav1.LXCClusterMachineNaming{Template: "{{ .MachineName }}_x"}, expectError: true},
+		{name: "TemplateEmpty", naming: &infrav1.LXCClusterMachineNaming{Template: "{{ if false }}x{{ end }}"}, expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			name, err := MachineInstanceName(tc.naming, "c1", lxcMachine)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(name).To(Equal(tc.expect))
+		})
+	}
+
+	t.Run("Truncate", func(t *testing.T) {
+		g := NewWithT(t)
+
+		naming := &infrav1.LXCClusterMachineNaming{Template: "{{ .Namespace }}-{{ .MachineName }}-{{ .MachineName }}-{{ .MachineName }}"}
+		name, err := MachineInstanceName(naming, "c1", lxcMachine)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(name).To(HaveLen(maxInstanceNameLength))
+
+		other := lxcMachine.DeepCopy()
+		other.Name = "c1-control-plane-fghij"
+		otherName, err := MachineInstanceName(naming, "c1", other)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(otherName).To(HaveLen(maxInstanceNameLength))
+		g.Expect(otherName).ToNot(Equal(name))
+	})
+}
+
+func TestValidateMachineNamingTemplate(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(ValidateMachineNamingTemplate(nil)).To(Succeed())
+	g.Expect(ValidateMachineNamingTemplate(&infrav1.LXCClusterMachineNaming{Template: "{{ .ClusterName }}-{{ .MachineName }}", NamespaceHashPrefix: true})).To(Succeed())
+	g.Expect(ValidateMachineNamingTemplate(&infrav1.LXCClusterMachineNaming{Template: "{{ .Cluster }}"})).ToNot(Succeed())
+	g.Expect(ValidateMachineNamingTemplate(&infrav1.LXCClusterMachineNaming{Template: "{{ .ClusterName "})).ToNot(Succeed())
+}
+
+func TestValidateMachineNaming(t *testing.T) {
+	g := NewWithT(t)
+
+	naming := &infrav1.LXCClusterMachineNaming{Template: "{{ .ClusterName }}-{{ .MachineName }}", NamespaceHashPrefix: true}
+	g.Expect(ValidateMachineNaming(naming, "c1", "default")).To(Succeed())
+	g.Expect(ValidateMachineNaming(&infrav1.LXCClusterMachineNaming{Template: "{{ .Cluster }}"}, "c1", "default")).ToNot(Succeed())
+
+	// The instance name depends on the cluster, e.g. cluster names may contain characters that are not valid in
+	// instance names.
+	g.Expect(ValidateMachineNaming(&infrav1.LXCClusterMachineNaming{Template: "{{ .ClusterName }}"}, "c1.example", "default")).ToNot(Succeed())
+	g.Expect(ValidateMachineNaming(&infrav1.LXCClusterMachineNaming{Template: "{{ .Namespace }}-{{ .MachineName }}"}, "c1", "Team_A")).ToNot(Succeed())
+}
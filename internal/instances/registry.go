@@ -0,0 +1,139 @@
+package instances
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// containerdCertsDirectory is the containerd registry configuration directory (config_path) on cluster machines.
+const containerdCertsDirectory = "/etc/containerd/certs.d"
+
+// RegistryMirror is a registry mirror configuration, with resolved credentials.
+type RegistryMirror struct {
+	infrav1.LXCClusterRegistryMirror
+
+	// Username and Password are used to authenticate to the mirrors. Optional.
+	Username string
+	Password string
+}
+
+// RegistryMirrorEndpoints returns the first endpoint of each registry mirror, see lxc.Image.WithRegistryMirrors.
+func RegistryMirrorEndpoints(registry *infrav1.LXCClusterRegistry) map[string]string {
+	if registry == nil || len(registry.Mirrors) == 0 {
+		return nil
+	}
+
+	endpoints := make(map[string]string, len(registry.Mirrors))
+	for _, mirror := range registry.Mirrors {
+		if len(mirror.Endpoints) > 0 {
+			endpoints[mirror.Registry] = strings.TrimSuffix(mirror.Endpoints[0], "/")
+		}
+	}
+	return endpoints
+}
+
+// ValidateRegistryMirrorName returns an error if registry is not a valid registry name for a registry mirror. Valid
+// names are registry hosts with an optional port (e.g. "docker.io" or "10.0.0.10:5000"), or "_default". The name is
+// used as a directory name under "/etc/containerd/certs.d" on the cluster machines.
+func ValidateRegistryMirrorName(registry string) error {
+	if registry == lxc.DefaultRegistryMirror {
+		return nil
+	}
+	if strings.ContainsAny(registry, `/\`) || strings.Contains(registry, "..") {
+		return fmt.Errorf("must not contain '/', '\\' or '..'")
+	}
+
+	host := registry
+	if h, port, err := net.SplitHostPort(registry); err == nil {
+		if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+		host = h
+	}
+	if net.ParseIP(host) == nil && len(validation.IsDNS1123Subdomain(host)) > 0 {
+		return fmt.Errorf("must be a registry host with an optional port (e.g. docker.io or 10.0.0.10:5000), or %q", lxc.DefaultRegistryMirror)
+	}
+	return nil
+}
+
+// RegistryMirrorFiles returns the containerd hosts.toml files (and CA bundles) for registry mirrors. Mirrors with an
+// invalid registry name (see ValidateRegistryMirrorName) are skipped.
+//
+// Note that the credentials of the mirrors are stored in plain text (base64 encoded) in the hosts.toml files.
+func RegistryMirrorFiles(mirrors []RegistryMirror) map[string]string {
+	files := make(map[string]string, 2*len(mirrors))
+	for _, mirror := range mirrors {
+		if ValidateRegistryMirrorName(mirror.Registry) != nil {
+			continue
+		}
+		dir := path.Join(containerdCertsDirectory, mirror.Registry)
+
+		var b strings.Builder
+		b.WriteString("# generated by cluster-api-provider-incus\n")
+		switch mirror.Registry {
+		case lxc.DefaultRegistryMirror:
+		case "docker.io":
+			b.WriteString("server = \"https://registry-1.docker.io\"\n")
+		default:
+			fmt.Fprintf(&b, "server = %q\n", "https://"+mirror.Registry)
+		}
+
+		for _, endpoint := range mirror.Endpoints {
+			fmt.Fprintf(&b, "\n[host.%q]\n", endpoint)
+			b.WriteString("  capabilities = [\"pull\", \"resolve\"]\n")
+			if mirror.CABundle != "" {
+				fmt.Fprintf(&b, "  ca = %q\n", path.Join(dir, "ca.crt"))
+			}
+			if mirror.InsecureSkipVerify {
+				b.WriteString("  skip_verify = true\n")
+			}
+			if mirror.Username != "" || mirror.Password != "" {
+				auth := base64.StdEncoding.EncodeToString([]byte(mirror.Username + ":" + mirror.Password))
+				fmt.Fprintf(&b, "  [host.%q.header]\n", endpoint)
+				fmt.Fprintf(&b, "    authorization = %q\n", "Basic "+auth)
+			}
+		}
+
+		files[path.Join(dir, "hosts.toml")] = b.String()
+		if mirror.CABundle != "" {
+			files[path.Join(dir, "ca.crt")] = mirror.CABundle
+		}
+	}
+	return files
+}
+
+// withRegistryMirrors creates the containerd registry mirror configuration files on instances. Files are created
+// with instance templates for virtual machines, which do not support creating files before the instance starts.
+func withRegistryMirrors(opts *lxc.LaunchOptions, instanceType api.InstanceType, mirrors []RegistryMirror) *lxc.LaunchOptions {
+	if len(mirrors) == 0 {
+		return opts
+	}
+
+	files := RegistryMirrorFiles(mirrors)
+	if instanceType == api.InstanceTypeVM {
+		for path, contents := range files {
+			files[path] = escapeInstanceTemplate(contents)
+		}
+		return opts.WithInstanceTemplates(files)
+	}
+
+	directories := []string{"/etc/containerd", containerdCertsDirectory}
+	for file := range files {
+		if dir := path.Dir(file); !slices.Contains(directories, dir) {
+			directories = append(directories, dir)
+		}
+	}
+	slices.Sort(directories)
+	return opts.WithDirectories(directories...).WithCreateFiles(files)
+}
@@ -0,0 +1,105 @@
+package instances
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func TestRegistryMirrorEndpoints(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(RegistryMirrorEndpoints(nil)).To(BeEmpty())
+	g.Expect(RegistryMirrorEndpoints(&infrav1.LXCClusterRegistry{
+		Mirrors: []infrav1.LXCClusterRegistryMirror{
+			{Registry: "docker.io", Endpoints: []string{"https://mirror.example.com/", "https://fallback.example.com"}},
+			{Registry: "_default", Endpoints: []string{"http://10.0.0.10:5000"}},
+		},
+	})).To(Equal(map[string]string{
+		"docker.io": "https://mirror.example.com",
+		"_default":  "http://10.0.0.10:5000",
+	}))
+}
+
+func TestRegistryMirrorFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	files := RegistryMirrorFiles([]RegistryMirror{
+		{
+			LXCClusterRegistryMirror: infrav1.LXCClusterRegistryMirror{
+				Registry:  "docker.io",
+				Endpoints: []string{"https://mirror.example.com"},
+				CABundle:  "CA",
+			},
+			Username: "user",
+			Password: "pass",
+		},
+		{
+			LXCClusterRegistryMirror: infrav1.LXCClusterRegistryMirror{
+				Registry:  "../../../root/.ssh",
+				Endpoints: []string{"https://mirror.example.com"},
+			},
+		},
+		{
+			LXCClusterRegistryMirror: infrav1.LXCClusterRegistryMirror{
+				Registry:           "_default",
+				Endpoints:          []string{"http://10.0.0.10:5000"},
+				InsecureSkipVerify: true,
+			},
+		},
+	})
+
+	g.Expect(files).To(Equal(map[string]string{
+		"/etc/containerd/certs.d/docker.io/hosts.toml": `# generated by cluster-api-provider-incus
+server = "https://registry-1.docker.io"
+
+[host."https://mirror.example.com"]
+  capabilities = ["pull", "resolve"]
+  ca = "/etc/containerd/certs.d/docker.io/ca.crt"
+  [host."https://mirror.example.com".header]
+    authorization = "Basic dXNlcjpwYXNz"
+`,
+		"/etc/containerd/certs.d/docker.io/ca.crt": "CA",
+		"/etc/containerd/certs.d/_default/hosts.toml": `# generated by cluster-api-provider-incus
+
+[host."http://10.0.0.10:5000"]
+  capabilities = ["pull", "resolve"]
+  skip_verify = true
+`,
+	}))
+}
+
+func TestValidateRegistryMirrorName(t *testing.T) {
+	for _, tc := range []struct {
+		registry    string
+		expectError bool
+	}{
+		{registry: "docker.io"},
+		{registry: "registry.k8s.io"},
+		{registry: "_default"},
+		{registry: "10.0.0.10:5000"},
+		{registry: "localhost:5000"},
+		{registry: "[fd42::10]:5000"},
+		{registry: "", expectError: true},
+		{registry: "..", expectError: true},
+		{registry: "../etc", expectError: true},
+		{registry: "docker.io/library", expectError: true},
+		{registry: "/etc/ssh", expectError: true},
+		{registry: "docker.io:http", expectError: true},
+		{registry: "docker.io:0", expectError: true},
+		{registry: "_other", expectError: true},
+	} {
+		t.Run(tc.registry, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := ValidateRegistryMirrorName(tc.registry)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
@@ -23,20 +23,60 @@ import (
 	"fmt"
 	"net"
 	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
 )
 
+// TemplateDataVersion is the version of the data that is supplied to haproxy configuration templates.
+// New fields may be added without changing the version. The version is incremented whenever existing
+// fields are changed or removed in a backwards incompatible way.
+const TemplateDataVersion = 1
+
 // configData is supplied to the loadbalancer config template.
+// This is documented in docs/book/src/reference/haproxy-template-data.md, please keep in sync.
 type configData struct {
+	// Version is the version of the template data, see TemplateDataVersion.
+	Version int
+
+	// ClusterName is the name of the Cluster.
+	ClusterName string
+	// ClusterNamespace is the namespace of the Cluster.
+	ClusterNamespace string
+
+	// FrontendControlPlanePort is the port the load balancer listens on for the Kubernetes API.
 	FrontendControlPlanePort string
-	BackendControlPlanePort  string
-	BackendServers           map[string]backendServer
-	IPv6                     bool
+	// BackendControlPlanePort is the port the Kubernetes API listens on for control plane instances.
+	BackendControlPlanePort string
+
+	// BackendServers is a map of the control plane backends, indexed by instance name.
+	// Prefer ControlPlaneBackends for new templates.
+	BackendServers map[string]backendServer
+	// ControlPlaneBackends is the list of control plane backends, sorted by instance name.
+	ControlPlaneBackends []backendServer
+	// WorkerBackends is the list of worker backends, sorted by instance name.
+	WorkerBackends []backendServer
+
+	// IPv6 is true if the load balancer should also listen on IPv6 addresses.
+	IPv6 bool
 }
 
 // backendServer defines a loadbalancer backend.
 type backendServer struct {
+	// Name is the name of the instance.
+	Name string
+	// MachineName is the name of the Machine. It may be empty for instances that are not managed by a Machine.
+	MachineName string
+	// Role is the role of the instance in the cluster, one of "control-plane" or "worker".
+	Role string
+	// FailureDomain is the cluster member where the instance is running. It is empty for standalone servers.
+	FailureDomain string
+
+	// Address is the address used for the backend.
 	Address string
-	Weight  int
+	// Addresses is the list of all addresses of the instance.
+	Addresses []string
+	// Weight is the weight of the backend.
+	Weight int
 }
 
 // DefaultHaproxyTemplate is the loadbalancer config template.
@@ -64,7 +104,7 @@ defaults
 
 frontend stats
   mode http
-  bind *:8404
+  bind 127.0.0.1:8404
   stats enable
   stats uri /stats
   stats refresh 1s
@@ -84,11 +124,17 @@ backend kube-apiservers
   {{- end}}
 `
 
+// templateFuncs returns the helper functions that are available to haproxy configuration templates.
+// Hermetic sprig functions are included (e.g. "join", "upper", "list", "has"), see https://masterminds.github.io/sprig/.
+func templateFuncs() template.FuncMap {
+	funcs := sprig.HermeticTxtFuncMap()
+	funcs["JoinHostPort"] = net.JoinHostPort
+	return funcs
+}
+
 // renderHaproxyConfiguration generates the loadbalancer config from the ConfigTemplate and ConfigData.
-func renderHaproxyConfiguration(data *configData, configTemplate string) (config []byte, err error) {
-	t, err := template.New("loadbalancer-config").Funcs(template.FuncMap{
-		"JoinHostPort": net.JoinHostPort,
-	}).Parse(configTemplate)
+func renderHaproxyConfiguration(data any, configTemplate string) (config []byte, err error) {
+	t, err := template.New("loadbalancer-config").Funcs(templateFuncs()).Parse(configTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config template: %w", err)
 	}
@@ -99,3 +145,38 @@ func renderHaproxyConfiguration(data *configData, configTemplate string) (config
 	}
 	return buff.Bytes(), nil
 }
+
+// ValidateHAProxyConfigTemplate checks that a custom haproxy configuration template can be rendered.
+// The template is rendered against sample data, so that errors surface before the load balancer is reconfigured.
+func ValidateHAProxyConfigTemplate(configTemplate string) error {
+	controlPlane := []backendServer{
+		{Name: "cp-0", MachineName: "cp-0", Role: "control-plane", FailureDomain: "server1", Address: "10.0.0.10", Addresses: []string{"10.0.0.10", "fd42::10"}, Weight: 100},
+		{Name: "cp-1", MachineName: "cp-1", Role: "control-plane", FailureDomain: "server2", Address: "10.0.0.11", Addresses: []string{"10.0.0.11", "fd42::11"}, Weight: 100},
+	}
+	workers := []backendServer{
+		{Name: "md-0", MachineName: "md-0", Role: "worker", FailureDomain: "server1", Address: "10.0.0.20", Addresses: []string{"10.0.0.20", "fd42::20"}, Weight: 100},
+	}
+
+	for _, ipv6 := range []bool{false, true} {
+		data := &configData{
+			Version:                  TemplateDataVersion,
+			ClusterName:              "cluster",
+			ClusterNamespace:         "default",
+			FrontendControlPlanePort: "6443",
+			BackendControlPlanePort:  "6443",
+			BackendServers:           make(map[string]backendServer, len(controlPlane)),
+			ControlPlaneBackends:     controlPlane,
+			WorkerBackends:           workers,
+			IPv6:                     ipv6,
+		}
+		for _, backend := range controlPlane {
+			data.BackendServers[backend.Name] = backend
+		}
+
+		if _, err := renderHaproxyConfiguration(data, configTemplate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
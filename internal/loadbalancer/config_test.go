@@ -0,0 +1,35 @@
+package loadbalancer
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateHAProxyConfigTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		template    string
+		expectError bool
+	}{
+		{name: "Default", template: DefaultHaproxyTemplate},
+		{name: "TemplateData", template: `# {{ .Version }} {{ .ClusterNamespace }}/{{ .ClusterName }}
+{{ range .ControlPlaneBackends }}server {{ .Name }} {{ JoinHostPort .Address $.BackendControlPlanePort }} # {{ .MachineName }} {{ .Role }} {{ .FailureDomain }} {{ join "," .Addresses }}
+{{ end }}{{ range .WorkerBackends }}server {{ .Name | upper }} {{ .Address }}:80
+{{ end }}`},
+		{name: "ParseError", template: "{{ .ClusterName ", expectError: true},
+		{name: "UnknownField", template: "{{ .UnknownField }}", expectError: true},
+		{name: "UnknownBackendField", template: "{{ range .ControlPlaneBackends }}{{ .Zone }}{{ end }}", expectError: true},
+		{name: "UnknownFunction", template: `{{ env "HOME" }}`, expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := ValidateHAProxyConfigTemplate(tc.template)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
@@ -4,4 +4,17 @@ import "time"
 
 const (
 	loadBalancerReconfigureTimeout = 30 * time.Second
+
+	// haproxyStatsTimeout is the timeout for retrieving backend health from the haproxy stats frontend.
+	haproxyStatsTimeout = 5 * time.Second
+
+	// haproxyStatsPort is the port of the haproxy stats frontend.
+	haproxyStatsPort = "8404"
+
+	// defaultKubeVIPImage is the default kube-vip image. It must support the BGP and services features (e.g. the
+	// "svc_leasename" setting, added in kube-vip v0.8).
+	defaultKubeVIPImage = "ghcr.io/kube-vip/kube-vip:v0.8.9"
+
+	// haproxyControlPlaneBackend is the name of the haproxy backend with the control plane servers.
+	haproxyControlPlaneBackend = "kube-apiservers"
 )
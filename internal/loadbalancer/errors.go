@@ -0,0 +1,23 @@
+package loadbalancer
+
+import "errors"
+
+type configValidationError struct {
+	error
+}
+
+// IsConfigValidationError checks whether the error indicates that the rendered load balancer configuration was rejected.
+// In that case, the live load balancer configuration has been left untouched.
+func IsConfigValidationError(err error) bool {
+	return errors.As(err, &configValidationError{})
+}
+
+type reloadError struct {
+	error
+}
+
+// IsReloadError checks whether the error indicates that the load balancer failed to reload its configuration.
+// In that case, the previous load balancer configuration has been restored.
+func IsReloadError(err error) bool {
+	return errors.As(err, &reloadError{})
+}
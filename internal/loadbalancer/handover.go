@@ -0,0 +1,71 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"net"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+// Identity returns a string that identifies the resources of the load balancer described by spec, e.g. "lxc",
+// "lxc/shared/<name>" or "ovn/<network>[/<listen address>]". When the load balancer spec of a cluster changes such
+// that its identity changes, the control plane endpoint must be handed over from the previous load balancer to the
+// new one.
+func Identity(spec infrav1.LXCClusterLoadBalancer) string {
+	switch {
+	case spec.LXC != nil:
+		if ref := spec.LXC.SharedLoadBalancerRef; ref != nil {
+			return fmt.Sprintf("lxc/shared/%s", ref.Name)
+		}
+		return "lxc"
+	case spec.OCI != nil:
+		if ref := spec.OCI.SharedLoadBalancerRef; ref != nil {
+			return fmt.Sprintf("oci/shared/%s", ref.Name)
+		}
+		return "oci"
+	case spec.OVN != nil:
+		if spec.OVN.ListenAddress != "" {
+			return fmt.Sprintf("ovn/%s/%s", spec.OVN.NetworkName, spec.OVN.ListenAddress)
+		}
+		return fmt.Sprintf("ovn/%s", spec.OVN.NetworkName)
+	case spec.KubeVIP != nil:
+		return "kube-vip"
+	default:
+		return "external"
+	}
+}
+
+// ValidateHandover returns an error if the control plane endpoint cannot be handed over from the load balancer
+// described by the from spec to the load balancer described by the to spec. The control plane endpoint cannot change
+// during the handover, so if endpointHost is an IP address, the new load balancer must be able to serve it.
+func ValidateHandover(from, to infrav1.LXCClusterLoadBalancer, endpointHost string) error {
+	fromIdentity, toIdentity := Identity(from), Identity(to)
+	if fromIdentity == toIdentity {
+		return nil
+	}
+
+	// Dedicated "lxc" and "oci" load balancers both use the load balancer instance name of the LXCCluster,
+	// so they cannot be running at the same time.
+	if (fromIdentity == "lxc" || fromIdentity == "oci") && (toIdentity == "lxc" || toIdentity == "oci") {
+		return fmt.Errorf("cannot switch from %q to %q load balancer, as both use the same load balancer instance", fromIdentity, toIdentity)
+	}
+
+	if endpointIP := net.ParseIP(endpointHost); endpointIP != nil {
+		switch {
+		case toIdentity == "lxc" || toIdentity == "oci":
+			// Dedicated "lxc" and "oci" load balancer instances get their address from the network.
+			return fmt.Errorf("cannot switch to %q load balancer, as it cannot serve the control plane endpoint address %s; use a DNS name as control plane endpoint, or a shared load balancer", toIdentity, endpointHost)
+		case to.OVN != nil && to.OVN.ListenAddress != "" && !net.ParseIP(to.OVN.ListenAddress).Equal(endpointIP):
+			return fmt.Errorf("cannot switch to %q load balancer, as its listen address does not match the control plane endpoint address %s", toIdentity, endpointHost)
+		}
+	}
+
+	return nil
+}
+
+// HandoverClaimsAddress returns true if the load balancer described by spec serves an IP address control plane
+// endpoint by claiming the address itself ("ovn" and "kube-vip"). During a handover to such a load balancer, the
+// previous load balancer must release the address before the new load balancer is created.
+func HandoverClaimsAddress(spec infrav1.LXCClusterLoadBalancer) bool {
+	return spec.OVN != nil || spec.KubeVIP != nil
+}
@@ -0,0 +1,85 @@
+package loadbalancer
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func TestIdentity(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		spec   infrav1.LXCClusterLoadBalancer
+		expect string
+	}{
+		{name: "LXC", spec: infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{}}, expect: "lxc"},
+		{name: "LXCShared", spec: infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{SharedLoadBalancerRef: &infrav1.LXCSharedLoadBalancerRef{Name: "lb"}}}, expect: "lxc/shared/lb"},
+		{name: "OCI", spec: infrav1.LXCClusterLoadBalancer{OCI: &infrav1.LXCLoadBalancerInstance{}}, expect: "oci"},
+		{name: "OCIShared", spec: infrav1.LXCClusterLoadBalancer{OCI: &infrav1.LXCLoadBalancerInstance{SharedLoadBalancerRef: &infrav1.LXCSharedLoadBalancerRef{Name: "lb"}}}, expect: "oci/shared/lb"},
+		{name: "OVN", spec: infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0"}}, expect: "ovn/ovn0"},
+		{name: "OVNListenAddress", spec: infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0", ListenAddress: "10.0.0.10"}}, expect: "ovn/ovn0/10.0.0.10"},
+		{name: "KubeVIP", spec: infrav1.LXCClusterLoadBalancer{KubeVIP: &infrav1.LXCLoadBalancerKubeVIP{}}, expect: "kube-vip"},
+		{name: "External", spec: infrav1.LXCClusterLoadBalancer{External: &infrav1.LXCLoadBalancerExternal{}}, expect: "external"},
+		{name: "Empty", expect: "external"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(Identity(tc.spec)).To(Equal(tc.expect))
+		})
+	}
+}
+
+func TestValidateHandover(t *testing.T) {
+	lxc := infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{}}
+	oci := infrav1.LXCClusterLoadBalancer{OCI: &infrav1.LXCLoadBalancerInstance{}}
+	shared := infrav1.LXCClusterLoadBalancer{OCI: &infrav1.LXCLoadBalancerInstance{SharedLoadBalancerRef: &infrav1.LXCSharedLoadBalancerRef{Name: "lb"}}}
+	ovn := infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0"}}
+	kubeVIP := infrav1.LXCClusterLoadBalancer{KubeVIP: &infrav1.LXCLoadBalancerKubeVIP{}}
+	ovnListen := func(address string) infrav1.LXCClusterLoadBalancer {
+		return infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0", ListenAddress: address}}
+	}
+
+	for _, tc := range []struct {
+		name        string
+		from, to    infrav1.LXCClusterLoadBalancer
+		endpoint    string
+		expectError bool
+	}{
+		{name: "Unchanged", from: lxc, to: lxc, endpoint: "10.0.0.10"},
+		{name: "LXCToOVN", from: lxc, to: ovn, endpoint: "c1.example.com"},
+		{name: "LXCToKubeVIP", from: lxc, to: kubeVIP, endpoint: "c1.example.com"},
+		{name: "LXCToShared", from: lxc, to: shared, endpoint: "c1.example.com"},
+		{name: "SharedToOCI", from: shared, to: oci, endpoint: "c1.example.com"},
+		{name: "LXCToOCI", from: lxc, to: oci, endpoint: "c1.example.com", expectError: true},
+		{name: "OCIToLXC", from: oci, to: lxc, endpoint: "c1.example.com", expectError: true},
+		{name: "IP/KubeVIPToOVN", from: kubeVIP, to: ovn, endpoint: "10.0.0.10"},
+		{name: "IP/KubeVIPToOVNListenAddress", from: kubeVIP, to: ovnListen("10.0.0.10"), endpoint: "10.0.0.10"},
+		{name: "IP/OVNToKubeVIP", from: ovn, to: kubeVIP, endpoint: "10.0.0.10"},
+		{name: "IP/KubeVIPToShared", from: kubeVIP, to: shared, endpoint: "10.0.0.10"},
+		{name: "IP/KubeVIPToLXC", from: kubeVIP, to: lxc, endpoint: "10.0.0.10", expectError: true},
+		{name: "IP/OVNToOCI", from: ovn, to: oci, endpoint: "10.0.0.10", expectError: true},
+		{name: "IP/KubeVIPToOVNOtherListenAddress", from: kubeVIP, to: ovnListen("10.0.0.20"), endpoint: "10.0.0.10", expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := ValidateHandover(tc.from, tc.to, tc.endpoint)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestHandoverClaimsAddress(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(HandoverClaimsAddress(infrav1.LXCClusterLoadBalancer{KubeVIP: &infrav1.LXCLoadBalancerKubeVIP{}})).To(BeTrue())
+	g.Expect(HandoverClaimsAddress(infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0"}})).To(BeTrue())
+	g.Expect(HandoverClaimsAddress(infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{}})).To(BeFalse())
+	g.Expect(HandoverClaimsAddress(infrav1.LXCClusterLoadBalancer{External: &infrav1.LXCLoadBalancerExternal{}})).To(BeFalse())
+}
@@ -0,0 +1,127 @@
+package loadbalancer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+
+	incus "github.com/lxc/incus/v6/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+)
+
+// haproxyInstanceClient is the subset of lxc.Client that is used to reconfigure haproxy on a load balancer instance.
+type haproxyInstanceClient interface {
+	GetInstanceFile(instanceName string, filePath string) (io.ReadCloser, *incus.InstanceFileResponse, error)
+	CreateInstanceFile(instanceName string, filePath string, args incus.InstanceFileArgs) error
+	DeleteInstanceFile(instanceName string, filePath string) error
+	RunCommand(ctx context.Context, instanceName string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+}
+
+var _ haproxyInstanceClient = &lxc.Client{}
+
+// haproxyInstance describes how haproxy is configured on a load balancer instance.
+type haproxyInstance struct {
+	lxcClient haproxyInstanceClient
+	name      string
+
+	// configPath is the path to the live haproxy configuration file.
+	configPath string
+	// binaryPath is the path to the haproxy binary, used to validate configuration files.
+	binaryPath string
+	// reloadCommands are the commands that reload the haproxy configuration, in order. A failing command aborts
+	// the reload, and the previous configuration is restored. Commands that cannot report a failed reload (e.g.
+	// sending a signal to haproxy) must be preceded by a command that validates the live configuration.
+	reloadCommands [][]string
+}
+
+// reconfigure safely replaces the haproxy configuration of the instance.
+//
+// The new configuration is first written to a staging path and validated with "haproxy -c". The live configuration
+// is only replaced after validation passes. If reloading haproxy fails, the previous configuration is restored.
+//
+// The staging path is unique for each call, as member clusters of a shared load balancer reconfigure the same
+// instance concurrently.
+func (h *haproxyInstance) reconfigure(ctx context.Context, haproxyCfg []byte) error {
+	stagingPath := fmt.Sprintf("%s.%s.new", h.configPath, rand.Text())
+
+	log.FromContext(ctx).V(1).WithValues("path", stagingPath).Info("Write staging haproxy config")
+	if err := h.writeConfig(stagingPath, haproxyCfg); err != nil {
+		return fmt.Errorf("failed to write staging haproxy config: %w", err)
+	}
+	defer func() {
+		if err := h.lxcClient.DeleteInstanceFile(h.name, stagingPath); err != nil {
+			log.FromContext(ctx).V(1).Error(err, "Failed to remove staging haproxy config", "path", stagingPath)
+		}
+	}()
+
+	log.FromContext(ctx).V(1).WithValues("path", stagingPath).Info("Validate staging haproxy config")
+	var stdout, stderr bytes.Buffer
+	if err := h.lxcClient.RunCommand(ctx, h.name, []string{h.binaryPath, "-c", "-f", stagingPath}, nil, &stdout, &stderr); err != nil {
+		return configValidationError{fmt.Errorf("haproxy configuration is not valid: %w: %s", err, strings.TrimSpace(stderr.String()+"\n"+stdout.String()))}
+	}
+
+	previousCfg, err := h.readConfig()
+	if err != nil {
+		return fmt.Errorf("failed to read current haproxy config: %w", err)
+	}
+
+	log.FromContext(ctx).V(1).WithValues("path", h.configPath).Info("Write haproxy config")
+	if err := h.writeConfig(h.configPath, haproxyCfg); err != nil {
+		return fmt.Errorf("failed to write haproxy config: %w", err)
+	}
+
+	log.FromContext(ctx).V(1).Info("Reloading haproxy configuration")
+	if reloadErr := h.reload(ctx); reloadErr != nil {
+		log.FromContext(ctx).Error(reloadErr, "Failed to reload haproxy, restoring previous configuration")
+
+		if err := h.writeConfig(h.configPath, previousCfg); err != nil {
+			return fmt.Errorf("failed to reload haproxy (%w), and failed to restore previous haproxy config: %w", reloadErr, err)
+		}
+		if err := h.reload(ctx); err != nil {
+			return fmt.Errorf("failed to reload haproxy (%w), and failed to reload previous haproxy config: %w", reloadErr, err)
+		}
+
+		return reloadError{fmt.Errorf("failed to reload haproxy, previous configuration was restored: %w", reloadErr)}
+	}
+
+	return nil
+}
+
+// reload runs the reload commands of the instance, and stops at the first failing command.
+func (h *haproxyInstance) reload(ctx context.Context) error {
+	for _, command := range h.reloadCommands {
+		var stdout, stderr bytes.Buffer
+		if err := h.lxcClient.RunCommand(ctx, h.name, command, nil, &stdout, &stderr); err != nil {
+			return fmt.Errorf("command %q failed: %w: %s", strings.Join(command, " "), err, strings.TrimSpace(stderr.String()+"\n"+stdout.String()))
+		}
+	}
+	return nil
+}
+
+// readConfig reads the live haproxy configuration file of the instance.
+func (h *haproxyInstance) readConfig() ([]byte, error) {
+	reader, _, err := h.lxcClient.GetInstanceFile(h.name, h.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetInstanceFile: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	return io.ReadAll(reader)
+}
+
+// writeConfig writes a haproxy configuration file on the instance.
+func (h *haproxyInstance) writeConfig(path string, haproxyCfg []byte) error {
+	return h.lxcClient.CreateInstanceFile(h.name, path, incus.InstanceFileArgs{
+		Content:   bytes.NewReader(haproxyCfg),
+		WriteMode: "overwrite",
+		Type:      "file",
+		Mode:      0440,
+		UID:       0,
+		GID:       0,
+	})
+}
@@ -0,0 +1,198 @@
+package loadbalancer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+
+	incus "github.com/lxc/incus/v6/client"
+	. "github.com/onsi/gomega"
+)
+
+// fakeHaproxyInstanceClient is a haproxyInstanceClient that keeps instance files in memory.
+type fakeHaproxyInstanceClient struct {
+	files    map[string][]byte
+	commands [][]string
+
+	// failCommand returns an error for commands that should fail. The files of the instance are passed, such that
+	// validation commands can inspect the configuration.
+	failCommand func(command []string, files map[string][]byte) error
+	// stdout is written to the stdout of commands.
+	stdout string
+}
+
+func (f *fakeHaproxyInstanceClient) GetInstanceFile(instanceName string, filePath string) (io.ReadCloser, *incus.InstanceFileResponse, error) {
+	b, ok := f.files[filePath]
+	if !ok {
+		return nil, nil, fmt.Errorf("file %q not found", filePath)
+	}
+	return io.NopCloser(bytes.NewReader(b)), &incus.InstanceFileResponse{}, nil
+}
+
+func (f *fakeHaproxyInstanceClient) CreateInstanceFile(instanceName string, filePath string, args incus.InstanceFileArgs) error {
+	b, err := io.ReadAll(args.Content)
+	if err != nil {
+		return err
+	}
+	f.files[filePath] = b
+	return nil
+}
+
+func (f *fakeHaproxyInstanceClient) DeleteInstanceFile(instanceName string, filePath string) error {
+	delete(f.files, filePath)
+	return nil
+}
+
+func (f *fakeHaproxyInstanceClient) RunCommand(ctx context.Context, instanceName string, command []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	f.commands = append(f.commands, command)
+	if stdout != nil {
+		_, _ = io.WriteString(stdout, f.stdout)
+	}
+	if f.failCommand != nil {
+		return f.failCommand(command, f.files)
+	}
+	return nil
+}
+
+func TestHaproxyInstanceReconfigure(t *testing.T) {
+	const configPath = "/usr/local/etc/haproxy/haproxy.cfg"
+
+	newInstance := func(client *fakeHaproxyInstanceClient) *haproxyInstance {
+		return &haproxyInstance{
+			lxcClient:  client,
+			name:       "lb",
+			configPath: configPath,
+			binaryPath: "/init",
+			reloadCommands: [][]string{
+				{"/init", "-c", "-f", configPath},
+				{"kill", "--signal", "SIGUSR2", "1"},
+			},
+		}
+	}
+
+	// stagingPath returns the staging path that was validated by the first command.
+	stagingPath := func(g *WithT, client *fakeHaproxyInstanceClient) string {
+		g.Expect(client.commands).ToNot(BeEmpty())
+		path := client.commands[0][len(client.commands[0])-1]
+		g.Expect(path).To(HavePrefix(configPath + "."))
+		g.Expect(path).To(HaveSuffix(".new"))
+		return path
+	}
+
+	// invalidConfig fails validation of configuration files that contain "invalid".
+	invalidConfig := func(command []string, files map[string][]byte) error {
+		if command[0] == "/init" && bytes.Contains(files[command[len(command)-1]], []byte("invalid")) {
+			return fmt.Errorf("exit status 1")
+		}
+		return nil
+	}
+
+	t.Run("Reconfigure", func(t *testing.T) {
+		g := NewWithT(t)
+
+		client := &fakeHaproxyInstanceClient{files: map[string][]byte{configPath: []byte("old")}}
+		g.Expect(newInstance(client).reconfigure(context.Background(), []byte("new"))).To(Succeed())
+
+		g.Expect(client.files).To(Equal(map[string][]byte{configPath: []byte("new")}))
+		g.Expect(client.commands).To(Equal([][]string{
+			{"/init", "-c", "-f", stagingPath(g, client)},
+			{"/init", "-c", "-f", configPath},
+			{"kill", "--signal", "SIGUSR2", "1"},
+		}))
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		g := NewWithT(t)
+
+		client := &fakeHaproxyInstanceClient{files: map[string][]byte{configPath: []byte("old")}, failCommand: invalidConfig}
+		err := newInstance(client).reconfigure(context.Background(), []byte("invalid"))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(IsConfigValidationError(err)).To(BeTrue())
+
+		g.Expect(client.files).To(Equal(map[string][]byte{configPath: []byte("old")}))
+		g.Expect(client.commands).To(Equal([][]string{
+			{"/init", "-c", "-f", stagingPath(g, client)},
+		}))
+	})
+
+	t.Run("ReloadFailed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		client := &fakeHaproxyInstanceClient{
+			files: map[string][]byte{configPath: []byte("old")},
+			failCommand: func(command []string, files map[string][]byte) error {
+				// The new configuration passes validation, but haproxy fails to reload it.
+				if command[0] == "kill" && bytes.Equal(files[configPath], []byte("new")) {
+					return fmt.Errorf("exit status 1")
+				}
+				return nil
+			},
+		}
+		err := newInstance(client).reconfigure(context.Background(), []byte("new"))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(IsReloadError(err)).To(BeTrue())
+
+		g.Expect(client.files).To(Equal(map[string][]byte{configPath: []byte("old")}))
+		g.Expect(client.commands[len(client.commands)-1]).To(Equal([]string{"kill", "--signal", "SIGUSR2", "1"}))
+	})
+
+	t.Run("LiveConfigInvalid", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// The staging configuration is valid, but validating the live configuration fails (e.g. the live
+		// configuration references files relative to its path). The signal must not be sent.
+		client := &fakeHaproxyInstanceClient{
+			files: map[string][]byte{configPath: []byte("old")},
+			failCommand: func(command []string, files map[string][]byte) error {
+				if slices.Equal(command, []string{"/init", "-c", "-f", configPath}) && bytes.Equal(files[configPath], []byte("new")) {
+					return fmt.Errorf("exit status 1")
+				}
+				return nil
+			},
+		}
+		err := newInstance(client).reconfigure(context.Background(), []byte("new"))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(IsReloadError(err)).To(BeTrue())
+
+		g.Expect(client.files).To(Equal(map[string][]byte{configPath: []byte("old")}))
+		g.Expect(client.commands).To(Equal([][]string{
+			{"/init", "-c", "-f", stagingPath(g, client)},
+			{"/init", "-c", "-f", configPath},
+			{"/init", "-c", "-f", configPath},
+			{"kill", "--signal", "SIGUSR2", "1"},
+		}))
+	})
+
+	t.Run("UniqueStagingPath", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// Member clusters of a shared load balancer must not overwrite each other's staging configuration.
+		first := &fakeHaproxyInstanceClient{files: map[string][]byte{configPath: []byte("old")}}
+		second := &fakeHaproxyInstanceClient{files: map[string][]byte{configPath: []byte("old")}}
+		g.Expect(newInstance(first).reconfigure(context.Background(), []byte("new"))).To(Succeed())
+		g.Expect(newInstance(second).reconfigure(context.Background(), []byte("new"))).To(Succeed())
+
+		g.Expect(stagingPath(g, first)).ToNot(Equal(stagingPath(g, second)))
+	})
+
+	t.Run("RestoreFailed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		client := &fakeHaproxyInstanceClient{
+			files: map[string][]byte{configPath: []byte("old")},
+			failCommand: func(command []string, files map[string][]byte) error {
+				if command[0] == "kill" {
+					return fmt.Errorf("exit status 1")
+				}
+				return nil
+			},
+		}
+		err := newInstance(client).reconfigure(context.Background(), []byte("new"))
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(IsReloadError(err)).To(BeFalse())
+		g.Expect(client.files).To(Equal(map[string][]byte{configPath: []byte("old")}))
+	})
+}
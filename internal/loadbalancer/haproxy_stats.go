@@ -0,0 +1,109 @@
+package loadbalancer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+// haproxyStatsCommand retrieves the haproxy stats in CSV format from the stats frontend of the load balancer instance.
+// The stats frontend only listens on localhost, so the command runs inside the instance, and only requires bash.
+var haproxyStatsCommand = []string{"bash", "-c", fmt.Sprintf(`exec 3<>/dev/tcp/127.0.0.1/%s && printf 'GET /stats;csv HTTP/1.0\r\n\r\n' >&3 && cat <&3`, haproxyStatsPort)}
+
+// getHaproxyBackends retrieves the health of the servers of a backend from the haproxy stats frontend of the load balancer instance.
+func getHaproxyBackends(ctx context.Context, lxcClient haproxyInstanceClient, instanceName string, backendName string) ([]infrav1.LXCLoadBalancerBackendStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, haproxyStatsTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	if err := lxcClient.RunCommand(ctx, instanceName, haproxyStatsCommand, nil, &stdout, &stderr); err != nil {
+		return nil, fmt.Errorf("failed to retrieve haproxy stats: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(&stdout), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse haproxy stats response: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve haproxy stats: unexpected status code %d", resp.StatusCode)
+	}
+
+	return parseHaproxyStatsCSV(resp.Body, backendName)
+}
+
+// parseHaproxyStatsCSV parses the CSV output of the haproxy stats page, and returns the servers of the specified backend.
+// See https://docs.haproxy.org/2.8/management.html#9.1 for details on the format.
+func parseHaproxyStatsCSV(r io.Reader, backendName string) ([]infrav1.LXCLoadBalancerBackendStatus, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "# ")
+	}
+	columns := func(name string) int { return slices.Index(header, name) }
+	pxname, svname, status, addr := columns("pxname"), columns("svname"), columns("status"), columns("addr")
+	if pxname < 0 || svname < 0 || status < 0 {
+		return nil, fmt.Errorf("unexpected header %v", header)
+	}
+
+	field := func(record []string, idx int) string {
+		if idx < 0 || idx >= len(record) {
+			return ""
+		}
+		return record[idx]
+	}
+
+	var backends []infrav1.LXCLoadBalancerBackendStatus
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read record: %w", err)
+		}
+
+		if field(record, pxname) != backendName {
+			continue
+		}
+		switch name := field(record, svname); name {
+		case "FRONTEND", "BACKEND":
+			continue
+		default:
+			// status is one of "UP", "DOWN", "NOLB", "MAINT", "no check", with an optional "x/y" suffix while transitioning.
+			health := infrav1.LoadBalancerBackendUnknown
+			switch st := field(record, status); {
+			case strings.HasPrefix(st, "UP"):
+				health = infrav1.LoadBalancerBackendHealthy
+			case strings.HasPrefix(st, "DOWN"):
+				health = infrav1.LoadBalancerBackendUnhealthy
+			}
+
+			address := field(record, addr)
+			if host, _, err := net.SplitHostPort(address); err == nil {
+				address = host
+			}
+
+			backends = append(backends, infrav1.LXCLoadBalancerBackendStatus{
+				Name:    name,
+				Address: address,
+				Health:  health,
+			})
+		}
+	}
+
+	return backends, nil
+}
@@ -0,0 +1,74 @@
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseHaproxyStatsCSV(t *testing.T) {
+	g := NewWithT(t)
+
+	stats := `# pxname,svname,qcur,qmax,scur,smax,slim,stot,bin,bout,dreq,dresp,ereq,econ,eresp,wretr,wredis,status,weight,act,bck,addr,
+stats,FRONTEND,,,0,1,100000,3,354,0,0,0,0,,,,,OPEN,,,,,
+control-plane,FRONTEND,,,0,0,100000,0,0,0,0,0,0,,,,,OPEN,,,,,
+kube-apiservers,cp-1,0,0,0,0,,0,0,0,,0,,0,0,0,0,UP,100,1,0,10.0.0.11:6443,
+kube-apiservers,cp-2,0,0,0,0,,0,0,0,,0,,0,0,0,0,DOWN 1/2,100,1,0,10.0.0.12:6443,
+kube-apiservers,cp-3,0,0,0,0,,0,0,0,,0,,0,0,0,0,MAINT,100,1,0,[fd42::13]:6443,
+kube-apiservers,BACKEND,0,0,0,0,10000,0,0,0,0,0,,0,0,0,0,UP,200,2,0,,
+`
+
+	backends, err := parseHaproxyStatsCSV(strings.NewReader(stats), "kube-apiservers")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(backends).To(Equal([]infrav1.LXCLoadBalancerBackendStatus{
+		{Name: "cp-1", Address: "10.0.0.11", Health: infrav1.LoadBalancerBackendHealthy},
+		{Name: "cp-2", Address: "10.0.0.12", Health: infrav1.LoadBalancerBackendUnhealthy},
+		{Name: "cp-3", Address: "fd42::13", Health: infrav1.LoadBalancerBackendUnknown},
+	}))
+
+	_, err = parseHaproxyStatsCSV(strings.NewReader("invalid\n"), "kube-apiservers")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGetHaproxyBackends(t *testing.T) {
+	const stats = `# pxname,svname,status,addr,
+kube-apiservers,cp-1,UP,10.0.0.11:6443,
+kube-apiservers,BACKEND,UP,,
+`
+
+	t.Run("OK", func(t *testing.T) {
+		g := NewWithT(t)
+
+		client := &fakeHaproxyInstanceClient{stdout: "HTTP/1.0 200 OK\r\nContent-Type: text/plain\r\n\r\n" + stats}
+		backends, err := getHaproxyBackends(context.Background(), client, "lb", "kube-apiservers")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(backends).To(Equal([]infrav1.LXCLoadBalancerBackendStatus{
+			{Name: "cp-1", Address: "10.0.0.11", Health: infrav1.LoadBalancerBackendHealthy},
+		}))
+
+		// stats are retrieved from inside the instance
+		g.Expect(client.commands).To(Equal([][]string{haproxyStatsCommand}))
+		g.Expect(haproxyStatsCommand[2]).To(ContainSubstring("/dev/tcp/127.0.0.1/8404"))
+	})
+
+	t.Run("StatusCode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		client := &fakeHaproxyInstanceClient{stdout: "HTTP/1.0 503 Service Unavailable\r\n\r\n"}
+		_, err := getHaproxyBackends(context.Background(), client, "lb", "kube-apiservers")
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("CommandFailed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		client := &fakeHaproxyInstanceClient{failCommand: func([]string, map[string][]byte) error { return fmt.Errorf("command failed with exit code 1") }}
+		_, err := getHaproxyBackends(context.Background(), client, "lb", "kube-apiservers")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
@@ -3,7 +3,10 @@ package loadbalancer
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"text/template"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
 )
 
 // DefaultKubeVIPTemplate is the KubeVIP config template.
@@ -18,10 +21,27 @@ spec:
   - args:
     - manager
     env:
+{{- if .BGP }}
+    - name: bgp_enable
+      value: "true"
+    - name: bgp_as
+      value: "{{ .BGP.AS }}"
+{{- if .BGP.RouterID }}
+    - name: bgp_routerid
+      value: "{{ .BGP.RouterID }}"
+{{- end }}
+{{- if .BGP.SourceInterface }}
+    - name: bgp_source_if
+      value: "{{ .BGP.SourceInterface }}"
+{{- end }}
+    - name: bgp_peers
+      value: "{{ .BGPPeers }}"
+{{- else }}
     - name: vip_arp
       value: "true"
+{{- end }}
     - name: port
-      value: "6443"
+      value: "{{ .Port }}"
     - name: vip_interface
       value: "{{ .Interface }}"
     - name: vip_cidr
@@ -75,15 +95,67 @@ spec:
 status: {}
 `
 
+// DefaultKubeVIPCloudProviderTemplate is the kube-vip cloud provider static pod template.
+// The cloud provider allocates addresses for Services of type LoadBalancer, which are then served by kube-vip.
+const DefaultKubeVIPCloudProviderTemplate = `# generated by capn
+apiVersion: v1
+kind: Pod
+metadata:
+  name: kube-vip-cloud-provider
+  namespace: kube-system
+spec:
+  containers:
+  - command:
+    - /kube-vip-cloud-provider
+    - --leader-elect-resource-name=kube-vip-cloud-controller
+    - --kubeconfig=/etc/kubernetes/admin.conf
+    image: "{{ .Image }}"
+    imagePullPolicy: IfNotPresent
+    name: kube-vip-cloud-provider
+    resources: {}
+    volumeMounts:
+    - mountPath: /etc/kubernetes/admin.conf
+      name: kubeconfig
+      readOnly: true
+  hostNetwork: true
+  volumes:
+  - hostPath:
+      path: {{ .KubeconfigPath }}
+    name: kubeconfig
+status: {}
+`
+
+// kubeVIPTemplateInput is supplied to the kube-vip manifest templates.
+// This is documented in docs/book/src/explanation/load-balancer.md, please keep in sync.
 type kubeVIPTemplateInput struct {
-	Interface      string
-	Address        string
-	Image          string
+	// Interface is the name of the interface where the VIP is configured. Empty means the default interface.
+	Interface string
+	// Address is the VIP address.
+	Address string
+	// Port is the port of the Kubernetes API.
+	Port string
+	// Image is the kube-vip image.
+	Image string
+	// KubeconfigPath is the kubeconfig host path to use for kube-vip.
 	KubeconfigPath string
+
+	// BGP is the BGP configuration. It is nil when using ARP.
+	BGP *infrav1.LXCLoadBalancerKubeVIPBGP
+	// BGPPeers is the list of BGP peers, in the format expected by kube-vip ("<address>:<as>::<multihop>,...").
+	BGPPeers string
+}
+
+// kubeVIPBGPPeers formats a list of BGP peers in the format expected by kube-vip.
+func kubeVIPBGPPeers(peers []infrav1.LXCLoadBalancerKubeVIPBGPPeer) string {
+	values := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		values = append(values, fmt.Sprintf("%s:%d::%v", peer.Address, peer.AS, peer.Multihop))
+	}
+	return strings.Join(values, ",")
 }
 
-func renderKubeVIPConfiguration(input kubeVIPTemplateInput) ([]byte, error) {
-	t, err := template.New("kube-vip-config").Parse(DefaultKubeVIPTemplate)
+func renderKubeVIPConfiguration(input any, manifestTemplate string) ([]byte, error) {
+	t, err := template.New("kube-vip-config").Funcs(templateFuncs()).Parse(manifestTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config template: %w", err)
 	}
@@ -95,3 +167,49 @@ func renderKubeVIPConfiguration(input kubeVIPTemplateInput) ([]byte, error) {
 	}
 	return buff.Bytes(), nil
 }
+
+// kubeVIPServicesConfigMap returns the kube-vip cloud provider ConfigMap for an address range.
+func kubeVIPServicesConfigMap(addressRange string) []byte {
+	key := "range-global"
+	if strings.Contains(addressRange, "/") {
+		key = "cidr-global"
+	}
+
+	return []byte(fmt.Sprintf(`# generated by capn
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kubevip
+  namespace: kube-system
+data:
+  %s: %q
+`, key, addressRange))
+}
+
+// ValidateKubeVIPManifestTemplate checks that a custom kube-vip manifest template can be rendered.
+// The template is rendered against sample data for both ARP and BGP modes.
+func ValidateKubeVIPManifestTemplate(manifestTemplate string) error {
+	for _, bgp := range []*infrav1.LXCLoadBalancerKubeVIPBGP{nil, {
+		AS:       65000,
+		RouterID: "10.0.0.10",
+		Peers:    []infrav1.LXCLoadBalancerKubeVIPBGPPeer{{Address: "10.0.0.1", AS: 65001}},
+	}} {
+		input := kubeVIPTemplateInput{
+			Interface:      "eth0",
+			Address:        "10.0.0.100",
+			Port:           "6443",
+			Image:          defaultKubeVIPImage,
+			KubeconfigPath: "/etc/kubernetes/admin.conf",
+			BGP:            bgp,
+		}
+		if bgp != nil {
+			input.BGPPeers = kubeVIPBGPPeers(bgp.Peers)
+		}
+
+		if _, err := renderKubeVIPConfiguration(input, manifestTemplate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package loadbalancer
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func TestRenderKubeVIPConfiguration(t *testing.T) {
+	t.Run("ARP", func(t *testing.T) {
+		g := NewWithT(t)
+
+		b, err := renderKubeVIPConfiguration(kubeVIPTemplateInput{Address: "10.0.0.100", Port: "6443", Interface: "eth0", Image: "kube-vip", KubeconfigPath: "/etc/kubernetes/admin.conf"}, DefaultKubeVIPTemplate)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		cfg := string(b)
+		g.Expect(cfg).To(ContainSubstring("    env:\n    - name: vip_arp\n      value: \"true\"\n    - name: port\n      value: \"6443\"\n"))
+		g.Expect(cfg).ToNot(ContainSubstring("bgp_"))
+	})
+
+	t.Run("BGP", func(t *testing.T) {
+		g := NewWithT(t)
+
+		bgp := &infrav1.LXCLoadBalancerKubeVIPBGP{
+			AS:       65000,
+			RouterID: "10.0.0.10",
+			Peers: []infrav1.LXCLoadBalancerKubeVIPBGPPeer{
+				{Address: "10.0.0.1", AS: 65001},
+				{Address: "10.0.0.2", AS: 65002, Multihop: true},
+			},
+		}
+		b, err := renderKubeVIPConfiguration(kubeVIPTemplateInput{Address: "10.0.0.100", Port: "6443", BGP: bgp, BGPPeers: kubeVIPBGPPeers(bgp.Peers)}, DefaultKubeVIPTemplate)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		cfg := string(b)
+		g.Expect(cfg).To(ContainSubstring("    - name: bgp_enable\n      value: \"true\"\n    - name: bgp_as\n      value: \"65000\"\n    - name: bgp_routerid\n      value: \"10.0.0.10\"\n    - name: bgp_peers\n      value: \"10.0.0.1:65001::false,10.0.0.2:65002::true\"\n"))
+		g.Expect(cfg).ToNot(ContainSubstring("vip_arp"))
+		g.Expect(cfg).ToNot(ContainSubstring("bgp_source_if"))
+	})
+}
+
+func TestManagerKubeVIPSyncedControlPlaneFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	l := &managerKubeVIP{address: "10.0.0.100", controlPlaneInitialized: true}
+	bootstrapFiles, err := l.controlPlaneFiles(false)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	files, isSynced, err := l.syncedControlPlaneFiles()
+	g.Expect(err).ToNot(HaveOccurred())
+	manifestPath := l.getManifestPath()
+	g.Expect(string(files[manifestPath])).To(ContainSubstring("/etc/kubernetes/admin.conf"))
+
+	// The manifest of the bootstrap control plane node is not rewritten.
+	g.Expect(string(bootstrapFiles[manifestPath])).To(ContainSubstring("/etc/kubernetes/super-admin.conf"))
+	g.Expect(isSynced(manifestPath, bootstrapFiles[manifestPath])).To(BeTrue())
+	g.Expect(isSynced(manifestPath, files[manifestPath])).To(BeTrue())
+
+	// Any other change is rendered with the admin kubeconfig.
+	l.address = "10.0.0.101"
+	files, isSynced, err = l.syncedControlPlaneFiles()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(isSynced(manifestPath, bootstrapFiles[manifestPath])).To(BeFalse())
+	g.Expect(string(files[manifestPath])).To(ContainSubstring("/etc/kubernetes/admin.conf"))
+}
+
+func TestKubeVIPServicesConfigMap(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(string(kubeVIPServicesConfigMap("10.0.0.100-10.0.0.120"))).To(ContainSubstring(`range-global: "10.0.0.100-10.0.0.120"`))
+	g.Expect(string(kubeVIPServicesConfigMap("10.0.0.96/28"))).To(ContainSubstring(`cidr-global: "10.0.0.96/28"`))
+}
+
+func TestValidateKubeVIPManifestTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		template    string
+		expectError bool
+	}{
+		{name: "Default", template: DefaultKubeVIPTemplate},
+		{name: "BGP", template: `{{ if .BGP }}{{ .BGP.AS }} {{ range .BGP.Peers }}{{ .Address }}{{ end }}{{ end }}`},
+		{name: "ParseError", template: "{{ .Address ", expectError: true},
+		{name: "UnknownField", template: "{{ .UnknownField }}", expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := ValidateKubeVIPManifestTemplate(tc.template)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
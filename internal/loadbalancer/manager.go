@@ -1,12 +1,21 @@
 package loadbalancer
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/instances"
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
 
 // Manager can be used to interact with the cluster load balancer.
@@ -16,9 +25,19 @@ type Manager interface {
 	// Callers must check these with utils.IsTerminalError() and treat them as terminal failures.
 	Create(context.Context) ([]string, error)
 	// Delete cleans up any load balancer resources.
+	// Implementations can indicate non-retriable failures (e.g. an external load balancer webhook rejecting the
+	// request). Callers must check these with utils.IsTerminalError(), and not block deletion on them.
 	Delete(context.Context) error
 	// Reconfigure updates the load balancer configuration based on the currently running control plane instances.
+	// Implementations can indicate that the rendered configuration was rejected, or that reloading the configuration failed.
+	// Callers can check these with IsConfigValidationError() and IsReloadError() respectively.
 	Reconfigure(context.Context) error
+	// IsSynced compares the current load balancer configuration against the currently running control plane instances.
+	// It returns false if the load balancer must be reconfigured.
+	IsSynced(context.Context) (bool, error)
+	// Backends returns the control plane backends of the load balancer, along with their health.
+	// Implementations that do not manage the load balancer backends return an empty list.
+	Backends(context.Context) ([]infrav1.LXCLoadBalancerBackendStatus, error)
 	// ControlPlaneInstanceTemplates is a map of files that will be injected as templates to control plane instances.
 	ControlPlaneInstanceTemplates(controlPlaneInitialized bool) (map[string]string, error)
 	// Inspect returns a map[string]string of the current state of the load balancer infrastructure.
@@ -26,10 +45,83 @@ type Manager interface {
 	Inspect(context.Context) map[string]string
 }
 
+// EndpointPortAssigner is implemented by managers that choose the port of the control plane endpoint.
+type EndpointPortAssigner interface {
+	// ControlPlaneEndpointPort returns the port of the control plane endpoint. It must be called after Create.
+	ControlPlaneEndpointPort(context.Context) (int32, error)
+}
+
+// ManagerOption configures optional settings of a Manager.
+type ManagerOption func(*managerOptions)
+
+type managerOptions struct {
+	webhookSecret []byte
+}
+
+// WithWebhookSecret sets the secret that is used to sign external load balancer webhook requests.
+func WithWebhookSecret(secret []byte) ManagerOption {
+	return func(o *managerOptions) {
+		o.webhookSecret = secret
+	}
+}
+
+// ManagerOptionsForCluster retrieves the secrets referenced by the load balancer spec of the lxcCluster, and
+// returns the respective options for ManagerForCluster.
+func ManagerOptionsForCluster(ctx context.Context, c client.Client, lxcCluster *infrav1.LXCCluster) ([]ManagerOption, error) {
+	var opts []ManagerOption
+
+	if external := lxcCluster.Spec.LoadBalancer.External; external != nil && external.Webhook != nil && external.Webhook.SecretRef != nil {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: lxcCluster.Namespace, Name: external.Webhook.SecretRef.Name}
+		if err := c.Get(ctx, key, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				err = utils.TerminalError(err)
+			}
+			return nil, fmt.Errorf("failed to retrieve webhook secret %s: %w", key, err)
+		}
+		value, ok := secret.Data[WebhookSecretKey]
+		if !ok {
+			return nil, utils.TerminalError(fmt.Errorf("webhook secret %s does not have key %q", key, WebhookSecretKey))
+		}
+		opts = append(opts, WithWebhookSecret(value))
+	}
+
+	return opts, nil
+}
+
+// externalWebhookCreated returns true if the external load balancer webhook of the lxcCluster was already notified
+// that the load balancer was created. The active load balancer spec is recorded after the load balancer is created, so
+// this is the case if the active load balancer is an external load balancer with the same webhook URL.
+func externalWebhookCreated(lxcCluster *infrav1.LXCCluster) bool {
+	if lxcCluster.Status.LoadBalancer == nil || lxcCluster.Status.LoadBalancer.ActiveSpec == nil {
+		return false
+	}
+	active, current := lxcCluster.Status.LoadBalancer.ActiveSpec.External, lxcCluster.Spec.LoadBalancer.External
+	if active == nil || active.Webhook == nil || current == nil || current.Webhook == nil {
+		return false
+	}
+	return active.Webhook.URL == current.Webhook.URL
+}
+
 // ManagerForCluster returns the proper Manager based on the lxcCluster spec.
-func ManagerForCluster(cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client) Manager {
+func ManagerForCluster(cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluster, lxcClient *lxc.Client, opts ...ManagerOption) Manager {
+	var options managerOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
 	switch {
 	case lxcCluster.Spec.LoadBalancer.LXC != nil:
+		if ref := lxcCluster.Spec.LoadBalancer.LXC.SharedLoadBalancerRef; ref != nil {
+			return &managerLXC{
+				lxcClient:        lxcClient,
+				clusterName:      cluster.Name,
+				clusterNamespace: cluster.Namespace,
+
+				name:   infrav1.SharedLoadBalancerInstanceName(ref.Name),
+				shared: &sharedMember{name: ref.Name, lbType: "lxc", endpoint: lxcCluster.Spec.ControlPlaneEndpoint},
+			}
+		}
 		return &managerLXC{
 			lxcClient:        lxcClient,
 			clusterName:      cluster.Name,
@@ -40,6 +132,16 @@ func ManagerForCluster(cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluste
 			customHAProxyConfigTemplate: lxcCluster.Spec.LoadBalancer.LXC.CustomHAProxyConfigTemplate,
 		}
 	case lxcCluster.Spec.LoadBalancer.OCI != nil:
+		if ref := lxcCluster.Spec.LoadBalancer.OCI.SharedLoadBalancerRef; ref != nil {
+			return &managerOCI{
+				lxcClient:        lxcClient,
+				clusterName:      cluster.Name,
+				clusterNamespace: cluster.Namespace,
+
+				name:   infrav1.SharedLoadBalancerInstanceName(ref.Name),
+				shared: &sharedMember{name: ref.Name, lbType: "oci", endpoint: lxcCluster.Spec.ControlPlaneEndpoint},
+			}
+		}
 		return &managerOCI{
 			lxcClient:        lxcClient,
 			clusterName:      cluster.Name,
@@ -48,6 +150,7 @@ func ManagerForCluster(cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluste
 			name:                        lxcCluster.GetLoadBalancerInstanceName(),
 			spec:                        lxcCluster.Spec.LoadBalancer.OCI.InstanceSpec,
 			customHAProxyConfigTemplate: lxcCluster.Spec.LoadBalancer.OCI.CustomHAProxyConfigTemplate,
+			registryMirrors:             instances.RegistryMirrorEndpoints(lxcCluster.Spec.Registry),
 		}
 	case lxcCluster.Spec.LoadBalancer.OVN != nil:
 		return &managerOVN{
@@ -56,7 +159,7 @@ func ManagerForCluster(cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluste
 			clusterNamespace: cluster.Namespace,
 
 			networkName:   lxcCluster.Spec.LoadBalancer.OVN.NetworkName,
-			listenAddress: lxcCluster.Spec.ControlPlaneEndpoint.Host,
+			listenAddress: cmp.Or(lxcCluster.Spec.LoadBalancer.OVN.ListenAddress, lxcCluster.Spec.ControlPlaneEndpoint.Host),
 		}
 	case lxcCluster.Spec.LoadBalancer.External != nil:
 		return &managerExternal{
@@ -65,6 +168,12 @@ func ManagerForCluster(cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluste
 			clusterNamespace: cluster.Namespace,
 
 			address: lxcCluster.Spec.ControlPlaneEndpoint.Host,
+			port:    lxcCluster.Spec.ControlPlaneEndpoint.Port,
+
+			webhook:        lxcCluster.Spec.LoadBalancer.External.Webhook,
+			webhookSecret:  options.webhookSecret,
+			webhookCreated: externalWebhookCreated(lxcCluster),
+			lxcCluster:     lxcCluster,
 		}
 	case lxcCluster.Spec.LoadBalancer.KubeVIP != nil:
 		return &managerKubeVIP{
@@ -78,6 +187,12 @@ func ManagerForCluster(cluster *clusterv1.Cluster, lxcCluster *infrav1.LXCCluste
 			image:          lxcCluster.Spec.LoadBalancer.KubeVIP.Image,
 			kubeconfigPath: lxcCluster.Spec.LoadBalancer.KubeVIP.KubeconfigPath,
 			manifestPath:   lxcCluster.Spec.LoadBalancer.KubeVIP.ManifestPath,
+
+			bgp:                    lxcCluster.Spec.LoadBalancer.KubeVIP.BGP,
+			services:               lxcCluster.Spec.LoadBalancer.KubeVIP.Services,
+			customManifestTemplate: lxcCluster.Spec.LoadBalancer.KubeVIP.CustomManifestTemplate,
+
+			controlPlaneInitialized: conditions.IsTrue(cluster, clusterv1.ControlPlaneInitializedCondition),
 		}
 	default:
 		// TODO: handle this more gracefully.
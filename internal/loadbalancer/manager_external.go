@@ -2,15 +2,20 @@ package loadbalancer
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
 	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
 
-// managerExternal is a no-op Manager when using an external LoadBalancer mechanism for the cluster (e.g. kube-vip).
+// managerExternal is a Manager when using an external LoadBalancer mechanism for the cluster (e.g. kube-vip).
+// If a webhook is configured, it is notified with the control plane backends of the cluster, otherwise it is a no-op.
 type managerExternal struct {
 	lxcClient *lxc.Client
 
@@ -18,6 +23,15 @@ type managerExternal struct {
 	clusterNamespace string
 
 	address string
+	port    int32
+
+	webhook       *infrav1.LXCLoadBalancerExternalWebhook
+	webhookSecret []byte
+	// webhookCreated is true if the webhook was already notified that the load balancer was created.
+	webhookCreated bool
+	// lxcCluster is used to record the hash of the control plane backends that were last sent to the webhook in its
+	// status, such that IsSynced can detect changes that were not notified (e.g. after a failed notification).
+	lxcCluster *infrav1.LXCCluster
 }
 
 // Create implements Manager.
@@ -25,14 +39,19 @@ func (l *managerExternal) Create(ctx context.Context) ([]string, error) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("address", l.address))
 
 	// TODO: extend to support automatically finding an available VIP from an address range (so that we don't have to statically assign kube-vips).
-	_ = l.clusterName
-	_ = l.clusterNamespace
-	_ = l.lxcClient
-
 	if l.address == "" {
 		return nil, utils.TerminalError(fmt.Errorf("using external load balancer but no address is configured"))
 	}
 
+	// The webhook is only notified once, when the load balancer is created. Changes to the control plane backends
+	// are notified with Reconfigure.
+	if l.webhook != nil && !l.webhookCreated {
+		log.FromContext(ctx).V(1).Info("Notifying external load balancer webhook")
+		if err := l.notify(ctx, webhookEventCreate); err != nil {
+			return nil, fmt.Errorf("failed to notify external load balancer: %w", err)
+		}
+	}
+
 	log.FromContext(ctx).V(1).Info("Using external load balancer")
 	return []string{l.address}, nil
 }
@@ -41,18 +60,131 @@ func (l *managerExternal) Create(ctx context.Context) ([]string, error) {
 func (l *managerExternal) Delete(ctx context.Context) error {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("address", l.address))
 
-	log.FromContext(ctx).V(1).Info("Using external load balancer, nothing to delete")
+	if l.webhook == nil {
+		log.FromContext(ctx).V(1).Info("Using external load balancer, nothing to delete")
+		return nil
+	}
+
+	log.FromContext(ctx).V(1).Info("Notifying external load balancer webhook")
+	if err := l.notify(ctx, webhookEventDelete); err != nil {
+		return fmt.Errorf("failed to notify external load balancer: %w", err)
+	}
 	return nil
 }
 
 // Reconfigure implements Manager.
 func (l *managerExternal) Reconfigure(ctx context.Context) error {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("address", l.address))
-	log.FromContext(ctx).V(1).Info("Using external load balancer, nothing to reconfigure")
+	if l.webhook == nil {
+		log.FromContext(ctx).V(1).Info("Using external load balancer, nothing to reconfigure")
+		return nil
+	}
 
+	log.FromContext(ctx).V(1).Info("Notifying external load balancer webhook")
+	if err := l.notify(ctx, webhookEventReconfigure); err != nil {
+		return fmt.Errorf("failed to notify external load balancer: %w", err)
+	}
 	return nil
 }
 
+// notify sends the control plane backends of the cluster to the external load balancer webhook.
+// The list of backends is empty for delete events.
+func (l *managerExternal) notify(ctx context.Context, event webhookEvent) error {
+	notifier, err := newWebhookNotifier(l.webhook, l.webhookSecret)
+	if err != nil {
+		return utils.TerminalError(fmt.Errorf("invalid webhook configuration: %w", err))
+	}
+
+	port := l.port
+	if port == 0 {
+		port = 6443
+	}
+	payload := &webhookPayload{
+		Version:              webhookPayloadVersion,
+		Event:                event,
+		Timestamp:            time.Now().UTC(),
+		Cluster:              webhookPayloadCluster{Name: l.clusterName, Namespace: l.clusterNamespace},
+		ControlPlaneEndpoint: webhookPayloadEndpoint{Host: l.address, Port: port},
+		Backends:             []webhookPayloadServer{},
+	}
+
+	if event != webhookEventDelete {
+		backends, err := l.webhookBackends(ctx)
+		if err != nil {
+			return err
+		}
+		payload.Backends = backends
+	}
+
+	if err := notifier.send(ctx, payload); err != nil {
+		return err
+	}
+
+	if l.lxcCluster != nil {
+		if l.lxcCluster.Status.LoadBalancer == nil {
+			l.lxcCluster.Status.LoadBalancer = &infrav1.LXCClusterLoadBalancerStatus{}
+		}
+		l.lxcCluster.Status.LoadBalancer.WebhookBackendsHash = ""
+		if event != webhookEventDelete {
+			l.lxcCluster.Status.LoadBalancer.WebhookBackendsHash = hashWebhookBackends(payload.Backends)
+		}
+	}
+	return nil
+}
+
+// webhookBackends returns the control plane backends of the cluster, as sent to the webhook.
+func (l *managerExternal) webhookBackends(ctx context.Context) ([]webhookPayloadServer, error) {
+	config, err := getClusterLoadBalancerConfiguration(ctx, l.lxcClient, l.clusterName, l.clusterNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build load balancer configuration: %w", err)
+	}
+	backends := []webhookPayloadServer{}
+	for _, backend := range config.ControlPlaneBackends {
+		backends = append(backends, webhookPayloadServer{
+			Name:          backend.Name,
+			MachineName:   backend.MachineName,
+			FailureDomain: backend.FailureDomain,
+			Address:       backend.Address,
+			Addresses:     backend.Addresses,
+			Port:          6443,
+		})
+	}
+	return backends, nil
+}
+
+// hashWebhookBackends returns a hash of the control plane backends that are sent to the webhook.
+func hashWebhookBackends(backends []webhookPayloadServer) string {
+	b, _ := json.Marshal(backends)
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// IsSynced implements Manager.
+//
+// If a webhook is configured, the current control plane backends are compared with the hash of the backends that
+// were last sent to the webhook, so that failed or missed notifications are retried.
+func (l *managerExternal) IsSynced(ctx context.Context) (bool, error) {
+	if l.webhook == nil || l.lxcCluster == nil {
+		return true, nil
+	}
+
+	backends, err := l.webhookBackends(ctx)
+	if err != nil {
+		return false, err
+	}
+	return l.webhookBackendsNotified(backends), nil
+}
+
+// webhookBackendsNotified returns true if backends match the control plane backends that were last sent to the webhook.
+func (l *managerExternal) webhookBackendsNotified(backends []webhookPayloadServer) bool {
+	status := l.lxcCluster.Status.LoadBalancer
+	return status != nil && status.WebhookBackendsHash == hashWebhookBackends(backends)
+}
+
+// Backends implements Manager.
+func (l *managerExternal) Backends(ctx context.Context) ([]infrav1.LXCLoadBalancerBackendStatus, error) {
+	return nil, nil
+}
+
 // Inspect implements Manager.
 func (l *managerExternal) Inspect(ctx context.Context) map[string]string {
 	return map[string]string{"address": l.address}
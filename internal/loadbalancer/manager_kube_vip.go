@@ -3,10 +3,17 @@ package loadbalancer
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"path"
+	"slices"
+	"strings"
 
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/shared/api"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
 	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
@@ -24,6 +31,14 @@ type managerKubeVIP struct {
 	kubeconfigPath string
 	manifestPath   string
 	image          string
+
+	bgp                    *infrav1.LXCLoadBalancerKubeVIPBGP
+	services               *infrav1.LXCLoadBalancerKubeVIPServices
+	customManifestTemplate string
+
+	// controlPlaneInitialized is true if the control plane of the cluster is initialized.
+	// Manifests are only pushed to existing control plane instances after the control plane is initialized.
+	controlPlaneInitialized bool
 }
 
 // Create implements Manager.
@@ -44,21 +59,218 @@ func (l *managerKubeVIP) Create(ctx context.Context) ([]string, error) {
 }
 
 // Delete implements Manager.
+//
+// Delete removes the kube-vip manifests from any running control plane instances, such that kube-vip stops announcing
+// the control plane endpoint address (e.g. when switching to a different load balancer type).
 func (l *managerKubeVIP) Delete(ctx context.Context) error {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("address", l.address))
 
-	log.FromContext(ctx).V(1).Info("Using KubeVIP load balancer, nothing to delete")
+	instances, err := l.listControlPlaneInstances(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		for _, filePath := range []string{l.getManifestPath(), l.getCloudProviderManifestPath(), l.servicesConfigMapPath()} {
+			if _, err := getInstanceFileContents(l.lxcClient, instance.Name, filePath); err != nil {
+				continue
+			}
+			log.FromContext(ctx).V(1).WithValues("instance", instance.Name, "path", filePath).Info("Remove kube-vip manifest")
+			if err := l.lxcClient.DeleteInstanceFile(instance.Name, filePath); err != nil {
+				return fmt.Errorf("failed to remove %s on instance %s: %w", filePath, instance.Name, err)
+			}
+		}
+	}
+
 	return nil
 }
 
 // Reconfigure implements Manager.
+//
+// Reconfigure pushes the kube-vip manifests to existing control plane instances, such that changes to the kube-vip
+// configuration are applied without a control plane rollout. The kubelet restarts static pods when their manifest
+// changes. If Services of type LoadBalancer are enabled, the kube-vip cloud provider ConfigMap is also applied.
 func (l *managerKubeVIP) Reconfigure(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, loadBalancerReconfigureTimeout)
+	defer cancel()
+
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("address", l.address))
-	log.FromContext(ctx).V(1).Info("Using KubeVIP load balancer, nothing to reconfigure")
 
+	if !l.controlPlaneInitialized {
+		log.FromContext(ctx).V(1).Info("Control plane is not initialized, nothing to reconfigure")
+		return nil
+	}
+
+	files, isSynced, err := l.syncedControlPlaneFiles()
+	if err != nil {
+		return err
+	}
+
+	instances, err := l.listControlPlaneInstances(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		for filePath, content := range files {
+			if current, err := getInstanceFileContents(l.lxcClient, instance.Name, filePath); err == nil && isSynced(filePath, current) {
+				continue
+			}
+
+			log.FromContext(ctx).V(1).WithValues("instance", instance.Name, "path", filePath).Info("Update kube-vip manifest")
+			if err := l.writeFile(instance.Name, filePath, content); err != nil {
+				return fmt.Errorf("failed to update %s on instance %s: %w", filePath, instance.Name, err)
+			}
+		}
+	}
+
+	if l.services == nil {
+		// Services of type LoadBalancer were disabled, remove the kube-vip cloud provider.
+		for _, instance := range instances {
+			if _, err := getInstanceFileContents(l.lxcClient, instance.Name, l.getCloudProviderManifestPath()); err != nil {
+				continue
+			}
+			log.FromContext(ctx).V(1).WithValues("instance", instance.Name, "path", l.getCloudProviderManifestPath()).Info("Remove kube-vip cloud provider manifest")
+			if err := l.lxcClient.DeleteInstanceFile(instance.Name, l.getCloudProviderManifestPath()); err != nil {
+				return fmt.Errorf("failed to remove %s on instance %s: %w", l.getCloudProviderManifestPath(), instance.Name, err)
+			}
+		}
+		return nil
+	}
+
+	return l.applyServicesConfigMap(ctx, instances)
+}
+
+// IsSynced implements Manager.
+func (l *managerKubeVIP) IsSynced(ctx context.Context) (bool, error) {
+	if !l.controlPlaneInitialized {
+		return true, nil
+	}
+
+	files, isSynced, err := l.syncedControlPlaneFiles()
+	if err != nil {
+		return false, err
+	}
+	if l.services != nil {
+		files[l.servicesConfigMapPath()] = kubeVIPServicesConfigMap(l.services.AddressRange)
+	}
+
+	instances, err := l.listControlPlaneInstances(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, instance := range instances {
+		for filePath, content := range files {
+			current, err := getInstanceFileContents(l.lxcClient, instance.Name, filePath)
+			if err != nil || !(bytes.Equal(current, content) || isSynced(filePath, current)) {
+				return false, nil
+			}
+		}
+		if l.services == nil {
+			if _, err := getInstanceFileContents(l.lxcClient, instance.Name, l.getCloudProviderManifestPath()); err == nil {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// listControlPlaneInstances returns the running control plane instances of the cluster, sorted by name.
+func (l *managerKubeVIP) listControlPlaneInstances(ctx context.Context) ([]api.InstanceFull, error) {
+	instances, err := l.lxcClient.ListInstances(ctx, lxc.WithConfig(map[string]string{
+		"user.cluster-namespace": l.clusterNamespace,
+		"user.cluster-name":      l.clusterName,
+		"user.cluster-role":      "control-plane",
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list control plane instances: %w", err)
+	}
+
+	instances = slices.DeleteFunc(instances, func(i api.InstanceFull) bool { return i.StatusCode != api.Running })
+	slices.SortFunc(instances, func(a, b api.InstanceFull) int { return strings.Compare(a.Name, b.Name) })
+	return instances, nil
+}
+
+// applyServicesConfigMap applies the kube-vip cloud provider ConfigMap on the workload cluster, using kubectl on the
+// first control plane instance where it succeeds. Instances that have not joined the cluster yet fail and are skipped.
+// The applied ConfigMap is then kept on all control plane instances, such that IsSynced can detect changes to the
+// address range.
+func (l *managerKubeVIP) applyServicesConfigMap(ctx context.Context, instances []api.InstanceFull) error {
+	if len(instances) == 0 {
+		return nil
+	}
+
+	configMap := kubeVIPServicesConfigMap(l.services.AddressRange)
+
+	applied := false
+	var errs []error
+	for _, instance := range instances {
+		log.FromContext(ctx).V(1).WithValues("instance", instance.Name, "addressRange", l.services.AddressRange).Info("Apply kube-vip cloud provider ConfigMap")
+		var stderr bytes.Buffer
+		if err := l.lxcClient.RunCommand(ctx, instance.Name, []string{"kubectl", "--kubeconfig", l.getKubeconfigPath(true), "apply", "-f", "-"}, bytes.NewReader(configMap), nil, &stderr); err != nil {
+			errs = append(errs, fmt.Errorf("instance %s: %w: %s", instance.Name, err, strings.TrimSpace(stderr.String())))
+			continue
+		}
+		applied = true
+		break
+	}
+	if !applied {
+		return fmt.Errorf("failed to apply kube-vip cloud provider ConfigMap: %w", errors.Join(errs...))
+	}
+
+	for _, instance := range instances {
+		if err := l.writeFile(instance.Name, l.servicesConfigMapPath(), configMap); err != nil {
+			return fmt.Errorf("failed to update %s on instance %s: %w", l.servicesConfigMapPath(), instance.Name, err)
+		}
+	}
 	return nil
 }
 
+// syncedControlPlaneFiles returns the control plane files for an initialized control plane, and a function that
+// reports whether the current content of a file on a control plane instance is up to date.
+//
+// The manifests of the bootstrap control plane node are rendered before the control plane is initialized, and use
+// the super-admin kubeconfig. They are considered up to date, as rewriting them would restart kube-vip on the node
+// that holds the virtual IP. Any other change to the kube-vip configuration renders them with the admin kubeconfig.
+func (l *managerKubeVIP) syncedControlPlaneFiles() (map[string][]byte, func(filePath string, current []byte) bool, error) {
+	files, err := l.controlPlaneFiles(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	bootstrapFiles, err := l.controlPlaneFiles(false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isSynced := func(filePath string, current []byte) bool {
+		if content, ok := files[filePath]; ok && bytes.Equal(current, content) {
+			return true
+		}
+		content, ok := bootstrapFiles[filePath]
+		return ok && bytes.Equal(current, content)
+	}
+	return files, isSynced, nil
+}
+
+// writeFile writes a file on a control plane instance.
+func (l *managerKubeVIP) writeFile(instanceName string, filePath string, content []byte) error {
+	return l.lxcClient.CreateInstanceFile(instanceName, filePath, incus.InstanceFileArgs{
+		Content:   bytes.NewReader(content),
+		WriteMode: "overwrite",
+		Type:      "file",
+		Mode:      0600,
+		UID:       0,
+		GID:       0,
+	})
+}
+
+// Backends implements Manager.
+func (l *managerKubeVIP) Backends(ctx context.Context) ([]infrav1.LXCLoadBalancerBackendStatus, error) {
+	return nil, nil
+}
+
 // Inspect implements Manager.
 func (l *managerKubeVIP) Inspect(ctx context.Context) map[string]string {
 	result := map[string]string{}
@@ -105,7 +317,22 @@ func (l *managerKubeVIP) getImage() string {
 	if len(l.image) > 0 {
 		return l.image
 	}
-	return "ghcr.io/kube-vip/kube-vip:v0.6.4"
+	return defaultKubeVIPImage
+}
+
+func (l *managerKubeVIP) getCloudProviderImage() string {
+	if l.services != nil && len(l.services.CloudProviderImage) > 0 {
+		return l.services.CloudProviderImage
+	}
+	return "ghcr.io/kube-vip/kube-vip-cloud-provider:v0.0.10"
+}
+
+func (l *managerKubeVIP) getCloudProviderManifestPath() string {
+	return path.Join(path.Dir(l.getManifestPath()), "kube-vip-cloud-provider.yaml")
+}
+
+func (l *managerKubeVIP) servicesConfigMapPath() string {
+	return "/etc/kubernetes/kube-vip-cloud-provider-configmap.yaml"
 }
 
 func (l *managerKubeVIP) getKubeconfigPath(controlPlaneInitialized bool) string {
@@ -120,16 +347,55 @@ func (l *managerKubeVIP) getKubeconfigPath(controlPlaneInitialized bool) string
 }
 
 func (l *managerKubeVIP) ControlPlaneInstanceTemplates(controlPlaneInitialized bool) (map[string]string, error) {
-	if b, err := renderKubeVIPConfiguration(kubeVIPTemplateInput{
+	files, err := l.controlPlaneFiles(controlPlaneInitialized)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]string, len(files))
+	for filePath, content := range files {
+		templates[filePath] = string(content)
+	}
+	return templates, nil
+}
+
+// controlPlaneFiles renders the kube-vip static pod manifests for control plane instances.
+func (l *managerKubeVIP) controlPlaneFiles(controlPlaneInitialized bool) (map[string][]byte, error) {
+	manifestTemplate := DefaultKubeVIPTemplate
+	if l.customManifestTemplate != "" {
+		manifestTemplate = l.customManifestTemplate
+	}
+
+	input := kubeVIPTemplateInput{
 		Address:        l.address,
+		Port:           "6443",
 		Interface:      l.interfaceName,
 		Image:          l.getImage(),
 		KubeconfigPath: l.getKubeconfigPath(controlPlaneInitialized),
-	}); err != nil {
+		BGP:            l.bgp,
+	}
+	if l.bgp != nil {
+		input.BGPPeers = kubeVIPBGPPeers(l.bgp.Peers)
+	}
+
+	b, err := renderKubeVIPConfiguration(input, manifestTemplate)
+	if err != nil {
 		return nil, utils.TerminalError(fmt.Errorf("failed to generate KubeVIP config file: %w", err))
-	} else {
-		return map[string]string{l.getManifestPath(): string(b)}, nil
 	}
+	files := map[string][]byte{l.getManifestPath(): b}
+
+	if l.services != nil {
+		b, err := renderKubeVIPConfiguration(struct{ Image, KubeconfigPath string }{
+			Image:          l.getCloudProviderImage(),
+			KubeconfigPath: l.getKubeconfigPath(controlPlaneInitialized),
+		}, DefaultKubeVIPCloudProviderTemplate)
+		if err != nil {
+			return nil, utils.TerminalError(fmt.Errorf("failed to generate KubeVIP cloud provider config file: %w", err))
+		}
+		files[l.getCloudProviderManifestPath()] = b
+	}
+
+	return files, nil
 }
 
 var _ Manager = &managerKubeVIP{}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"fmt"
 
-	incus "github.com/lxc/incus/v6/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
 
@@ -25,12 +24,20 @@ type managerLXC struct {
 	spec infrav1.LXCLoadBalancerMachineSpec
 
 	customHAProxyConfigTemplate string
+
+	// shared is set if the cluster uses a LXCSharedLoadBalancer. In that case, name is the name of the shared
+	// load balancer instance, and the haproxy configuration is rendered for all member clusters.
+	shared *sharedMember
 }
 
 // Create implements Manager.
 func (l *managerLXC) Create(ctx context.Context) ([]string, error) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", l.name))
 
+	if l.shared != nil {
+		return createSharedMember(ctx, l.lxcClient, l.name, l.clusterName, l.clusterNamespace, l.shared)
+	}
+
 	launchOpts := instances.HaproxyLXCLaunchOptions().
 		WithProfiles(l.spec.Profiles).
 		WithFlavor(l.spec.Flavor).
@@ -59,6 +66,13 @@ func (l *managerLXC) Create(ctx context.Context) ([]string, error) {
 func (l *managerLXC) Delete(ctx context.Context) error {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", l.name))
 
+	if l.shared != nil {
+		if removed, err := deleteSharedMember(ctx, l.lxcClient, l.name, l.clusterName, l.clusterNamespace); err != nil || !removed {
+			return err
+		}
+		return l.Reconfigure(ctx)
+	}
+
 	log.FromContext(ctx).V(1).Info("Deleting load balancer instance")
 	if err := l.lxcClient.WaitForDeleteInstance(ctx, l.name); err != nil {
 		return fmt.Errorf("failed to delete load balancer instance: %w", err)
@@ -74,11 +88,61 @@ func (l *managerLXC) Reconfigure(ctx context.Context) error {
 
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", l.name))
 
-	config, err := getLoadBalancerConfiguration(ctx, l.lxcClient, filterClusterControlPlaneInstances(l.clusterName, l.clusterNamespace))
+	haproxyCfg, err := l.renderConfiguration(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).V(1).Info("Update haproxy config")
+	if err := l.haproxy().reconfigure(ctx, haproxyCfg); err != nil {
+		return fmt.Errorf("failed to update haproxy config: %w", err)
+	}
+
+	return nil
+}
+
+// IsSynced implements Manager.
+func (l *managerLXC) IsSynced(ctx context.Context) (bool, error) {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", l.name))
+
+	haproxyCfg, err := l.renderConfiguration(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to build load balancer configuration: %w", err)
+		return false, err
 	}
 
+	currentCfg, err := getInstanceFileContents(l.lxcClient, l.name, l.haproxy().configPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read current haproxy config: %w", err)
+	}
+
+	return bytes.Equal(haproxyCfg, currentCfg), nil
+}
+
+// haproxy returns the haproxy configuration details of the load balancer instance.
+func (l *managerLXC) haproxy() *haproxyInstance {
+	return &haproxyInstance{
+		lxcClient: l.lxcClient,
+		name:      l.name,
+
+		configPath: "/etc/haproxy/haproxy.cfg",
+		binaryPath: "haproxy",
+		// The haproxy service validates the configuration before reloading, and reports failures.
+		reloadCommands: [][]string{{"systemctl", "reload", "haproxy.service"}},
+	}
+}
+
+// renderConfiguration renders the haproxy configuration for the currently running control plane instances.
+func (l *managerLXC) renderConfiguration(ctx context.Context) ([]byte, error) {
+	if l.shared != nil {
+		return renderSharedConfiguration(ctx, l.lxcClient, l.name, l.shared.name)
+	}
+
+	config, err := getClusterLoadBalancerConfiguration(ctx, l.lxcClient, l.clusterName, l.clusterNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build load balancer configuration: %w", err)
+	}
+	log.FromContext(ctx).V(2).WithValues("servers", config.BackendServers).Info("Rendering haproxy config")
+
 	haproxyTemplate := DefaultHaproxyTemplate
 	if l.customHAProxyConfigTemplate != "" {
 		log.FromContext(ctx).V(1).Info("Using custom HAProxy configuration template")
@@ -87,27 +151,26 @@ func (l *managerLXC) Reconfigure(ctx context.Context) error {
 
 	haproxyCfg, err := renderHaproxyConfiguration(config, haproxyTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to render load balancer config: %w", err)
+		return nil, configValidationError{fmt.Errorf("failed to render load balancer config: %w", err)}
 	}
 
-	log.FromContext(ctx).V(1).WithValues("path", "/etc/haproxy/haproxy.cfg", "servers", config.BackendServers).Info("Write haproxy config")
-	if err := l.lxcClient.CreateInstanceFile(l.name, "/etc/haproxy/haproxy.cfg", incus.InstanceFileArgs{
-		Content:   bytes.NewReader(haproxyCfg),
-		WriteMode: "overwrite",
-		Type:      "file",
-		Mode:      0440,
-		UID:       0,
-		GID:       0,
-	}); err != nil {
-		return fmt.Errorf("failed to write haproxy config: %w", err)
-	}
+	return haproxyCfg, nil
+}
 
-	log.FromContext(ctx).V(1).Info("Reloading haproxy service")
-	if err := l.lxcClient.RunCommand(ctx, l.name, []string{"systemctl", "reload", "haproxy.service"}, nil, nil, nil); err != nil {
-		return fmt.Errorf("failed to reload haproxy service: %w", err)
+// Backends implements Manager.
+func (l *managerLXC) Backends(ctx context.Context) ([]infrav1.LXCLoadBalancerBackendStatus, error) {
+	if l.shared != nil {
+		return getHaproxyBackends(ctx, l.lxcClient, l.name, sharedBackendName(l.clusterName, l.clusterNamespace))
 	}
+	return getHaproxyBackends(ctx, l.lxcClient, l.name, haproxyControlPlaneBackend)
+}
 
-	return nil
+// ControlPlaneEndpointPort implements EndpointPortAssigner.
+func (l *managerLXC) ControlPlaneEndpointPort(ctx context.Context) (int32, error) {
+	if l.shared != nil {
+		return sharedControlPlaneEndpointPort(l.lxcClient, l.name, l.clusterName, l.clusterNamespace)
+	}
+	return 6443, nil
 }
 
 func (l *managerLXC) Inspect(ctx context.Context) map[string]string {
@@ -160,3 +223,4 @@ func (l *managerLXC) ControlPlaneInstanceTemplates(controlPlaneInitialized bool)
 }
 
 var _ Manager = &managerLXC{}
+var _ EndpointPortAssigner = &managerLXC{}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 
-	incus "github.com/lxc/incus/v6/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
 
@@ -26,12 +25,23 @@ type managerOCI struct {
 	spec infrav1.LXCLoadBalancerMachineSpec
 
 	customHAProxyConfigTemplate string
+
+	// registryMirrors are registry mirrors for the load balancer image, see lxc.Image.WithRegistryMirrors.
+	registryMirrors map[string]string
+
+	// shared is set if the cluster uses a LXCSharedLoadBalancer. In that case, name is the name of the shared
+	// load balancer instance, and the haproxy configuration is rendered for all member clusters.
+	shared *sharedMember
 }
 
 // Create implements Manager.
 func (l *managerOCI) Create(ctx context.Context) ([]string, error) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", l.name))
 
+	if l.shared != nil {
+		return createSharedMember(ctx, l.lxcClient, l.name, l.clusterName, l.clusterNamespace, l.shared)
+	}
+
 	if err := l.lxcClient.SupportsInstanceOCI(); err != nil {
 		return nil, fmt.Errorf("server does not support OCI containers: %w", err)
 	}
@@ -49,7 +59,8 @@ func (l *managerOCI) Create(ctx context.Context) ([]string, error) {
 			Server:      l.spec.Image.Server,
 			Alias:       l.spec.Image.Name,
 			Fingerprint: l.spec.Image.Fingerprint,
-		})
+		}).
+		WithRegistryMirrors(l.registryMirrors)
 
 	log.FromContext(ctx).V(1).Info("Launching load balancer instance")
 	addrs, err := l.lxcClient.WithTarget(l.spec.Target).WaitForLaunchInstance(ctx, l.name, launchOpts)
@@ -64,6 +75,13 @@ func (l *managerOCI) Create(ctx context.Context) ([]string, error) {
 func (l *managerOCI) Delete(ctx context.Context) error {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", l.name))
 
+	if l.shared != nil {
+		if removed, err := deleteSharedMember(ctx, l.lxcClient, l.name, l.clusterName, l.clusterNamespace); err != nil || !removed {
+			return err
+		}
+		return l.Reconfigure(ctx)
+	}
+
 	log.FromContext(ctx).V(1).Info("Deleting load balancer instance")
 	if err := l.lxcClient.WaitForDeleteInstance(ctx, l.name); err != nil {
 		return fmt.Errorf("failed to delete load balancer instance: %w", err)
@@ -79,10 +97,66 @@ func (l *managerOCI) Reconfigure(ctx context.Context) error {
 
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", l.name))
 
-	config, err := getLoadBalancerConfiguration(ctx, l.lxcClient, filterClusterControlPlaneInstances(l.clusterName, l.clusterNamespace))
+	haproxyCfg, err := l.renderConfiguration(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).V(1).Info("Update haproxy config")
+	if err := l.haproxy().reconfigure(ctx, haproxyCfg); err != nil {
+		return fmt.Errorf("failed to update haproxy config: %w", err)
+	}
+
+	return nil
+}
+
+// IsSynced implements Manager.
+func (l *managerOCI) IsSynced(ctx context.Context) (bool, error) {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", l.name))
+
+	haproxyCfg, err := l.renderConfiguration(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	currentCfg, err := getInstanceFileContents(l.lxcClient, l.name, l.haproxy().configPath)
 	if err != nil {
-		return fmt.Errorf("failed to build load balancer configuration: %w", err)
+		return false, fmt.Errorf("failed to read current haproxy config: %w", err)
+	}
+
+	return bytes.Equal(haproxyCfg, currentCfg), nil
+}
+
+// haproxy returns the haproxy configuration details of the load balancer instance.
+func (l *managerOCI) haproxy() *haproxyInstance {
+	return &haproxyInstance{
+		lxcClient: l.lxcClient,
+		name:      l.name,
+
+		configPath: "/usr/local/etc/haproxy/haproxy.cfg",
+		// /init is a symlink to the haproxy binary, see instances.HaproxyOCILaunchOptions.
+		binaryPath: "/init",
+		// haproxy runs in master-worker mode, and reloads its configuration on SIGUSR2. Sending the signal does not
+		// report whether the reload succeeded, so the live configuration is validated first. If validation fails,
+		// the signal is not sent and the previous configuration is restored.
+		reloadCommands: [][]string{
+			{"/init", "-c", "-f", "/usr/local/etc/haproxy/haproxy.cfg"},
+			{"kill", "--signal", "SIGUSR2", "1"},
+		},
 	}
+}
+
+// renderConfiguration renders the haproxy configuration for the currently running control plane instances.
+func (l *managerOCI) renderConfiguration(ctx context.Context) ([]byte, error) {
+	if l.shared != nil {
+		return renderSharedConfiguration(ctx, l.lxcClient, l.name, l.shared.name)
+	}
+
+	config, err := getClusterLoadBalancerConfiguration(ctx, l.lxcClient, l.clusterName, l.clusterNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build load balancer configuration: %w", err)
+	}
+	log.FromContext(ctx).V(2).WithValues("servers", config.BackendServers).Info("Rendering haproxy config")
 
 	haproxyCfgTemplate := DefaultHaproxyTemplate
 	if l.customHAProxyConfigTemplate != "" {
@@ -92,27 +166,26 @@ func (l *managerOCI) Reconfigure(ctx context.Context) error {
 
 	haproxyCfg, err := renderHaproxyConfiguration(config, haproxyCfgTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to render load balancer config: %w", err)
+		return nil, configValidationError{fmt.Errorf("failed to render load balancer config: %w", err)}
 	}
 
-	log.FromContext(ctx).V(1).WithValues("path", "/usr/local/etc/haproxy/haproxy.cfg", "servers", config.BackendServers).Info("Write haproxy config")
-	if err := l.lxcClient.CreateInstanceFile(l.name, "/usr/local/etc/haproxy/haproxy.cfg", incus.InstanceFileArgs{
-		Content:   bytes.NewReader(haproxyCfg),
-		WriteMode: "overwrite",
-		Type:      "file",
-		Mode:      0440,
-		UID:       0,
-		GID:       0,
-	}); err != nil {
-		return fmt.Errorf("failed to write haproxy config: %w", err)
-	}
+	return haproxyCfg, nil
+}
 
-	log.FromContext(ctx).V(1).Info("Reloading haproxy configuration")
-	if err := l.lxcClient.RunCommand(ctx, l.name, append([]string{"kill", "--signal", "SIGUSR2"}, "1"), nil, nil, nil); err != nil {
-		return fmt.Errorf("failed to send SIGUSR2 to haproxy pids: %w", err)
-	}
+// Backends implements Manager.
+//
+// Backends are not reported, as the haproxy stats frontend only listens on localhost, and the haproxy OCI image has
+// no tools to retrieve the stats from inside the instance (see haproxyStatsCommand).
+func (l *managerOCI) Backends(ctx context.Context) ([]infrav1.LXCLoadBalancerBackendStatus, error) {
+	return nil, nil
+}
 
-	return nil
+// ControlPlaneEndpointPort implements EndpointPortAssigner.
+func (l *managerOCI) ControlPlaneEndpointPort(ctx context.Context) (int32, error) {
+	if l.shared != nil {
+		return sharedControlPlaneEndpointPort(l.lxcClient, l.name, l.clusterName, l.clusterNamespace)
+	}
+	return 6443, nil
 }
 
 func (l *managerOCI) Inspect(ctx context.Context) map[string]string {
@@ -157,3 +230,4 @@ func (l *managerOCI) ControlPlaneInstanceTemplates(controlPlaneInitialized bool)
 }
 
 var _ Manager = &managerOCI{}
+var _ EndpointPortAssigner = &managerOCI{}
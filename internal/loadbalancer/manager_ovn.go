@@ -3,12 +3,16 @@ package loadbalancer
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/lxc/incus/v6/shared/api"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
 
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
 	"github.com/lxc/cluster-api-provider-incus/internal/utils"
 )
@@ -84,12 +88,64 @@ func (l *managerOVN) Reconfigure(ctx context.Context) error {
 
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("networkName", l.networkName, "listenAddress", l.listenAddress))
 
-	config, err := getLoadBalancerConfiguration(ctx, l.lxcClient, filterClusterControlPlaneInstances(l.clusterName, l.clusterNamespace))
+	lbConfig, err := l.desiredLoadBalancerConfig(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to build load balancer configuration: %w", err)
+		return err
 	}
 
-	log.FromContext(ctx).V(1).WithValues("servers", config.BackendServers).Info("Updating network load balancer")
+	log.FromContext(ctx).V(1).WithValues("backends", lbConfig.Backends).Info("Updating network load balancer")
+
+	if err := l.lxcClient.UpdateNetworkLoadBalancer(l.networkName, l.listenAddress, lbConfig, ""); err != nil {
+		return fmt.Errorf("failed to UpdateNetworkLoadBalancer: %w", err)
+	}
+
+	return nil
+}
+
+// IsSynced implements Manager.
+func (l *managerOVN) IsSynced(ctx context.Context) (bool, error) {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("networkName", l.networkName, "listenAddress", l.listenAddress))
+
+	lbConfig, err := l.desiredLoadBalancerConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	lb, _, err := l.lxcClient.GetNetworkLoadBalancer(l.networkName, l.listenAddress)
+	if err != nil {
+		return false, fmt.Errorf("failed to GetNetworkLoadBalancer: %w", err)
+	}
+
+	if len(lb.Ports) != len(lbConfig.Ports) {
+		return false, nil
+	}
+	for idx, port := range lbConfig.Ports {
+		if lb.Ports[idx].ListenPort != port.ListenPort || !sets.New(lb.Ports[idx].TargetBackend...).Equal(sets.New(port.TargetBackend...)) {
+			return false, nil
+		}
+	}
+
+	backendKey := func(b api.NetworkLoadBalancerBackend) string {
+		return fmt.Sprintf("%s/%s/%s", b.Name, b.TargetAddress, b.TargetPort)
+	}
+	current := sets.New[string]()
+	for _, backend := range lb.Backends {
+		current.Insert(backendKey(backend))
+	}
+	desired := sets.New[string]()
+	for _, backend := range lbConfig.Backends {
+		desired.Insert(backendKey(backend))
+	}
+
+	return current.Equal(desired), nil
+}
+
+// desiredLoadBalancerConfig returns the network load balancer configuration for the currently running control plane instances.
+func (l *managerOVN) desiredLoadBalancerConfig(ctx context.Context) (api.NetworkLoadBalancerPut, error) {
+	config, err := getClusterLoadBalancerConfiguration(ctx, l.lxcClient, l.clusterName, l.clusterNamespace)
+	if err != nil {
+		return api.NetworkLoadBalancerPut{}, fmt.Errorf("failed to build load balancer configuration: %w", err)
+	}
 
 	lbConfig := api.NetworkLoadBalancerPut{
 		Config: map[string]string{
@@ -109,7 +165,8 @@ func (l *managerOVN) Reconfigure(ctx context.Context) error {
 			TargetBackend: make([]string, 0, len(config.BackendServers)),
 		}},
 	}
-	for name, backend := range config.BackendServers {
+	for _, name := range slices.Sorted(maps.Keys(config.BackendServers)) {
+		backend := config.BackendServers[name]
 		lbConfig.Backends = append(lbConfig.Backends, api.NetworkLoadBalancerBackend{
 			Name:          name,
 			TargetPort:    config.BackendControlPlanePort,
@@ -119,11 +176,38 @@ func (l *managerOVN) Reconfigure(ctx context.Context) error {
 		lbConfig.Ports[0].TargetBackend = append(lbConfig.Ports[0].TargetBackend, name)
 	}
 
-	if err := l.lxcClient.UpdateNetworkLoadBalancer(l.networkName, l.listenAddress, lbConfig, ""); err != nil {
-		return fmt.Errorf("failed to UpdateNetworkLoadBalancer: %w", err)
+	return lbConfig, nil
+}
+
+// Backends implements Manager.
+func (l *managerOVN) Backends(ctx context.Context) ([]infrav1.LXCLoadBalancerBackendStatus, error) {
+	state, err := l.lxcClient.GetNetworkLoadBalancerState(l.networkName, l.listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetNetworkLoadBalancerState: %w", err)
 	}
 
-	return nil
+	backends := make([]infrav1.LXCLoadBalancerBackendStatus, 0, len(state.BackendHealth))
+	for _, name := range slices.Sorted(maps.Keys(state.BackendHealth)) {
+		backend := state.BackendHealth[name]
+
+		// backend is healthy only if all ports are online
+		health := infrav1.LoadBalancerBackendUnknown
+		for _, port := range backend.Ports {
+			if port.Status != "online" {
+				health = infrav1.LoadBalancerBackendUnhealthy
+				break
+			}
+			health = infrav1.LoadBalancerBackendHealthy
+		}
+
+		backends = append(backends, infrav1.LXCLoadBalancerBackendStatus{
+			Name:    name,
+			Address: backend.Address,
+			Health:  health,
+		})
+	}
+
+	return backends, nil
 }
 
 // Inspect implements Manager.
@@ -0,0 +1,541 @@
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/shared/api"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/instances"
+	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// The state of a shared load balancer is kept in the configuration of the load balancer instance, such that
+// the LXCCluster controllers of the member clusters do not need access to the LXCSharedLoadBalancer object.
+const (
+	sharedConfigKeyType         = "user.shared-loadbalancer.type"
+	sharedConfigKeyRoutingMode  = "user.shared-loadbalancer.routing"
+	sharedConfigKeyMinPort      = "user.shared-loadbalancer.min-port"
+	sharedConfigKeyMaxPort      = "user.shared-loadbalancer.max-port"
+	sharedConfigKeyMemberPrefix = "user.shared-loadbalancer.member."
+
+	// sharedSNIPort is the frontend port of shared load balancers with the "SNI" routing mode.
+	sharedSNIPort = 6443
+)
+
+// sharedMember is a cluster that uses a shared load balancer instance.
+type sharedMember struct {
+	// name is the name of the LXCSharedLoadBalancer.
+	name string
+	// lbType is the load balancer type of the member cluster, one of "lxc" or "oci".
+	lbType string
+	// endpoint is the control plane endpoint of the member cluster.
+	endpoint clusterv1.APIEndpoint
+}
+
+// sharedState is the state of a shared load balancer, as parsed from the instance configuration.
+type sharedState struct {
+	lbType  string
+	routing infrav1.LXCSharedLoadBalancerRouting
+
+	// members is the list of member clusters, sorted by namespace and name.
+	members []infrav1.LXCSharedLoadBalancerMember
+}
+
+// sharedMemberConfigKey is the instance configuration key of a member cluster.
+// Namespaces cannot contain dots, so the key can be parsed unambiguously.
+func sharedMemberConfigKey(clusterName string, clusterNamespace string) string {
+	return fmt.Sprintf("%s%s.%s", sharedConfigKeyMemberPrefix, clusterNamespace, clusterName)
+}
+
+// parseSharedState parses the shared load balancer state from the instance configuration.
+func parseSharedState(config map[string]string) (*sharedState, error) {
+	state := &sharedState{
+		lbType: config[sharedConfigKeyType],
+		routing: infrav1.LXCSharedLoadBalancerRouting{
+			Mode: infrav1.LXCSharedLoadBalancerRoutingMode(config[sharedConfigKeyRoutingMode]),
+		},
+	}
+	if state.lbType == "" || state.routing.Mode == "" {
+		return nil, fmt.Errorf("instance is not a shared load balancer")
+	}
+
+	for key, value := range map[string]*int32{
+		sharedConfigKeyMinPort: &state.routing.MinPort,
+		sharedConfigKeyMaxPort: &state.routing.MaxPort,
+	} {
+		if config[key] == "" {
+			continue
+		}
+		port, err := strconv.ParseInt(config[key], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s: %w", config[key], key, err)
+		}
+		*value = int32(port)
+	}
+
+	for key, value := range config {
+		namespacedName, ok := strings.CutPrefix(key, sharedConfigKeyMemberPrefix)
+		if !ok {
+			continue
+		}
+		namespace, name, ok := strings.Cut(namespacedName, ".")
+		if !ok {
+			return nil, fmt.Errorf("invalid shared load balancer member key %q", key)
+		}
+
+		member := infrav1.LXCSharedLoadBalancerMember{ClusterName: name, ClusterNamespace: namespace}
+		switch state.routing.Mode {
+		case infrav1.SharedLoadBalancerRoutingSNI:
+			member.Port = sharedSNIPort
+			member.ServerName = value
+		default:
+			port, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port %q for shared load balancer member %s/%s: %w", value, namespace, name, err)
+			}
+			member.Port = int32(port)
+		}
+		state.members = append(state.members, member)
+	}
+	slices.SortFunc(state.members, func(a, b infrav1.LXCSharedLoadBalancerMember) int {
+		if c := strings.Compare(a.ClusterNamespace, b.ClusterNamespace); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ClusterName, b.ClusterName)
+	})
+
+	return state, nil
+}
+
+// member returns the member with the specified cluster name and namespace.
+func (s *sharedState) member(clusterName string, clusterNamespace string) (infrav1.LXCSharedLoadBalancerMember, bool) {
+	for _, member := range s.members {
+		if member.ClusterName == clusterName && member.ClusterNamespace == clusterNamespace {
+			return member, true
+		}
+	}
+	return infrav1.LXCSharedLoadBalancerMember{}, false
+}
+
+// assign returns the instance configuration value for a member cluster. Conflicts with other member clusters
+// are returned as terminal errors, since they require manual intervention.
+func (s *sharedState) assign(clusterName string, clusterNamespace string, endpoint clusterv1.APIEndpoint) (string, error) {
+	others := slices.DeleteFunc(slices.Clone(s.members), func(m infrav1.LXCSharedLoadBalancerMember) bool {
+		return m.ClusterName == clusterName && m.ClusterNamespace == clusterNamespace
+	})
+
+	if s.routing.Mode == infrav1.SharedLoadBalancerRoutingSNI {
+		if endpoint.Host == "" || net.ParseIP(endpoint.Host) != nil {
+			return "", utils.TerminalError(fmt.Errorf("shared load balancer uses SNI routing, the control plane endpoint host must be set to a DNS name"))
+		}
+		if endpoint.Port != 0 && endpoint.Port != sharedSNIPort {
+			return "", utils.TerminalError(fmt.Errorf("shared load balancer uses SNI routing, the control plane endpoint port must be %d", sharedSNIPort))
+		}
+		for _, other := range others {
+			if strings.EqualFold(other.ServerName, endpoint.Host) {
+				return "", utils.TerminalError(fmt.Errorf("server name %q is already used by cluster %s/%s", endpoint.Host, other.ClusterNamespace, other.ClusterName))
+			}
+		}
+		return strings.ToLower(endpoint.Host), nil
+	}
+
+	port := endpoint.Port
+	if port == 0 {
+		if existing, ok := s.member(clusterName, clusterNamespace); ok {
+			return strconv.Itoa(int(existing.Port)), nil
+		}
+
+		for candidate := s.routing.MinPort; candidate <= s.routing.MaxPort; candidate++ {
+			if !slices.ContainsFunc(others, func(m infrav1.LXCSharedLoadBalancerMember) bool { return m.Port == candidate }) {
+				port = candidate
+				break
+			}
+		}
+		if port == 0 {
+			return "", utils.TerminalError(fmt.Errorf("no free ports left in range %d-%d", s.routing.MinPort, s.routing.MaxPort))
+		}
+	}
+
+	for _, other := range others {
+		if other.Port == port {
+			return "", utils.TerminalError(fmt.Errorf("port %d is already used by cluster %s/%s", port, other.ClusterNamespace, other.ClusterName))
+		}
+	}
+	if strconv.Itoa(int(port)) == haproxyStatsPort {
+		return "", utils.TerminalError(fmt.Errorf("port %d is reserved for the haproxy stats frontend", port))
+	}
+	return strconv.Itoa(int(port)), nil
+}
+
+// getSharedInstance retrieves the shared load balancer instance and its state.
+// The returned error is nil and the instance is nil if the instance does not exist.
+func getSharedInstance(lxcClient *lxc.Client, name string) (*api.Instance, *sharedState, string, error) {
+	instance, etag, err := lxcClient.GetInstance(name)
+	if err != nil {
+		if api.StatusErrorCheck(err, http.StatusNotFound) {
+			return nil, nil, "", nil
+		}
+		return nil, nil, "", fmt.Errorf("failed to GetInstance: %w", err)
+	}
+
+	state, err := parseSharedState(instance.Config)
+	if err != nil {
+		return nil, nil, "", utils.TerminalError(fmt.Errorf("instance %q is not a shared load balancer: %w", name, err))
+	}
+
+	return instance, state, etag, nil
+}
+
+// updateSharedMember registers (if value is not empty) or deregisters (if value is empty) a member cluster.
+// The instance ETag is used, so that concurrent updates from the controllers of other member clusters are not lost.
+func updateSharedMember(ctx context.Context, lxcClient *lxc.Client, instance *api.Instance, etag string, key string, value string) error {
+	if instance.Config[key] == value {
+		return nil
+	}
+
+	put := instance.Writable()
+	if value == "" {
+		delete(put.Config, key)
+	} else {
+		put.Config[key] = value
+	}
+
+	return lxcClient.WaitForOperation(ctx, "UpdateInstance", func() (incus.Operation, error) {
+		return lxcClient.UpdateInstance(instance.Name, put, etag)
+	})
+}
+
+// createSharedMember registers a cluster with the shared load balancer instance, and returns the instance addresses.
+func createSharedMember(ctx context.Context, lxcClient *lxc.Client, name string, clusterName string, clusterNamespace string, member *sharedMember) ([]string, error) {
+	instance, state, etag, err := getSharedInstance(lxcClient, name)
+	if err != nil {
+		return nil, err
+	}
+	if instance == nil {
+		return nil, fmt.Errorf("shared load balancer instance %q does not exist yet", name)
+	}
+	if state.lbType != member.lbType {
+		return nil, utils.TerminalError(fmt.Errorf("shared load balancer has type %q, but cluster uses load balancer type %q", state.lbType, member.lbType))
+	}
+
+	value, err := state.assign(clusterName, clusterNamespace, member.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).V(1).WithValues("value", value).Info("Registering cluster with shared load balancer")
+	if err := updateSharedMember(ctx, lxcClient, instance, etag, sharedMemberConfigKey(clusterName, clusterNamespace), value); err != nil {
+		return nil, fmt.Errorf("failed to register cluster with shared load balancer: %w", err)
+	}
+
+	instanceState, _, err := lxcClient.GetInstanceState(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetInstanceState: %w", err)
+	}
+	addrs := lxc.ParseHostAddresses(instanceState)
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("shared load balancer instance %q does not have any addresses yet", name)
+	}
+
+	return addrs, nil
+}
+
+// deleteSharedMember deregisters a cluster from the shared load balancer instance.
+// It returns false if the cluster was not registered, or the shared load balancer instance does not exist.
+func deleteSharedMember(ctx context.Context, lxcClient *lxc.Client, name string, clusterName string, clusterNamespace string) (bool, error) {
+	instance, state, etag, err := getSharedInstance(lxcClient, name)
+	if err != nil {
+		return false, err
+	}
+	if instance == nil {
+		return false, nil
+	}
+	if _, ok := state.member(clusterName, clusterNamespace); !ok {
+		return false, nil
+	}
+
+	log.FromContext(ctx).V(1).Info("Deregistering cluster from shared load balancer")
+	if err := updateSharedMember(ctx, lxcClient, instance, etag, sharedMemberConfigKey(clusterName, clusterNamespace), ""); err != nil {
+		return false, fmt.Errorf("failed to deregister cluster from shared load balancer: %w", err)
+	}
+
+	return true, nil
+}
+
+// sharedControlPlaneEndpointPort returns the frontend port that is assigned to a member cluster.
+func sharedControlPlaneEndpointPort(lxcClient *lxc.Client, name string, clusterName string, clusterNamespace string) (int32, error) {
+	instance, state, _, err := getSharedInstance(lxcClient, name)
+	if err != nil {
+		return 0, err
+	}
+	if instance == nil {
+		return 0, fmt.Errorf("shared load balancer instance %q does not exist", name)
+	}
+	member, ok := state.member(clusterName, clusterNamespace)
+	if !ok {
+		return 0, fmt.Errorf("cluster is not registered with shared load balancer %q", name)
+	}
+	return member.Port, nil
+}
+
+// sharedBackendName is the name of the haproxy backend for a member cluster.
+func sharedBackendName(clusterName string, clusterNamespace string) string {
+	return fmt.Sprintf("%s.%s.%s", haproxyControlPlaneBackend, clusterNamespace, clusterName)
+}
+
+// sharedConfigData is supplied to the shared load balancer config template.
+// This is documented in docs/book/src/reference/haproxy-template-data.md, please keep in sync.
+type sharedConfigData struct {
+	// Version is the version of the template data, see TemplateDataVersion.
+	Version int
+
+	// Name is the name of the LXCSharedLoadBalancer.
+	Name string
+	// RoutingMode is the routing mode of the shared load balancer, one of "Port" or "SNI".
+	RoutingMode string
+
+	// FrontendControlPlanePort is the port the load balancer listens on for the "SNI" routing mode.
+	FrontendControlPlanePort string
+	// BackendControlPlanePort is the port the Kubernetes API listens on for control plane instances.
+	BackendControlPlanePort string
+
+	// Clusters is the list of member clusters, sorted by namespace and name.
+	Clusters []sharedClusterData
+
+	// IPv6 is true if the load balancer should also listen on IPv6 addresses.
+	IPv6 bool
+}
+
+// sharedClusterData defines a member cluster of a shared load balancer.
+type sharedClusterData struct {
+	// ClusterName is the name of the Cluster.
+	ClusterName string
+	// ClusterNamespace is the namespace of the Cluster.
+	ClusterNamespace string
+
+	// BackendName is the name of the haproxy backend for the cluster.
+	BackendName string
+	// FrontendPort is the port the load balancer listens on for the cluster.
+	FrontendPort string
+	// ServerName is the TLS SNI server name of the cluster, for the "SNI" routing mode.
+	ServerName string
+
+	// ControlPlaneBackends is the list of control plane backends, sorted by instance name.
+	ControlPlaneBackends []backendServer
+}
+
+// DefaultSharedHaproxyTemplate is the shared loadbalancer config template.
+const DefaultSharedHaproxyTemplate = `# generated by cluster-api-provider-incus
+global
+  log /dev/log local0
+  log /dev/log local1 notice
+  daemon
+  maxconn 100000
+
+defaults
+  log global
+  mode tcp
+  option dontlognull
+  timeout connect 5000
+  timeout client 50000
+  timeout server 50000
+  default-server init-addr none
+
+frontend stats
+  mode http
+  bind 127.0.0.1:8404
+  stats enable
+  stats uri /stats
+  stats refresh 1s
+  stats admin if TRUE
+{{ if eq .RoutingMode "SNI" }}
+frontend control-plane
+  bind *:{{ .FrontendControlPlanePort }}
+  {{- if .IPv6 }}
+  bind :::{{ .FrontendControlPlanePort }}
+  {{- end }}
+  tcp-request inspect-delay 5s
+  tcp-request content accept if { req_ssl_hello_type 1 }
+  {{- range .Clusters }}
+  use_backend {{ .BackendName }} if { req_ssl_sni -i {{ .ServerName }} }
+  {{- end }}
+{{ else }}
+{{- range .Clusters }}
+frontend control-plane.{{ .ClusterNamespace }}.{{ .ClusterName }}
+  bind *:{{ .FrontendPort }}
+  {{- if $.IPv6 }}
+  bind :::{{ .FrontendPort }}
+  {{- end }}
+  default_backend {{ .BackendName }}
+{{ end }}
+{{- end }}
+{{- range .Clusters }}
+backend {{ .BackendName }}
+  option httpchk GET /healthz
+  {{- range .ControlPlaneBackends }}
+  server {{ .Name }} {{ JoinHostPort .Address $.BackendControlPlanePort }} weight {{ .Weight }} check check-ssl verify none
+  {{- end }}
+{{ end }}`
+
+// getSharedLoadBalancerConfiguration returns the load balancer configuration for all member clusters of a shared load balancer.
+func getSharedLoadBalancerConfiguration(ctx context.Context, lxcClient *lxc.Client, name string, sharedName string) (*sharedConfigData, error) {
+	instance, state, _, err := getSharedInstance(lxcClient, name)
+	if err != nil {
+		return nil, err
+	}
+	if instance == nil {
+		return nil, fmt.Errorf("shared load balancer instance %q does not exist", name)
+	}
+
+	config := &sharedConfigData{
+		Version:                  TemplateDataVersion,
+		Name:                     sharedName,
+		RoutingMode:              string(state.routing.Mode),
+		FrontendControlPlanePort: strconv.Itoa(sharedSNIPort),
+		BackendControlPlanePort:  "6443",
+	}
+	for _, member := range state.members {
+		clusterConfig, err := getClusterLoadBalancerConfiguration(ctx, lxcClient, member.ClusterName, member.ClusterNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build load balancer configuration for cluster %s/%s: %w", member.ClusterNamespace, member.ClusterName, err)
+		}
+
+		config.Clusters = append(config.Clusters, sharedClusterData{
+			ClusterName:          member.ClusterName,
+			ClusterNamespace:     member.ClusterNamespace,
+			BackendName:          sharedBackendName(member.ClusterName, member.ClusterNamespace),
+			FrontendPort:         strconv.Itoa(int(member.Port)),
+			ServerName:           member.ServerName,
+			ControlPlaneBackends: clusterConfig.ControlPlaneBackends,
+		})
+	}
+
+	return config, nil
+}
+
+// renderSharedConfiguration renders the haproxy configuration for all member clusters of a shared load balancer.
+func renderSharedConfiguration(ctx context.Context, lxcClient *lxc.Client, name string, sharedName string) ([]byte, error) {
+	config, err := getSharedLoadBalancerConfiguration(ctx, lxcClient, name, sharedName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build shared load balancer configuration: %w", err)
+	}
+
+	haproxyCfg, err := renderHaproxyConfiguration(config, DefaultSharedHaproxyTemplate)
+	if err != nil {
+		return nil, configValidationError{fmt.Errorf("failed to render shared load balancer config: %w", err)}
+	}
+
+	return haproxyCfg, nil
+}
+
+// SharedManager manages the load balancer instance of a LXCSharedLoadBalancer.
+// Member clusters are registered and deregistered by the Manager of each member cluster.
+type SharedManager struct {
+	lxcClient *lxc.Client
+
+	sharedLB *infrav1.LXCSharedLoadBalancer
+}
+
+// NewSharedManager returns a SharedManager for a LXCSharedLoadBalancer.
+func NewSharedManager(lxcClient *lxc.Client, sharedLB *infrav1.LXCSharedLoadBalancer) *SharedManager {
+	return &SharedManager{lxcClient: lxcClient, sharedLB: sharedLB}
+}
+
+// member returns the Manager that is used to render and apply the shared load balancer configuration.
+func (m *SharedManager) member() Manager {
+	shared := &sharedMember{name: m.sharedLB.Name, lbType: m.sharedLB.Spec.Type}
+	if m.sharedLB.Spec.Type == "oci" {
+		return &managerOCI{lxcClient: m.lxcClient, name: m.sharedLB.GetInstanceName(), spec: m.sharedLB.Spec.InstanceSpec, shared: shared}
+	}
+	return &managerLXC{lxcClient: m.lxcClient, name: m.sharedLB.GetInstanceName(), spec: m.sharedLB.Spec.InstanceSpec, shared: shared}
+}
+
+// Create provisions the shared load balancer instance.
+func (m *SharedManager) Create(ctx context.Context) ([]string, error) {
+	name := m.sharedLB.GetInstanceName()
+	spec := m.sharedLB.Spec.InstanceSpec
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", name))
+
+	var launchOpts *lxc.LaunchOptions
+	switch m.sharedLB.Spec.Type {
+	case "oci":
+		if err := m.lxcClient.SupportsInstanceOCI(); err != nil {
+			return nil, utils.TerminalError(fmt.Errorf("server does not support OCI containers: %w", err))
+		}
+		launchOpts = instances.HaproxyOCILaunchOptions()
+	default:
+		launchOpts = instances.HaproxyLXCLaunchOptions()
+	}
+
+	routing := m.sharedLB.Spec.Routing
+	launchOpts = launchOpts.
+		WithProfiles(spec.Profiles).
+		WithFlavor(spec.Flavor).
+		WithConfig(map[string]string{
+			"user.cluster-role":        "shared-loadbalancer",
+			sharedConfigKeyType:        m.sharedLB.Spec.Type,
+			sharedConfigKeyRoutingMode: string(routing.Mode),
+			sharedConfigKeyMinPort:     strconv.Itoa(int(routing.MinPort)),
+			sharedConfigKeyMaxPort:     strconv.Itoa(int(routing.MaxPort)),
+		}).
+		WithImage(lxc.Image{
+			Protocol:    spec.Image.Protocol,
+			Server:      spec.Image.Server,
+			Alias:       spec.Image.Name,
+			Fingerprint: spec.Image.Fingerprint,
+		})
+
+	log.FromContext(ctx).V(1).Info("Launching shared load balancer instance")
+	addrs, err := m.lxcClient.WithTarget(spec.Target).WaitForLaunchInstance(ctx, name, launchOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shared load balancer instance: %w", err)
+	}
+
+	return addrs, nil
+}
+
+// Delete removes the shared load balancer instance.
+func (m *SharedManager) Delete(ctx context.Context) error {
+	name := m.sharedLB.GetInstanceName()
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("loadbalancer.instance", name))
+
+	log.FromContext(ctx).V(1).Info("Deleting shared load balancer instance")
+	if err := m.lxcClient.WaitForDeleteInstance(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete shared load balancer instance: %w", err)
+	}
+
+	return nil
+}
+
+// Reconfigure updates the shared load balancer configuration based on the control plane instances of all member clusters.
+func (m *SharedManager) Reconfigure(ctx context.Context) error {
+	return m.member().Reconfigure(ctx)
+}
+
+// IsSynced compares the current shared load balancer configuration against the control plane instances of all member clusters.
+func (m *SharedManager) IsSynced(ctx context.Context) (bool, error) {
+	return m.member().IsSynced(ctx)
+}
+
+// Members returns the list of member clusters of the shared load balancer.
+func (m *SharedManager) Members(ctx context.Context) ([]infrav1.LXCSharedLoadBalancerMember, error) {
+	instance, state, _, err := getSharedInstance(m.lxcClient, m.sharedLB.GetInstanceName())
+	if err != nil {
+		return nil, err
+	}
+	if instance == nil {
+		return nil, nil
+	}
+	return state.members, nil
+}
@@ -0,0 +1,176 @@
+package loadbalancer
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestParseSharedState(t *testing.T) {
+	t.Run("Port", func(t *testing.T) {
+		g := NewWithT(t)
+
+		state, err := parseSharedState(map[string]string{
+			"user.cluster-role":                          "shared-loadbalancer",
+			sharedConfigKeyType:                          "lxc",
+			sharedConfigKeyRoutingMode:                   "Port",
+			sharedConfigKeyMinPort:                       "16443",
+			sharedConfigKeyMaxPort:                       "16445",
+			sharedMemberConfigKey("c2", "default"):       "16444",
+			sharedMemberConfigKey("c1.example", "other"): "16443",
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(state.lbType).To(Equal("lxc"))
+		g.Expect(state.routing).To(Equal(infrav1.LXCSharedLoadBalancerRouting{Mode: infrav1.SharedLoadBalancerRoutingPort, MinPort: 16443, MaxPort: 16445}))
+		g.Expect(state.members).To(Equal([]infrav1.LXCSharedLoadBalancerMember{
+			{ClusterName: "c2", ClusterNamespace: "default", Port: 16444},
+			{ClusterName: "c1.example", ClusterNamespace: "other", Port: 16443},
+		}))
+	})
+
+	t.Run("SNI", func(t *testing.T) {
+		g := NewWithT(t)
+
+		state, err := parseSharedState(map[string]string{
+			sharedConfigKeyType:                    "oci",
+			sharedConfigKeyRoutingMode:             "SNI",
+			sharedMemberConfigKey("c1", "default"): "c1.example.com",
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(state.members).To(Equal([]infrav1.LXCSharedLoadBalancerMember{
+			{ClusterName: "c1", ClusterNamespace: "default", Port: 6443, ServerName: "c1.example.com"},
+		}))
+	})
+
+	t.Run("NotShared", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := parseSharedState(map[string]string{"user.cluster-role": "loadbalancer"})
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("InvalidPort", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := parseSharedState(map[string]string{
+			sharedConfigKeyType:                    "lxc",
+			sharedConfigKeyRoutingMode:             "Port",
+			sharedMemberConfigKey("c1", "default"): "invalid",
+		})
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestSharedStateAssign(t *testing.T) {
+	portState := &sharedState{
+		lbType:  "lxc",
+		routing: infrav1.LXCSharedLoadBalancerRouting{Mode: infrav1.SharedLoadBalancerRoutingPort, MinPort: 16443, MaxPort: 16445},
+		members: []infrav1.LXCSharedLoadBalancerMember{
+			{ClusterName: "c1", ClusterNamespace: "default", Port: 16443},
+			{ClusterName: "c3", ClusterNamespace: "default", Port: 16445},
+		},
+	}
+	sniState := &sharedState{
+		lbType:  "lxc",
+		routing: infrav1.LXCSharedLoadBalancerRouting{Mode: infrav1.SharedLoadBalancerRoutingSNI},
+		members: []infrav1.LXCSharedLoadBalancerMember{
+			{ClusterName: "c1", ClusterNamespace: "default", Port: 6443, ServerName: "c1.example.com"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name           string
+		state          *sharedState
+		clusterName    string
+		endpoint       clusterv1.APIEndpoint
+		expectValue    string
+		expectTerminal bool
+	}{
+		{name: "Port/Existing", state: portState, clusterName: "c1", expectValue: "16443"},
+		{name: "Port/ExistingRequested", state: portState, clusterName: "c1", endpoint: clusterv1.APIEndpoint{Host: "10.0.0.1", Port: 16443}, expectValue: "16443"},
+		{name: "Port/LowestFree", state: portState, clusterName: "c2", expectValue: "16444"},
+		{name: "Port/Requested", state: portState, clusterName: "c2", endpoint: clusterv1.APIEndpoint{Port: 6443}, expectValue: "6443"},
+		{name: "Port/Conflict", state: portState, clusterName: "c2", endpoint: clusterv1.APIEndpoint{Port: 16445}, expectTerminal: true},
+		{name: "Port/Stats", state: portState, clusterName: "c2", endpoint: clusterv1.APIEndpoint{Port: 8404}, expectTerminal: true},
+		{name: "Port/Exhausted", state: &sharedState{routing: infrav1.LXCSharedLoadBalancerRouting{Mode: infrav1.SharedLoadBalancerRoutingPort, MinPort: 16443, MaxPort: 16443}, members: portState.members[:1]}, clusterName: "c2", expectTerminal: true},
+		{name: "SNI/Host", state: sniState, clusterName: "c2", endpoint: clusterv1.APIEndpoint{Host: "C2.example.com"}, expectValue: "c2.example.com"},
+		{name: "SNI/Existing", state: sniState, clusterName: "c1", endpoint: clusterv1.APIEndpoint{Host: "c1.example.com", Port: 6443}, expectValue: "c1.example.com"},
+		{name: "SNI/NoHost", state: sniState, clusterName: "c2", expectTerminal: true},
+		{name: "SNI/IPHost", state: sniState, clusterName: "c2", endpoint: clusterv1.APIEndpoint{Host: "10.0.0.1"}, expectTerminal: true},
+		{name: "SNI/Port", state: sniState, clusterName: "c2", endpoint: clusterv1.APIEndpoint{Host: "c2.example.com", Port: 16443}, expectTerminal: true},
+		{name: "SNI/Conflict", state: sniState, clusterName: "c2", endpoint: clusterv1.APIEndpoint{Host: "c1.example.com"}, expectTerminal: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			value, err := tc.state.assign(tc.clusterName, "default", tc.endpoint)
+			if tc.expectTerminal {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(value).To(Equal(tc.expectValue))
+			}
+		})
+	}
+}
+
+func TestRenderSharedHaproxyConfiguration(t *testing.T) {
+	clusters := []sharedClusterData{
+		{
+			ClusterName:      "c1",
+			ClusterNamespace: "default",
+			BackendName:      sharedBackendName("c1", "default"),
+			FrontendPort:     "16443",
+			ServerName:       "c1.example.com",
+			ControlPlaneBackends: []backendServer{
+				{Name: "c1-cp-0", Address: "10.0.0.10", Weight: 100},
+			},
+		},
+		{
+			ClusterName:      "c2",
+			ClusterNamespace: "default",
+			BackendName:      sharedBackendName("c2", "default"),
+			FrontendPort:     "16444",
+			ServerName:       "c2.example.com",
+		},
+	}
+
+	t.Run("Port", func(t *testing.T) {
+		g := NewWithT(t)
+
+		b, err := renderHaproxyConfiguration(&sharedConfigData{RoutingMode: "Port", FrontendControlPlanePort: "6443", BackendControlPlanePort: "6443", Clusters: clusters}, DefaultSharedHaproxyTemplate)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		cfg := string(b)
+		g.Expect(cfg).To(ContainSubstring("frontend control-plane.default.c1\n  bind *:16443\n  default_backend kube-apiservers.default.c1\n"))
+		g.Expect(cfg).To(ContainSubstring("frontend control-plane.default.c2\n  bind *:16444\n  default_backend kube-apiservers.default.c2\n"))
+		g.Expect(cfg).To(ContainSubstring("backend kube-apiservers.default.c1\n  option httpchk GET /healthz\n  server c1-cp-0 10.0.0.10:6443 weight 100 check check-ssl verify none\n"))
+		g.Expect(cfg).To(ContainSubstring("backend kube-apiservers.default.c2\n"))
+		g.Expect(cfg).ToNot(ContainSubstring("req_ssl_sni"))
+	})
+
+	t.Run("SNI", func(t *testing.T) {
+		g := NewWithT(t)
+
+		b, err := renderHaproxyConfiguration(&sharedConfigData{RoutingMode: "SNI", FrontendControlPlanePort: "6443", BackendControlPlanePort: "6443", Clusters: clusters, IPv6: true}, DefaultSharedHaproxyTemplate)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		cfg := string(b)
+		g.Expect(cfg).To(ContainSubstring("frontend control-plane\n  bind *:6443\n  bind :::6443\n"))
+		g.Expect(cfg).To(ContainSubstring("use_backend kube-apiservers.default.c1 if { req_ssl_sni -i c1.example.com }\n"))
+		g.Expect(cfg).To(ContainSubstring("use_backend kube-apiservers.default.c2 if { req_ssl_sni -i c2.example.com }\n"))
+		g.Expect(cfg).ToNot(ContainSubstring("frontend control-plane.default.c1"))
+	})
+
+	t.Run("NoMembers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := renderHaproxyConfiguration(&sharedConfigData{RoutingMode: "Port"}, DefaultSharedHaproxyTemplate)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+}
@@ -3,18 +3,48 @@ package loadbalancer
 import (
 	"context"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
 
 	"github.com/lxc/cluster-api-provider-incus/internal/lxc"
 )
 
-func filterClusterControlPlaneInstances(clusterName string, clusterNamespace string) lxc.ListInstanceFilter {
+func filterClusterInstances(clusterName string, clusterNamespace string) lxc.ListInstanceFilter {
 	return lxc.WithConfig(map[string]string{
 		"user.cluster-name":      clusterName,
 		"user.cluster-namespace": clusterNamespace,
-		"user.cluster-role":      "control-plane",
 	})
 }
 
+// newBackendServer returns the backend server for an instance. It returns false if the instance does not have any addresses.
+func newBackendServer(instance api.InstanceFull) (backendServer, bool) {
+	addresses := lxc.ParseHostAddresses(instance.State)
+	if len(addresses) == 0 {
+		return backendServer{}, false
+	}
+
+	// standalone servers report "none" as the instance location
+	failureDomain := instance.Location
+	if failureDomain == "none" {
+		failureDomain = ""
+	}
+
+	// TODO(neoaggelos): care about the instance weight (e.g. for deleted machines)
+	// TODO(neoaggelos): care about ipv4 vs ipv6 addresses
+	return backendServer{
+		Name:          instance.Name,
+		MachineName:   instance.Config["user.machine-name"],
+		Role:          instance.Config["user.cluster-role"],
+		FailureDomain: failureDomain,
+		Address:       addresses[0],
+		Addresses:     addresses,
+		Weight:        100,
+	}, true
+}
+
 func getLoadBalancerConfiguration(ctx context.Context, lxcClient *lxc.Client, filters ...lxc.ListInstanceFilter) (*configData, error) {
 	instances, err := lxcClient.ListInstances(ctx, filters...)
 	if err != nil {
@@ -22,21 +52,60 @@ func getLoadBalancerConfiguration(ctx context.Context, lxcClient *lxc.Client, fi
 	}
 
 	config := &configData{
+		Version:                  TemplateDataVersion,
+		FrontendControlPlanePort: "6443",
+		BackendControlPlanePort:  "6443",
+		BackendServers:           make(map[string]backendServer, len(instances)),
+	}
+	for _, instance := range instances {
+		if backend, ok := newBackendServer(instance); ok {
+			config.BackendServers[instance.Name] = backend
+			config.ControlPlaneBackends = append(config.ControlPlaneBackends, backend)
+		}
+	}
+	slices.SortFunc(config.ControlPlaneBackends, compareBackendServers)
+
+	return config, nil
+}
+
+// getClusterLoadBalancerConfiguration returns the load balancer configuration for the instances of a cluster.
+func getClusterLoadBalancerConfiguration(ctx context.Context, lxcClient *lxc.Client, clusterName string, clusterNamespace string) (*configData, error) {
+	instances, err := lxcClient.ListInstances(ctx, filterClusterInstances(clusterName, clusterNamespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve cluster instances: %w", err)
+	}
+
+	config := &configData{
+		Version:                  TemplateDataVersion,
+		ClusterName:              clusterName,
+		ClusterNamespace:         clusterNamespace,
 		FrontendControlPlanePort: "6443",
 		BackendControlPlanePort:  "6443",
 		BackendServers:           make(map[string]backendServer, len(instances)),
 	}
 	for _, instance := range instances {
-		if addresses := lxc.ParseHostAddresses(instance.State); len(addresses) > 0 {
-			// TODO(neoaggelos): care about the instance weight (e.g. for deleted machines)
-			// TODO(neoaggelos): care about ipv4 vs ipv6 addresses
-			config.BackendServers[instance.Name] = backendServer{Address: addresses[0], Weight: 100}
+		backend, ok := newBackendServer(instance)
+		if !ok {
+			continue
+		}
+		switch backend.Role {
+		case "control-plane":
+			config.BackendServers[instance.Name] = backend
+			config.ControlPlaneBackends = append(config.ControlPlaneBackends, backend)
+		case "worker":
+			config.WorkerBackends = append(config.WorkerBackends, backend)
 		}
 	}
+	slices.SortFunc(config.ControlPlaneBackends, compareBackendServers)
+	slices.SortFunc(config.WorkerBackends, compareBackendServers)
 
 	return config, nil
 }
 
+func compareBackendServers(a, b backendServer) int {
+	return strings.Compare(a.Name, b.Name)
+}
+
 func GenerateHaproxyLoadBalancerConfiguration(ctx context.Context, lxcClient *lxc.Client, filters ...lxc.ListInstanceFilter) ([]byte, error) {
 	config, err := getLoadBalancerConfiguration(ctx, lxcClient, filters...)
 	if err != nil {
@@ -45,3 +114,18 @@ func GenerateHaproxyLoadBalancerConfiguration(ctx context.Context, lxcClient *lx
 
 	return renderHaproxyConfiguration(config, DefaultHaproxyTemplate)
 }
+
+// getInstanceFileContents reads the contents of a file from an instance.
+func getInstanceFileContents(lxcClient *lxc.Client, instanceName string, path string) ([]byte, error) {
+	reader, _, err := lxcClient.GetInstanceFile(instanceName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetInstanceFile: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return b, nil
+}
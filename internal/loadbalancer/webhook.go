@@ -0,0 +1,192 @@
+package loadbalancer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+const (
+	// WebhookSecretKey is the key of the secret that is used to sign external load balancer webhook requests.
+	WebhookSecretKey = "secret"
+
+	// webhookSignatureHeader is the header with the HMAC-SHA256 signature of the request body.
+	webhookSignatureHeader = "X-Capn-Signature-256"
+	// webhookEventHeader is the header with the webhook event.
+	webhookEventHeader = "X-Capn-Event"
+
+	// webhookPayloadVersion is the version of the webhook request body.
+	webhookPayloadVersion = 1
+)
+
+// webhookEvent is the reason an external load balancer webhook is called.
+type webhookEvent string
+
+const (
+	webhookEventCreate      webhookEvent = "create"
+	webhookEventReconfigure webhookEvent = "reconfigure"
+	webhookEventDelete      webhookEvent = "delete"
+)
+
+// webhookPayload is the JSON body of external load balancer webhook requests.
+// This is documented in docs/book/src/explanation/load-balancer.md, please keep in sync.
+type webhookPayload struct {
+	Version              int                    `json:"version"`
+	Event                webhookEvent           `json:"event"`
+	Timestamp            time.Time              `json:"timestamp"`
+	Cluster              webhookPayloadCluster  `json:"cluster"`
+	ControlPlaneEndpoint webhookPayloadEndpoint `json:"controlPlaneEndpoint"`
+	Backends             []webhookPayloadServer `json:"backends"`
+}
+
+type webhookPayloadCluster struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type webhookPayloadEndpoint struct {
+	Host string `json:"host"`
+	Port int32  `json:"port"`
+}
+
+type webhookPayloadServer struct {
+	Name          string   `json:"name"`
+	MachineName   string   `json:"machineName,omitempty"`
+	FailureDomain string   `json:"failureDomain,omitempty"`
+	Address       string   `json:"address"`
+	Addresses     []string `json:"addresses"`
+	Port          int32    `json:"port"`
+}
+
+// webhookStatusError is returned for unsuccessful webhook responses.
+type webhookStatusError struct {
+	statusCode int
+	body       string
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.statusCode, e.body)
+}
+
+// retriable returns true if the request should be retried.
+func (e *webhookStatusError) retriable() bool {
+	return e.statusCode == http.StatusRequestTimeout || e.statusCode == http.StatusTooManyRequests || e.statusCode >= 500
+}
+
+// webhookNotifier sends signed requests to an external load balancer webhook.
+type webhookNotifier struct {
+	url        string
+	secret     []byte
+	httpClient *http.Client
+
+	maxRetries int
+	// backoff is the delay before the first retry. It is doubled after each retry.
+	backoff time.Duration
+}
+
+// newWebhookNotifier returns a webhookNotifier for the webhook configuration.
+func newWebhookNotifier(spec *infrav1.LXCLoadBalancerExternalWebhook, secret []byte) (*webhookNotifier, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: spec.InsecureSkipVerify} //nolint:gosec // configured by the user
+	if len(spec.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(spec.CABundle) {
+			return nil, fmt.Errorf("failed to parse CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	timeout := 10 * time.Second
+	if spec.TimeoutSeconds > 0 {
+		timeout = time.Duration(spec.TimeoutSeconds) * time.Second
+	}
+
+	return &webhookNotifier{
+		url:    spec.URL,
+		secret: secret,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+		maxRetries: int(spec.MaxRetries),
+		backoff:    time.Second,
+	}, nil
+}
+
+// send sends the payload to the webhook, retrying on network errors and retriable responses.
+func (w *webhookNotifier) send(ctx context.Context, payload *webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		err := w.post(ctx, payload.Event, body)
+		if err == nil {
+			return nil
+		}
+
+		var statusErr *webhookStatusError
+		if errors.As(err, &statusErr) && !statusErr.retriable() {
+			return utils.TerminalError(fmt.Errorf("webhook request failed: %w", err))
+		}
+		if attempt >= w.maxRetries {
+			return fmt.Errorf("webhook request failed after %d attempts: %w", attempt+1, err)
+		}
+
+		log.FromContext(ctx).V(1).WithValues("attempt", attempt+1, "backoff", backoff).Error(err, "Webhook request failed, retrying")
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("webhook request failed: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends a single request to the webhook.
+func (w *webhookNotifier) post(ctx context.Context, event webhookEvent, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, string(event))
+	if len(w.secret) > 0 {
+		req.Header.Set(webhookSignatureHeader, signWebhookPayload(w.secret, body))
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &webhookStatusError{statusCode: resp.StatusCode, body: string(b)}
+	}
+	return nil
+}
+
+// signWebhookPayload returns the HMAC-SHA256 signature of the request body, in the format "sha256=<hex>".
+func signWebhookPayload(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
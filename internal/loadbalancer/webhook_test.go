@@ -0,0 +1,227 @@
+package loadbalancer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	payload := &webhookPayload{
+		Version:              webhookPayloadVersion,
+		Event:                webhookEventReconfigure,
+		Cluster:              webhookPayloadCluster{Name: "c1", Namespace: "default"},
+		ControlPlaneEndpoint: webhookPayloadEndpoint{Host: "10.0.0.100", Port: 6443},
+		Backends: []webhookPayloadServer{
+			{Name: "c1-cp-0", MachineName: "c1-cp-0", Address: "10.0.0.10", Addresses: []string{"10.0.0.10"}, Port: 6443},
+		},
+	}
+
+	// newServer starts a local HTTP stand-in for an external load balancer API, which responds with the
+	// specified status codes in order, and then with 200 OK.
+	newServer := func(t *testing.T, statusCodes ...int) (*httptest.Server, *atomic.Int32) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			g := NewWithT(t)
+
+			idx := int(requests.Add(1)) - 1
+
+			body, err := io.ReadAll(r.Body)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(r.Method).To(Equal(http.MethodPost))
+			g.Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+			g.Expect(r.Header.Get(webhookEventHeader)).To(Equal("reconfigure"))
+			g.Expect(r.Header.Get(webhookSignatureHeader)).To(Equal(signWebhookPayload([]byte("s3cr3t"), body)))
+
+			var received webhookPayload
+			g.Expect(json.Unmarshal(body, &received)).To(Succeed())
+			g.Expect(received.Cluster).To(Equal(payload.Cluster))
+			g.Expect(received.Backends).To(Equal(payload.Backends))
+
+			if idx < len(statusCodes) {
+				w.WriteHeader(statusCodes[idx])
+				_, _ = w.Write([]byte("error"))
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests
+	}
+
+	newNotifier := func(g *WithT, url string, maxRetries int32) *webhookNotifier {
+		notifier, err := newWebhookNotifier(&infrav1.LXCLoadBalancerExternalWebhook{URL: url, MaxRetries: maxRetries}, []byte("s3cr3t"))
+		g.Expect(err).ToNot(HaveOccurred())
+		notifier.backoff = time.Millisecond
+		return notifier
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		g := NewWithT(t)
+		server, requests := newServer(t)
+
+		g.Expect(newNotifier(g, server.URL, 3).send(context.Background(), payload)).To(Succeed())
+		g.Expect(requests.Load()).To(Equal(int32(1)))
+	})
+
+	t.Run("RetryServerError", func(t *testing.T) {
+		g := NewWithT(t)
+		server, requests := newServer(t, http.StatusInternalServerError, http.StatusTooManyRequests)
+
+		g.Expect(newNotifier(g, server.URL, 3).send(context.Background(), payload)).To(Succeed())
+		g.Expect(requests.Load()).To(Equal(int32(3)))
+	})
+
+	t.Run("MaxRetries", func(t *testing.T) {
+		g := NewWithT(t)
+		server, requests := newServer(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+
+		err := newNotifier(g, server.URL, 2).send(context.Background(), payload)
+		g.Expect(err).To(MatchError(ContainSubstring("after 3 attempts")))
+		g.Expect(utils.IsTerminalError(err)).To(BeFalse())
+		g.Expect(requests.Load()).To(Equal(int32(3)))
+	})
+
+	t.Run("NoRetryClientError", func(t *testing.T) {
+		g := NewWithT(t)
+		server, requests := newServer(t, http.StatusBadRequest)
+
+		err := newNotifier(g, server.URL, 3).send(context.Background(), payload)
+		g.Expect(err).To(MatchError(ContainSubstring("unexpected status code 400")))
+		g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+		g.Expect(requests.Load()).To(Equal(int32(1)))
+	})
+
+	t.Run("InvalidCABundle", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := newWebhookNotifier(&infrav1.LXCLoadBalancerExternalWebhook{URL: "https://lb.example.com", CABundle: []byte("invalid")}, nil)
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestExternalWebhookCreated(t *testing.T) {
+	webhook := func(url string) *infrav1.LXCLoadBalancerExternal {
+		return &infrav1.LXCLoadBalancerExternal{Webhook: &infrav1.LXCLoadBalancerExternalWebhook{URL: url}}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		spec   infrav1.LXCClusterLoadBalancer
+		active *infrav1.LXCClusterLoadBalancer
+		expect bool
+	}{
+		{name: "NotCreated", spec: infrav1.LXCClusterLoadBalancer{External: webhook("https://lb.example.com")}},
+		{name: "Created", spec: infrav1.LXCClusterLoadBalancer{External: webhook("https://lb.example.com")}, active: &infrav1.LXCClusterLoadBalancer{External: webhook("https://lb.example.com")}, expect: true},
+		{name: "WebhookChanged", spec: infrav1.LXCClusterLoadBalancer{External: webhook("https://lb2.example.com")}, active: &infrav1.LXCClusterLoadBalancer{External: webhook("https://lb.example.com")}},
+		{name: "WebhookAdded", spec: infrav1.LXCClusterLoadBalancer{External: webhook("https://lb.example.com")}, active: &infrav1.LXCClusterLoadBalancer{External: &infrav1.LXCLoadBalancerExternal{}}},
+		{name: "Handover", spec: infrav1.LXCClusterLoadBalancer{External: webhook("https://lb.example.com")}, active: &infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			lxcCluster := &infrav1.LXCCluster{Spec: infrav1.LXCClusterSpec{LoadBalancer: tc.spec}}
+			if tc.active != nil {
+				lxcCluster.Status.LoadBalancer = &infrav1.LXCClusterLoadBalancerStatus{ActiveSpec: tc.active}
+			}
+			g.Expect(externalWebhookCreated(lxcCluster)).To(Equal(tc.expect))
+		})
+	}
+}
+
+func TestManagerExternalCreate(t *testing.T) {
+	g := NewWithT(t)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	// The webhook is not notified again once the load balancer was created.
+	l := &managerExternal{
+		address:        "10.0.0.100",
+		webhook:        &infrav1.LXCLoadBalancerExternalWebhook{URL: server.URL},
+		webhookCreated: true,
+	}
+	addrs, err := l.Create(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(addrs).To(Equal([]string{"10.0.0.100"}))
+	g.Expect(requests.Load()).To(BeZero())
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	g := NewWithT(t)
+
+	// echo -n '{"event":"create"}' | openssl dgst -sha256 -hmac s3cr3t
+	g.Expect(signWebhookPayload([]byte("s3cr3t"), []byte(`{"event":"create"}`))).To(Equal("sha256=1d478e1afc99c28702c45585d3047484a620af12683497cb9a6f24ff892bb401"))
+}
+
+func TestManagerExternalIsSynced(t *testing.T) {
+	backends := []webhookPayloadServer{{Name: "c1-cp-1", Address: "10.0.0.11", Addresses: []string{"10.0.0.11"}, Port: 6443}}
+
+	t.Run("NoWebhook", func(t *testing.T) {
+		g := NewWithT(t)
+
+		synced, err := (&managerExternal{lxcCluster: &infrav1.LXCCluster{}}).IsSynced(context.Background())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(synced).To(BeTrue())
+	})
+
+	t.Run("Notified", func(t *testing.T) {
+		g := NewWithT(t)
+
+		l := &managerExternal{
+			webhook:    &infrav1.LXCLoadBalancerExternalWebhook{URL: "https://lb.example.com"},
+			lxcCluster: &infrav1.LXCCluster{},
+		}
+		g.Expect(l.webhookBackendsNotified(backends)).To(BeFalse())
+
+		l.lxcCluster.Status.LoadBalancer = &infrav1.LXCClusterLoadBalancerStatus{WebhookBackendsHash: hashWebhookBackends(backends)}
+		g.Expect(l.webhookBackendsNotified(backends)).To(BeTrue())
+
+		// a backend address changed, but the webhook was not notified
+		changed := []webhookPayloadServer{{Name: "c1-cp-1", Address: "10.0.0.12", Addresses: []string{"10.0.0.12"}, Port: 6443}}
+		g.Expect(l.webhookBackendsNotified(changed)).To(BeFalse())
+	})
+
+	t.Run("FailedNotification", func(t *testing.T) {
+		g := NewWithT(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		l := &managerExternal{
+			address:    "10.0.0.100",
+			webhook:    &infrav1.LXCLoadBalancerExternalWebhook{URL: server.URL},
+			lxcCluster: &infrav1.LXCCluster{Status: infrav1.LXCClusterStatus{LoadBalancer: &infrav1.LXCClusterLoadBalancerStatus{WebhookBackendsHash: hashWebhookBackends(backends)}}},
+		}
+		g.Expect(l.Delete(context.Background())).ToNot(Succeed())
+		g.Expect(l.lxcCluster.Status.LoadBalancer.WebhookBackendsHash).To(Equal(hashWebhookBackends(backends)))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		g := NewWithT(t)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		l := &managerExternal{
+			address:    "10.0.0.100",
+			webhook:    &infrav1.LXCLoadBalancerExternalWebhook{URL: server.URL},
+			lxcCluster: &infrav1.LXCCluster{Status: infrav1.LXCClusterStatus{LoadBalancer: &infrav1.LXCClusterLoadBalancerStatus{WebhookBackendsHash: hashWebhookBackends(backends)}}},
+		}
+		g.Expect(l.Delete(context.Background())).To(Succeed())
+		g.Expect(l.lxcCluster.Status.LoadBalancer.WebhookBackendsHash).To(BeEmpty())
+	})
+}
@@ -0,0 +1,128 @@
+package lxc
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// kubernetesArchitectures maps Incus architecture names to Kubernetes (GOARCH) architecture names.
+var kubernetesArchitectures = map[string]string{
+	"x86_64":  "amd64",
+	"i686":    "386",
+	"aarch64": "arm64",
+	"armv7l":  "arm",
+	"armv8l":  "arm",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+	"riscv64": "riscv64",
+}
+
+// KubernetesArchitecture returns the Kubernetes name of an Incus architecture (e.g. "x86_64" -> "amd64").
+// Unknown architectures are returned unchanged.
+func KubernetesArchitecture(arch string) string {
+	if v, ok := kubernetesArchitectures[arch]; ok {
+		return v
+	}
+	return arch
+}
+
+// IncusArchitecture returns the Incus name of a Kubernetes architecture (e.g. "amd64" -> "x86_64").
+// Unknown architectures are returned unchanged.
+func IncusArchitecture(arch string) string {
+	switch arch {
+	case "arm":
+		return "armv7l"
+	case "":
+		return ""
+	}
+	for incusArch, kubernetesArch := range kubernetesArchitectures {
+		if kubernetesArch == arch {
+			return incusArch
+		}
+	}
+	return arch
+}
+
+// GetArchitecture returns the primary architecture of the server, in Incus format (e.g. "x86_64").
+func (c *Client) GetArchitecture() string {
+	if len(c.serverInfo.Environment.Architectures) == 0 {
+		return ""
+	}
+	return c.serverInfo.Environment.Architectures[0]
+}
+
+// ResolveArchitectureTarget returns the target for an instance with the specified architecture (in Incus format).
+//
+// On standalone servers, the target is returned unchanged if the server supports the architecture.
+// On clustered servers, the target is returned unchanged if all online members (of the target cluster group, if any)
+// have the architecture. Otherwise, a random member with the architecture is returned.
+//
+// A terminal error is returned if no server or cluster member has the architecture.
+func (c *Client) ResolveArchitectureTarget(target string, arch string) (string, error) {
+	if arch == "" {
+		return target, nil
+	}
+
+	if c.SupportsInstanceTarget() != nil {
+		if archs := c.SupportsArchitectures(); !slices.Contains(archs, arch) {
+			return "", utils.TerminalError(fmt.Errorf("server does not support architecture %q, supported architectures are %v", arch, archs))
+		}
+		return target, nil
+	}
+
+	members, err := c.GetClusterMembers()
+	if err != nil {
+		return "", fmt.Errorf("failed to list cluster members: %w", err)
+	}
+
+	return selectArchitectureTarget(members, target, arch)
+}
+
+// selectArchitectureTarget implements ResolveArchitectureTarget for clustered servers.
+func selectArchitectureTarget(members []api.ClusterMember, target string, arch string) (string, error) {
+	group, isGroup := strings.CutPrefix(target, "@")
+
+	var candidates, matching, offline []string
+	archs := map[string]struct{}{}
+	for _, member := range members {
+		switch {
+		case isGroup && !slices.Contains(member.Groups, group):
+			continue
+		case !isGroup && target != "" && member.ServerName != target:
+			continue
+		case member.Status != "Online":
+			if member.Architecture == arch {
+				offline = append(offline, member.ServerName)
+			}
+			continue
+		}
+
+		candidates = append(candidates, member.ServerName)
+		archs[member.Architecture] = struct{}{}
+		if member.Architecture == arch {
+			matching = append(matching, member.ServerName)
+		}
+	}
+
+	switch {
+	case len(matching) == 0 && len(offline) > 0:
+		// members may come back online, so this is not a terminal error
+		return "", fmt.Errorf("cluster members %v with architecture %q are not online", offline, arch)
+	case len(matching) == 0 && target != "":
+		return "", utils.TerminalError(fmt.Errorf("no online cluster member in target %q has architecture %q, available architectures are %v", target, arch, slices.Sorted(maps.Keys(archs))))
+	case len(matching) == 0:
+		return "", utils.TerminalError(fmt.Errorf("no online cluster member has architecture %q, available architectures are %v", arch, slices.Sorted(maps.Keys(archs))))
+	case len(matching) == len(candidates):
+		// let the server scheduler pick a member
+		return target, nil
+	default:
+		return matching[rand.IntN(len(matching))], nil
+	}
+}
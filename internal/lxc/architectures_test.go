@@ -0,0 +1,71 @@
+package lxc
+
+import (
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestArchitectureNames(t *testing.T) {
+	g := NewWithT(t)
+
+	for incusArch, kubernetesArch := range map[string]string{"x86_64": "amd64", "aarch64": "arm64", "armv7l": "arm", "s390x": "s390x"} {
+		g.Expect(KubernetesArchitecture(incusArch)).To(Equal(kubernetesArch))
+		g.Expect(IncusArchitecture(kubernetesArch)).To(Equal(incusArch))
+	}
+	g.Expect(IncusArchitecture("")).To(BeEmpty())
+	g.Expect(KubernetesArchitecture("mips")).To(Equal("mips"))
+}
+
+func TestSelectArchitectureTarget(t *testing.T) {
+	member := func(name string, arch string, status string, groups ...string) api.ClusterMember {
+		return api.ClusterMember{
+			ClusterMemberPut: api.ClusterMemberPut{Groups: groups},
+			ServerName:       name,
+			Architecture:     arch,
+			Status:           status,
+		}
+	}
+	members := []api.ClusterMember{
+		member("w01", "x86_64", "Online", "default", "amd"),
+		member("w02", "x86_64", "Online", "default", "amd"),
+		member("w03", "aarch64", "Online", "default", "arm"),
+		member("w04", "aarch64", "Offline", "default", "arm"),
+		member("w05", "riscv64", "Offline", "default", "riscv"),
+	}
+
+	for _, tc := range []struct {
+		name           string
+		target         string
+		arch           string
+		expectOneOf    []string
+		expectError    bool
+		expectTerminal bool
+	}{
+		{name: "PickMatchingMember", arch: "aarch64", expectOneOf: []string{"w03"}},
+		{name: "PickOneOfMatchingMembers", arch: "x86_64", expectOneOf: []string{"w01", "w02"}},
+		{name: "GroupAllMatching", target: "@amd", arch: "x86_64", expectOneOf: []string{"@amd"}},
+		{name: "GroupSomeMatching", target: "@default", arch: "aarch64", expectOneOf: []string{"w03"}},
+		{name: "MemberMatching", target: "w02", arch: "x86_64", expectOneOf: []string{"w02"}},
+		{name: "MemberNotMatching", target: "w02", arch: "aarch64", expectError: true, expectTerminal: true},
+		{name: "GroupNotMatching", target: "@amd", arch: "aarch64", expectError: true, expectTerminal: true},
+		{name: "NoMatchingMember", arch: "s390x", expectError: true, expectTerminal: true},
+		{name: "MatchingMemberOffline", arch: "riscv64", expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			target, err := selectArchitectureTarget(members, tc.target, tc.arch)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(utils.IsTerminalError(err)).To(Equal(tc.expectTerminal))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(target).To(BeElementOf(tc.expectOneOf))
+		})
+	}
+}
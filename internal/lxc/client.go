@@ -16,6 +16,9 @@ type Client struct {
 
 	serverInfo *api.Server
 
+	// config is the configuration used to connect to the server.
+	config Configuration
+
 	progressHandler func(api.Operation)
 }
 
@@ -87,7 +90,7 @@ func New(ctx context.Context, config Configuration, options ...Option) (*Client,
 
 	log.V(5).Info("Initialized client")
 
-	c := &Client{InstanceServer: client, serverInfo: server}
+	c := &Client{InstanceServer: client, serverInfo: server, config: config}
 	for _, o := range options {
 		o(c)
 	}
@@ -104,6 +107,7 @@ func (c *Client) WithTarget(target string) *Client {
 	return &Client{
 		InstanceServer:  c.UseTarget(target),
 		serverInfo:      c.serverInfo,
+		config:          c.config,
 		progressHandler: c.progressHandler,
 	}
 }
@@ -0,0 +1,75 @@
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// GetServerHostName returns the name of the server. For clustered servers, this is the name of the cluster member
+// that the client is connected to.
+func (c *Client) GetServerHostName() string {
+	return c.serverInfo.Environment.ServerName
+}
+
+// ResolveClusterMembers returns the cluster members that match any of the targets. Targets may be cluster member names
+// or cluster groups ("@group"). If targets is empty, all cluster members are returned.
+//
+// A terminal error is returned if the server is not clustered, or if no cluster member matches.
+func (c *Client) ResolveClusterMembers(targets []string) ([]api.ClusterMember, error) {
+	if err := c.SupportsInstanceTarget(); err != nil {
+		return nil, utils.TerminalError(fmt.Errorf("server is not clustered: %w", err))
+	}
+
+	members, err := c.GetClusterMembers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster members: %w", err)
+	}
+
+	if selected := selectClusterMembers(members, targets); len(selected) > 0 {
+		return selected, nil
+	}
+	return nil, utils.TerminalError(fmt.Errorf("no cluster members match targets %v", targets))
+}
+
+// selectClusterMembers implements ResolveClusterMembers for clustered servers.
+func selectClusterMembers(members []api.ClusterMember, targets []string) []api.ClusterMember {
+	if len(targets) == 0 {
+		return members
+	}
+
+	var selected []api.ClusterMember
+	for _, member := range members {
+		if slices.ContainsFunc(targets, func(target string) bool {
+			if group, isGroup := strings.CutPrefix(target, "@"); isGroup {
+				return slices.Contains(member.Groups, group)
+			}
+			return member.ServerName == target
+		}) {
+			selected = append(selected, member)
+		}
+	}
+	return selected
+}
+
+// ForClusterMember returns a client that connects directly to a cluster member, using the same credentials and
+// project. The cluster member address must be reachable.
+func (c *Client) ForClusterMember(ctx context.Context, member api.ClusterMember) (*Client, error) {
+	if !strings.HasPrefix(c.config.ServerURL, "https://") {
+		return nil, utils.TerminalError(fmt.Errorf("cannot connect to cluster member %q, as server %q is not https://", member.ServerName, c.config.ServerURL))
+	}
+
+	config := c.config
+	config.ServerURL = member.URL
+	client, err := New(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to cluster member %q at %q: %w", member.ServerName, member.URL, err)
+	}
+	client.progressHandler = c.progressHandler
+	return client, nil
+}
@@ -0,0 +1,42 @@
+package lxc
+
+import (
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+)
+
+func TestSelectClusterMembers(t *testing.T) {
+	member := func(name string, groups ...string) api.ClusterMember {
+		return api.ClusterMember{ClusterMemberPut: api.ClusterMemberPut{Groups: groups}, ServerName: name}
+	}
+	members := []api.ClusterMember{
+		member("w01", "default", "cpu"),
+		member("w02", "default", "cpu"),
+		member("w03", "default", "gpu"),
+	}
+
+	for _, tc := range []struct {
+		name    string
+		targets []string
+		expect  []string
+	}{
+		{name: "All", expect: []string{"w01", "w02", "w03"}},
+		{name: "Member", targets: []string{"w02"}, expect: []string{"w02"}},
+		{name: "Group", targets: []string{"@cpu"}, expect: []string{"w01", "w02"}},
+		{name: "MemberAndGroup", targets: []string{"@gpu", "w01"}, expect: []string{"w01", "w03"}},
+		{name: "Overlapping", targets: []string{"@cpu", "w01"}, expect: []string{"w01", "w02"}},
+		{name: "NoMatch", targets: []string{"w04", "@arm"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var names []string
+			for _, member := range selectClusterMembers(members, tc.targets) {
+				names = append(names, member.ServerName)
+			}
+			g.Expect(names).To(Equal(tc.expect))
+		})
+	}
+}
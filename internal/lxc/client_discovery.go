@@ -5,6 +5,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/lxc/incus/v6/shared/api"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/lxc/cluster-api-provider-incus/internal/utils"
@@ -20,6 +21,14 @@ func (c *Client) GetServerName() string {
 	}
 }
 
+// GetProject returns the name of the project used by the client.
+func (c *Client) GetProject() string {
+	if info, err := c.GetConnectionInfo(); err == nil && info.Project != "" {
+		return info.Project
+	}
+	return api.ProjectDefaultName
+}
+
 // The built-in Client.HasExtension() from Incus cannot be trusted, as it returns true if we skip the GetServer call.
 // Return the list of extensions that are NOT supported by the server, if any.
 func (c *Client) serverSupportsExtensions(extensions ...string) error {
@@ -37,6 +46,10 @@ func (c *Client) SupportsNetworkLoadBalancers() error {
 	return c.serverSupportsExtensions("network_load_balancer", "network_load_balancer_health_check")
 }
 
+func (c *Client) SupportsNetworkDNSRecords() error {
+	return c.serverSupportsExtensions("network_dns_records")
+}
+
 func (c *Client) SupportsContainerDiskTmpfs() error {
 	return c.serverSupportsExtensions("container_disk_tmpfs")
 }
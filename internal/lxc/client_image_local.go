@@ -0,0 +1,97 @@
+package lxc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lxc/incus/v6/shared/api"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// CheckImage checks that an image is available. In offline mode (see SetOfflineImages), the image is resolved against
+// the local image store of the server. Otherwise, the upstream server of the image is checked (see Image.Check).
+func (c *Client) CheckImage(image Image, instanceType api.InstanceType, architecture string) error {
+	if offlineImages {
+		_, err := c.ResolveLocalImage(image, instanceType, architecture)
+		return err
+	}
+	return image.Check(instanceType, architecture)
+}
+
+// ResolveLocalImage resolves an image against the local image store of the server, without contacting the upstream
+// server of the image. It returns an image with the fingerprint of the local image. If architecture (in Incus format,
+// e.g. "x86_64") is not empty, the local image must also match the architecture.
+//
+// Images are matched (in order) by fingerprint, by local alias, or by the source of cached images (e.g. images that
+// were previously pulled from the simplestreams server or OCI registry). ResolveLocalImage returns a terminal error if
+// no local image matches.
+func (c *Client) ResolveLocalImage(image Image, instanceType api.InstanceType, architecture string) (Image, error) {
+	if instanceType == "" {
+		instanceType = api.InstanceTypeContainer
+	}
+
+	if image.Fingerprint != "" {
+		local, _, err := c.GetImage(image.Fingerprint)
+		if err != nil {
+			if api.StatusErrorCheck(err, http.StatusNotFound) {
+				return Image{}, utils.TerminalError(fmt.Errorf("no image with fingerprint %q found on the server", image.Fingerprint))
+			}
+			return Image{}, fmt.Errorf("failed to retrieve image with fingerprint %q: %w", image.Fingerprint, err)
+		}
+		if !localImageMatches(*local, instanceType, architecture) {
+			return Image{}, utils.TerminalError(fmt.Errorf("image with fingerprint %q (type %q, architecture %q) does not match instance type %q and architecture %q", image.Fingerprint, local.Type, local.Architecture, instanceType, architecture))
+		}
+		return Image{Fingerprint: local.Fingerprint}, nil
+	}
+
+	if image.Alias == "" {
+		return Image{}, utils.TerminalError(fmt.Errorf("image has no alias or fingerprint"))
+	}
+
+	if alias, _, err := c.GetImageAliasType(string(instanceType), image.Alias); err == nil {
+		if local, _, err := c.GetImage(alias.Target); err != nil {
+			return Image{}, fmt.Errorf("failed to retrieve image %q of alias %q: %w", alias.Target, image.Alias, err)
+		} else if localImageMatches(*local, instanceType, architecture) {
+			return Image{Fingerprint: local.Fingerprint}, nil
+		}
+	} else if !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return Image{}, fmt.Errorf("failed to retrieve image alias %q: %w", image.Alias, err)
+	}
+
+	images, err := c.GetImages()
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to list images: %w", err)
+	}
+	if local := selectCachedImage(images, image, instanceType, architecture); local != nil {
+		return Image{Fingerprint: local.Fingerprint}, nil
+	}
+
+	if architecture != "" {
+		return Image{}, utils.TerminalError(fmt.Errorf("no local %s image with alias %q found on the server for architecture %q", instanceType, image.Alias, architecture))
+	}
+	return Image{}, utils.TerminalError(fmt.Errorf("no local %s image with alias %q found on the server", instanceType, image.Alias))
+}
+
+// localImageMatches returns true if the image has the instance type and the architecture (if not empty).
+func localImageMatches(local api.Image, instanceType api.InstanceType, architecture string) bool {
+	return local.Type == string(instanceType) && (architecture == "" || local.Architecture == architecture)
+}
+
+// selectCachedImage returns the most recent image that was downloaded from the alias (and server, if set) of image.
+// It returns nil if no image matches.
+func selectCachedImage(images []api.Image, image Image, instanceType api.InstanceType, architecture string) *api.Image {
+	var selected *api.Image
+	for i, local := range images {
+		source := local.UpdateSource
+		switch {
+		case source == nil || source.Alias != image.Alias:
+		case image.Server != "" && strings.TrimSuffix(source.Server, "/") != strings.TrimSuffix(image.Server, "/"):
+		case !localImageMatches(local, instanceType, architecture):
+		case selected == nil || local.CreatedAt.After(selected.CreatedAt):
+			selected = &images[i]
+		}
+	}
+	return selected
+}
@@ -0,0 +1,70 @@
+package lxc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+)
+
+func TestSelectCachedImage(t *testing.T) {
+	cached := func(fingerprint string, server string, alias string, instanceType string, architecture string, createdAt time.Time) api.Image {
+		return api.Image{
+			Fingerprint:  fingerprint,
+			Type:         instanceType,
+			Architecture: architecture,
+			CreatedAt:    createdAt,
+			UpdateSource: &api.ImageSource{Server: server, Alias: alias, Protocol: Simplestreams},
+		}
+	}
+	now := time.Now()
+	images := []api.Image{
+		{Fingerprint: "local", Type: "container", Architecture: "x86_64"},
+		cached("old", DefaultSimplestreamsServer, "kubeadm/v1.33.0", "container", "x86_64", now.Add(-time.Hour)),
+		cached("new", DefaultSimplestreamsServer+"/", "kubeadm/v1.33.0", "container", "x86_64", now),
+		cached("arm", DefaultSimplestreamsServer, "kubeadm/v1.33.0", "container", "aarch64", now),
+		cached("vm", DefaultSimplestreamsServer, "kubeadm/v1.33.0", "virtual-machine", "x86_64", now),
+		cached("stg", DefaultStagingSimplestreamsServer, "kubeadm/v1.34.0", "container", "x86_64", now),
+	}
+
+	for _, tc := range []struct {
+		name         string
+		image        Image
+		instanceType api.InstanceType
+		architecture string
+		expect       string
+	}{
+		{name: "Latest", image: CapnImage("kubeadm/v1.33.0"), instanceType: api.InstanceTypeContainer, expect: "new"},
+		{name: "Architecture", image: CapnImage("kubeadm/v1.33.0"), instanceType: api.InstanceTypeContainer, architecture: "aarch64", expect: "arm"},
+		{name: "VirtualMachine", image: CapnImage("kubeadm/v1.33.0"), instanceType: api.InstanceTypeVM, expect: "vm"},
+		{name: "NoServer", image: Image{Alias: "kubeadm/v1.34.0"}, instanceType: api.InstanceTypeContainer, expect: "stg"},
+		{name: "OtherServer", image: CapnImage("kubeadm/v1.34.0"), instanceType: api.InstanceTypeContainer},
+		{name: "NoArchitecture", image: CapnImage("kubeadm/v1.33.0"), instanceType: api.InstanceTypeVM, architecture: "aarch64"},
+		{name: "NotFound", image: CapnImage("kubeadm/v1.35.0"), instanceType: api.InstanceTypeContainer},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			selected := selectCachedImage(images, tc.image, tc.instanceType, tc.architecture)
+			if tc.expect == "" {
+				g.Expect(selected).To(BeNil())
+			} else {
+				g.Expect(selected).ToNot(BeNil())
+				g.Expect(selected.Fingerprint).To(Equal(tc.expect))
+			}
+		})
+	}
+}
+
+func TestSetDefaultSimplestreamsServer(t *testing.T) {
+	g := NewWithT(t)
+	defer func() { defaultSimplestreamsServer = DefaultSimplestreamsServer }()
+
+	g.Expect(SetDefaultSimplestreamsServer("images.example.com")).ToNot(Succeed())
+	g.Expect(GetDefaultSimplestreamsServer()).To(Equal(DefaultSimplestreamsServer))
+
+	g.Expect(SetDefaultSimplestreamsServer("http://10.0.0.10:8080/")).To(Succeed())
+	g.Expect(GetDefaultSimplestreamsServer()).To(Equal("http://10.0.0.10:8080"))
+	g.Expect(CapnImage("haproxy")).To(Equal(Image{Protocol: Simplestreams, Server: "http://10.0.0.10:8080", Alias: "haproxy"}))
+}
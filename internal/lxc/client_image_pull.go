@@ -11,18 +11,47 @@ import (
 
 // PullImage pulls a remote image.
 func (c *Client) PullImage(ctx context.Context, imageFamily ImageFamily) error {
+	_, err := c.PullImageForType(ctx, imageFamily, "")
+	return err
+}
+
+// PullImageForType pulls a remote image for instances of the specified type, and returns the pulled image. If the
+// image is already available on the server, it is not downloaded again.
+//
+// On clustered servers, the image is pulled on the cluster member that the client is connected to (see
+// ForClusterMember).
+func (c *Client) PullImageForType(ctx context.Context, imageFamily ImageFamily, instanceType api.InstanceType) (*api.Image, error) {
 	image, err := imageFamily.For(c.GetServerName())
 	if err != nil {
-		return fmt.Errorf("unsupported image: %w", err)
+		return nil, fmt.Errorf("unsupported image: %w", err)
 	}
 
-	log.FromContext(ctx).V(4).Info("Pulling image", "server", image.Server, "name", image.Alias, "protocol", image.Protocol)
-	return c.WaitForOperation(ctx, "PullImage", func() (incus.Operation, error) {
-		return c.CreateImage(api.ImagesPost{
+	source := image.ImageSource()
+	if image.Protocol != OCI {
+		source.ImageType = string(instanceType)
+	}
+
+	log.FromContext(ctx).V(4).Info("Pulling image", "server", image.Server, "name", image.Alias, "protocol", image.Protocol, "type", instanceType)
+	var op incus.Operation
+	if err := c.WaitForOperation(ctx, "PullImage", func() (incus.Operation, error) {
+		op, err = c.CreateImage(api.ImagesPost{
 			Source: &api.ImagesPostSource{
 				Type:        "image",
-				ImageSource: image.ImageSource(),
+				ImageSource: source,
 			},
 		}, nil)
-	})
+		return op, err
+	}); err != nil {
+		return nil, err
+	}
+
+	fingerprint, ok := op.Get().Metadata["fingerprint"].(string)
+	if !ok || fingerprint == "" {
+		return nil, fmt.Errorf("PullImage operation did not return the image fingerprint")
+	}
+	pulled, _, err := c.GetImage(fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pulled image %q: %w", fingerprint, err)
+	}
+	return pulled, nil
 }
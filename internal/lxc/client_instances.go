@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"maps"
+	"net/http"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -35,12 +36,20 @@ func (c *Client) WaitForLaunchInstance(ctx context.Context, name string, opts *L
 		if err := opts.complete(c.GetServerName()); err != nil {
 			return nil, fmt.Errorf("failed to complete launch options: %w", err)
 		}
+		if offlineImages {
+			image, err := c.ResolveLocalImage(opts.image.(Image), opts.instanceType, opts.architecture)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve local image: %w", err)
+			}
+			opts.image = image
+		}
 
 		log.FromContext(ctx).V(2).WithValues(
 			"lxc.instance.name", name,
 			"lxc.instance.image", opts.image,
 			"lxc.instance.type", opts.instanceType,
 			"lxc.instance.flavor", opts.flavor,
+			"lxc.instance.architecture", opts.architecture,
 			"lxc.instance.profiles", opts.profiles,
 			"lxc.instance.devices", slices.Collect(maps.Keys(opts.devices)),
 		).Info("Creating instance")
@@ -51,16 +60,36 @@ func (c *Client) WaitForLaunchInstance(ctx context.Context, name string, opts *L
 			Type:         opts.instanceType,
 			InstanceType: opts.flavor,
 			InstancePut: api.InstancePut{
-				Config:   opts.config,
-				Devices:  opts.devices,
-				Profiles: opts.profiles,
+				Architecture: opts.architecture,
+				Config:       opts.config,
+				Devices:      opts.devices,
+				Profiles:     opts.profiles,
 			},
 		})
 	}); err != nil {
 		return nil, err
 	}
 
-	if templates := opts.instanceTemplates; len(templates) > 0 {
+	templates, createFiles := opts.instanceTemplates, opts.createFiles
+	if opts.bootstrapReplacer != nil {
+		instance, etag, err := c.GetInstance(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to GetInstance: %w", err)
+		}
+		replacer := opts.bootstrapReplacer(instance)
+
+		if put, changed := replaceInstanceConfig(instance.Writable(), opts.bootstrapConfigKeys, replacer); changed {
+			if err := c.WaitForOperation(ctx, "UpdateInstance", func() (incus.Operation, error) {
+				return c.UpdateInstance(name, put, etag)
+			}); err != nil {
+				return nil, fmt.Errorf("failed to update instance config: %w", err)
+			}
+		}
+
+		templates, createFiles = replaceInstanceFiles(opts.instanceTemplates, opts.createFiles, opts.bootstrapPaths, replacer)
+	}
+
+	if len(templates) > 0 {
 		metadata, _, err := c.GetInstanceMetadata(name)
 		if err != nil {
 			return nil, fmt.Errorf("failed to GetInstanceMetadata: %w", err)
@@ -87,7 +116,7 @@ func (c *Client) WaitForLaunchInstance(ctx context.Context, name string, opts *L
 		}
 	}
 
-	for _, file := range opts.createFiles {
+	for _, file := range createFiles {
 		if err := c.CreateInstanceFile(name, file.path(), file.args()); err != nil {
 			return nil, fmt.Errorf("failed to %s: %w", file.action(), err)
 		}
@@ -131,6 +160,39 @@ func (c *Client) WaitForLaunchInstance(ctx context.Context, name string, opts *L
 	return c.WaitForStartInstance(ctx, name)
 }
 
+// replaceInstanceConfig applies text replacements to the values of the specified config keys of an instance. It
+// returns true if any value was changed.
+func replaceInstanceConfig(put api.InstancePut, keys []string, replacer *strings.Replacer) (api.InstancePut, bool) {
+	config := maps.Clone(put.Config)
+	changed := false
+	for _, key := range keys {
+		if value, ok := config[key]; ok {
+			config[key] = replacer.Replace(value)
+			changed = changed || config[key] != value
+		}
+	}
+	put.Config = config
+	return put, changed
+}
+
+// replaceInstanceFiles applies text replacements to the instance templates and created files in the specified paths.
+// The input map and slice are not modified.
+func replaceInstanceFiles(templates map[string]string, createFiles []instanceFileCreator, paths []string, replacer *strings.Replacer) (map[string]string, []instanceFileCreator) {
+	newTemplates := maps.Clone(templates)
+	newCreateFiles := slices.Clone(createFiles)
+	for _, path := range paths {
+		if contents, ok := newTemplates[path]; ok {
+			newTemplates[path] = replacer.Replace(contents)
+		}
+		for i, file := range newCreateFiles {
+			if f, ok := file.(*createFile); ok && f.Path == path {
+				newCreateFiles[i] = &createFile{Path: f.Path, Contents: replacer.Replace(f.Contents)}
+			}
+		}
+	}
+	return newTemplates, newCreateFiles
+}
+
 // WaitForStartInstance starts an instance, and waits for at least one valid host address.
 func (c *Client) WaitForStartInstance(ctx context.Context, name string) ([]string, error) {
 	ctx, cancel := context.WithTimeout(ctx, instanceStartTimeout)
@@ -176,6 +238,21 @@ func (c *Client) WaitForStopInstance(ctx context.Context, name string) error {
 	})
 }
 
+// InstanceExists returns true if an instance exists, or is being created.
+func (c *Client) InstanceExists(ctx context.Context, name string) (bool, error) {
+	if _, _, err := c.GetInstance(name); err == nil {
+		return true, nil
+	} else if !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return false, fmt.Errorf("failed to GetInstance: %w", err)
+	}
+
+	op, err := c.tryFindInstanceCreateOperation(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return op != nil, nil
+}
+
 // WaitForDeleteInstance stops and removes an instance.
 // WaitForDeleteInstance will not fail if the instance does not exist.
 func (c *Client) WaitForDeleteInstance(ctx context.Context, name string) error {
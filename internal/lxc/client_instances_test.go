@@ -0,0 +1,75 @@
+package lxc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lxc/incus/v6/shared/api"
+	. "github.com/onsi/gomega"
+)
+
+func TestReplaceInstanceConfig(t *testing.T) {
+	replacer := strings.NewReplacer("lxc:///{{ v1.local_hostname }}", "lxc://proj/w01/c1")
+
+	t.Run("Changed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		put, changed := replaceInstanceConfig(api.InstancePut{
+			Profiles: []string{"default"},
+			Config: map[string]string{
+				"cloud-init.user-data": "provider-id: lxc:///{{ v1.local_hostname }}",
+				"user.cluster-name":    "c1",
+				"user.note":            "lxc:///{{ v1.local_hostname }}",
+				"raw.qemu":             `-fw_cfg 'name=opt/com.coreos/config,string={"provider-id":"lxc:///{{ v1.local_hostname }}"}'`,
+			},
+		}, []string{"cloud-init.user-data", "raw.qemu"}, replacer)
+		g.Expect(changed).To(BeTrue())
+		g.Expect(put).To(Equal(api.InstancePut{
+			Profiles: []string{"default"},
+			Config: map[string]string{
+				"cloud-init.user-data": "provider-id: lxc://proj/w01/c1",
+				"user.cluster-name":    "c1",
+				"user.note":            "lxc:///{{ v1.local_hostname }}",
+				"raw.qemu":             `-fw_cfg 'name=opt/com.coreos/config,string={"provider-id":"lxc://proj/w01/c1"}'`,
+			},
+		}))
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, changed := replaceInstanceConfig(api.InstancePut{Config: map[string]string{"user.note": "lxc:///{{ v1.local_hostname }}"}}, []string{"cloud-init.user-data"}, replacer)
+		g.Expect(changed).To(BeFalse())
+	})
+}
+
+func TestReplaceInstanceFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	replacer := strings.NewReplacer("lxc:///{{ v1.local_hostname }}", "lxc://proj/w01/c1")
+
+	templates := map[string]string{
+		"/var/lib/cloud/seed/nocloud/user-data": "provider-id: lxc:///{{ v1.local_hostname }}",
+		"/etc/kube-vip.yaml":                    "lxc:///{{ v1.local_hostname }}",
+	}
+	createFiles := []instanceFileCreator{
+		&createDirectory{Path: "/usr/share/oem"},
+		&createFile{Path: "/usr/share/oem/config.ign", Contents: `{"provider-id": "lxc:///{{ v1.local_hostname }}"}`},
+		&createFile{Path: "/etc/other", Contents: "lxc:///{{ v1.local_hostname }}"},
+	}
+
+	newTemplates, newCreateFiles := replaceInstanceFiles(templates, createFiles, []string{"/var/lib/cloud/seed/nocloud/user-data", "/usr/share/oem/config.ign"}, replacer)
+	g.Expect(newTemplates).To(Equal(map[string]string{
+		"/var/lib/cloud/seed/nocloud/user-data": "provider-id: lxc://proj/w01/c1",
+		"/etc/kube-vip.yaml":                    "lxc:///{{ v1.local_hostname }}",
+	}))
+	g.Expect(newCreateFiles).To(Equal([]instanceFileCreator{
+		&createDirectory{Path: "/usr/share/oem"},
+		&createFile{Path: "/usr/share/oem/config.ign", Contents: `{"provider-id": "lxc://proj/w01/c1"}`},
+		&createFile{Path: "/etc/other", Contents: "lxc:///{{ v1.local_hostname }}"},
+	}))
+
+	// inputs are not modified
+	g.Expect(templates["/var/lib/cloud/seed/nocloud/user-data"]).To(Equal("provider-id: lxc:///{{ v1.local_hostname }}"))
+	g.Expect(createFiles[1]).To(Equal(&createFile{Path: "/usr/share/oem/config.ign", Contents: `{"provider-id": "lxc:///{{ v1.local_hostname }}"}`}))
+}
@@ -1,12 +1,16 @@
 package lxc
 
 import (
+	"bytes"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	incus "github.com/lxc/incus/v6/client"
 	"github.com/lxc/incus/v6/shared/api"
 
@@ -64,16 +68,25 @@ func (i Image) InstanceSource() api.InstanceSource {
 }
 
 // Check runs a local check that the image is available on the upstream server.
+// If architecture (in Incus format, e.g. "x86_64") is not empty, it also checks that the image is available for
+// that architecture.
 //
 // For simplestreams images, connects to the simplestreams server and validates image alias exists.
-// For OCI images, validates that the HEAD request succeeds.
-func (i *Image) Check(instanceType api.InstanceType) error {
+// For OCI images, validates that the HEAD request succeeds. For multi-platform OCI images, validates that the image
+// index includes the architecture.
+func (i *Image) Check(instanceType api.InstanceType, architecture string) error {
 	switch i.Protocol {
 	case Simplestreams:
 		if client, err := incus.ConnectSimpleStreams(i.Server, &incus.ConnectionArgs{HTTPClient: &http.Client{Timeout: 10 * time.Second}}); err != nil {
 			return fmt.Errorf("failed to connect to simplestreams server %q: %w", i.Server, err)
 		} else if _, _, err := client.GetImageAliasType(string(instanceType), i.Alias); err != nil {
 			return utils.TerminalError(fmt.Errorf("no image with alias %q found on the simplestreams server %q: %w", i.Alias, i.Server, err))
+		} else if architecture != "" {
+			if entries, err := client.GetImageAliasArchitectures(string(instanceType), i.Alias); err != nil {
+				return fmt.Errorf("failed to retrieve architectures of image with alias %q from simplestreams server %q: %w", i.Alias, i.Server, err)
+			} else if _, ok := entries[architecture]; !ok {
+				return utils.TerminalError(fmt.Errorf("image with alias %q on the simplestreams server %q is not available for architecture %q, available architectures are %v", i.Alias, i.Server, architecture, slices.Sorted(maps.Keys(entries))))
+			}
 		}
 	case OCI:
 		var opts []crane.Option
@@ -98,8 +111,43 @@ func (i *Image) Check(instanceType api.InstanceType) error {
 			}
 			return err
 		}
+
+		if architecture != "" {
+			if err := checkOCIImageArchitecture(imageRef, KubernetesArchitecture(architecture), opts...); err != nil {
+				return err
+			}
+		}
 	default:
 		return fmt.Errorf("check not supported for protocol %q", i.Protocol)
 	}
 	return nil
 }
+
+// checkOCIImageArchitecture checks that a multi-platform OCI image includes a Linux image for the architecture
+// (in Kubernetes format, e.g. "amd64"). Single-platform images are not checked, as their manifest does not
+// include the platform.
+func checkOCIImageArchitecture(imageRef string, architecture string, opts ...crane.Option) error {
+	b, err := crane.Manifest(imageRef, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve manifest of image %q: %w", imageRef, err)
+	}
+	index, err := v1.ParseIndexManifest(bytes.NewReader(b))
+	if err != nil || len(index.Manifests) == 0 {
+		return nil
+	}
+
+	var platforms []string
+	for _, manifest := range index.Manifests {
+		if manifest.Platform == nil || manifest.Platform.OS == "unknown" {
+			continue
+		}
+		if manifest.Platform.OS == "linux" && manifest.Platform.Architecture == architecture {
+			return nil
+		}
+		platforms = append(platforms, fmt.Sprintf("%s/%s", manifest.Platform.OS, manifest.Platform.Architecture))
+	}
+	if len(platforms) == 0 {
+		return nil
+	}
+	return utils.TerminalError(fmt.Errorf("image %q is not available for architecture %q, available platforms are %v", imageRef, architecture, platforms))
+}
@@ -0,0 +1,42 @@
+package lxc
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// defaultSimplestreamsServer is the simplestreams server for "capi:" images, as well as the default kubeadm and
+	// haproxy images.
+	defaultSimplestreamsServer = DefaultSimplestreamsServer
+
+	// offlineImages is true if images are resolved against the local image store of the server.
+	offlineImages bool
+)
+
+// SetDefaultSimplestreamsServer sets the simplestreams server for "capi:" images, as well as the default kubeadm and
+// haproxy images. It must be called before any images are resolved, typically on manager startup.
+func SetDefaultSimplestreamsServer(server string) error {
+	if !strings.HasPrefix(server, "https://") && !strings.HasPrefix(server, "http://") {
+		return fmt.Errorf("simplestreams server %q is not an HTTP or HTTPS server", server)
+	}
+	defaultSimplestreamsServer = strings.TrimSuffix(server, "/")
+	return nil
+}
+
+// GetDefaultSimplestreamsServer returns the simplestreams server for "capi:" images.
+func GetDefaultSimplestreamsServer() string {
+	return defaultSimplestreamsServer
+}
+
+// SetOfflineImages configures whether images are resolved against the local image store of the server (aliases,
+// fingerprints and cached images), without contacting simplestreams servers or OCI registries. It must be called
+// before any images are resolved, typically on manager startup.
+func SetOfflineImages(offline bool) {
+	offlineImages = offline
+}
+
+// IsOfflineImages returns true if images are resolved against the local image store of the server.
+func IsOfflineImages() bool {
+	return offlineImages
+}
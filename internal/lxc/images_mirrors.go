@@ -0,0 +1,32 @@
+package lxc
+
+import (
+	"strings"
+)
+
+// DefaultRegistryMirror is the registry mirrors key that matches all registries without their own mirror.
+const DefaultRegistryMirror = "_default"
+
+// WithRegistryMirrors returns the image with the server replaced by a registry mirror, if the image is an OCI image.
+// Mirrors map registry hosts (e.g. "docker.io") to mirror endpoints (e.g. "https://mirror.example.com:5000").
+func (i Image) WithRegistryMirrors(mirrors map[string]string) Image {
+	if i.Protocol != OCI || len(mirrors) == 0 {
+		return i
+	}
+
+	registry := i.Server
+	for _, prefix := range []string{"https://", "http://"} {
+		registry = strings.TrimPrefix(registry, prefix)
+	}
+	registry = strings.TrimSuffix(registry, "/")
+	if registry == "index.docker.io" || registry == "registry-1.docker.io" {
+		registry = "docker.io"
+	}
+
+	if endpoint, ok := mirrors[registry]; ok {
+		i.Server = endpoint
+	} else if endpoint, ok := mirrors[DefaultRegistryMirror]; ok {
+		i.Server = endpoint
+	}
+	return i
+}
@@ -0,0 +1,38 @@
+package lxc
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestImageWithRegistryMirrors(t *testing.T) {
+	mirrors := map[string]string{
+		"docker.io": "https://docker-mirror.example.com",
+		"ghcr.io":   "http://10.0.0.10:5000",
+	}
+
+	for _, tc := range []struct {
+		name    string
+		image   Image
+		mirrors map[string]string
+		expect  string
+	}{
+		{name: "DockerHub", image: Image{Protocol: OCI, Server: "https://docker.io", Alias: "kindest/haproxy:v1"}, mirrors: mirrors, expect: "https://docker-mirror.example.com"},
+		{name: "DockerHubIndex", image: Image{Protocol: OCI, Server: "https://index.docker.io/", Alias: "library/nginx"}, mirrors: mirrors, expect: "https://docker-mirror.example.com"},
+		{name: "Registry", image: Image{Protocol: OCI, Server: "https://ghcr.io", Alias: "lxc/image"}, mirrors: mirrors, expect: "http://10.0.0.10:5000"},
+		{name: "NoMirror", image: Image{Protocol: OCI, Server: "https://quay.io", Alias: "image"}, mirrors: mirrors, expect: "https://quay.io"},
+		{name: "DefaultMirror", image: Image{Protocol: OCI, Server: "https://quay.io", Alias: "image"}, mirrors: map[string]string{DefaultRegistryMirror: "https://mirror.example.com"}, expect: "https://mirror.example.com"},
+		{name: "Simplestreams", image: Image{Protocol: Simplestreams, Server: "https://images.linuxcontainers.org", Alias: "ubuntu/24.04"}, mirrors: map[string]string{DefaultRegistryMirror: "https://mirror.example.com"}, expect: "https://images.linuxcontainers.org"},
+		{name: "NoMirrors", image: Image{Protocol: OCI, Server: "https://docker.io", Alias: "image"}, expect: "https://docker.io"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			image := tc.image.WithRegistryMirrors(tc.mirrors)
+			g.Expect(image.Server).To(Equal(tc.expect))
+			g.Expect(image.Alias).To(Equal(tc.image.Alias))
+			g.Expect(image.Protocol).To(Equal(tc.image.Protocol))
+		})
+	}
+}
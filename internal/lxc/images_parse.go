@@ -23,9 +23,22 @@ func ParseImage(imageName string) (ImageFamily, bool, error) {
 	if len(parts) != 2 {
 		return nil, false, nil
 	}
+	if p, ok := customImagePrefixes[parts[0]]; ok {
+		f, err := p.family(parts[1])
+		if err != nil {
+			return nil, false, err
+		}
+		return f, true, nil
+	}
 	if f, ok := wellKnownImages[parts[0]]; ok {
 		return f(parts[1]), true, nil
 	}
 
-	return nil, false, fmt.Errorf("unknown image prefix %q, must be one of %v", parts[0], slices.Collect(maps.Keys(wellKnownImages)))
+	prefixes := slices.Sorted(maps.Keys(wellKnownImages))
+	for prefix := range customImagePrefixes {
+		if _, ok := wellKnownImages[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return nil, false, fmt.Errorf("unknown image prefix %q, must be one of %v", parts[0], prefixes)
 }
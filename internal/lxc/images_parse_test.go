@@ -2,6 +2,8 @@ package lxc_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/lxc/incus/v6/shared/api"
@@ -82,3 +84,89 @@ func TestParseImage(t *testing.T) {
 		})
 	}
 }
+
+func TestParseImageCustomPrefixes(t *testing.T) {
+	g := NewWithT(t)
+
+	path := filepath.Join(t.TempDir(), "prefixes.yaml")
+	g.Expect(os.WriteFile(path, []byte(`
+corp:
+  sources:
+    incus:
+      protocol: simplestreams
+      server: https://images.example.internal
+      alias: "kubeadm/{{ .Image }}"
+    lxd:
+      protocol: simplestreams
+      server: https://lxd-images.example.internal
+      alias: "lxd/kubeadm/{{ .Image }}"
+ubuntu:
+  sources:
+    incus:
+      protocol: simplestreams
+      server: https://ubuntu.example.internal
+      alias: "ubuntu/{{ .Image }}"
+`), 0o600)).To(Succeed())
+
+	g.Expect(lxc.LoadImagePrefixesFile(path)).To(Succeed())
+	t.Cleanup(func() { _ = lxc.SetImagePrefixes(nil) })
+
+	for _, tc := range []struct {
+		server            string
+		image             string
+		expectErr         bool
+		expectImageSource api.ImageSource
+	}{
+		{server: "incus", image: "corp:v1.33.0", expectImageSource: simplestreamsImage("https://images.example.internal", "kubeadm/v1.33.0")},
+		{server: "lxd", image: "corp:v1.33.0", expectImageSource: simplestreamsImage("https://lxd-images.example.internal", "lxd/kubeadm/v1.33.0")},
+		{server: "incus", image: "ubuntu:24.04", expectImageSource: simplestreamsImage("https://ubuntu.example.internal", "ubuntu/24.04")},
+		{server: "lxd", image: "ubuntu:24.04", expectErr: true},
+		{server: "incus", image: "debian:12", expectImageSource: simplestreamsImage("https://images.linuxcontainers.org", "debian/12/cloud")},
+	} {
+		t.Run(fmt.Sprintf("%s/%s", tc.server, tc.image), func(t *testing.T) {
+			g := NewWithT(t)
+
+			family, parsed, err := lxc.ParseImage(tc.image)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(parsed).To(BeTrue())
+
+			image, err := family.For(tc.server)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(image.ImageSource()).To(Equal(tc.expectImageSource))
+			}
+		})
+	}
+}
+
+func TestSetImagePrefixes(t *testing.T) {
+	t.Cleanup(func() { _ = lxc.SetImagePrefixes(nil) })
+
+	valid := lxc.Image{Protocol: "simplestreams", Server: "https://images.example.internal", Alias: "{{ .Image }}"}
+	for _, tc := range []struct {
+		name      string
+		prefixes  map[string]lxc.ImagePrefix
+		expectErr bool
+	}{
+		{name: "Valid", prefixes: map[string]lxc.ImagePrefix{"corp": {Sources: map[string]lxc.Image{"incus": valid}}}},
+		{name: "InvalidName", prefixes: map[string]lxc.ImagePrefix{"corp:x": {Sources: map[string]lxc.Image{"incus": valid}}}, expectErr: true},
+		{name: "NoSources", prefixes: map[string]lxc.ImagePrefix{"corp": {}}, expectErr: true},
+		{name: "UnknownServer", prefixes: map[string]lxc.ImagePrefix{"corp": {Sources: map[string]lxc.Image{"other": valid}}}, expectErr: true},
+		{name: "UnknownProtocol", prefixes: map[string]lxc.ImagePrefix{"corp": {Sources: map[string]lxc.Image{"incus": {Protocol: "incus", Server: valid.Server, Alias: valid.Alias}}}}, expectErr: true},
+		{name: "InvalidServer", prefixes: map[string]lxc.ImagePrefix{"corp": {Sources: map[string]lxc.Image{"incus": {Protocol: valid.Protocol, Server: "images.example.internal", Alias: valid.Alias}}}}, expectErr: true},
+		{name: "InvalidTemplate", prefixes: map[string]lxc.ImagePrefix{"corp": {Sources: map[string]lxc.Image{"incus": {Protocol: valid.Protocol, Server: valid.Server, Alias: "{{ .Image "}}}}, expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := lxc.SetImagePrefixes(tc.prefixes)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
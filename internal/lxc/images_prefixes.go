@@ -0,0 +1,125 @@
+package lxc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ImagePrefix is a user-defined image prefix, e.g. "corp:kubeadm/VERSION".
+type ImagePrefix struct {
+	// Description is a description of the images, e.g. "corp".
+	Description string `json:"description,omitempty"`
+
+	// Sources are the image sources for each server type ("incus" or "lxd").
+	//
+	// The alias of each source is a Go template. The image name (the part after the prefix) is available
+	// as {{ .Image }}, e.g. "kubeadm/{{ .Image }}/ubuntu".
+	Sources map[string]Image `json:"sources"`
+}
+
+// imagePrefixTemplateData is the data passed to the alias templates of user-defined image prefixes.
+type imagePrefixTemplateData struct {
+	Image string
+}
+
+type customImagePrefix struct {
+	description string
+	sources     map[string]Image
+	templates   map[string]*template.Template
+}
+
+// family returns the image family for an image name (the part after the prefix).
+func (p *customImagePrefix) family(image string) (ImageFamily, error) {
+	sources := make(map[string]Image, len(p.sources))
+	for serverName, source := range p.sources {
+		var b bytes.Buffer
+		if err := p.templates[serverName].Execute(&b, imagePrefixTemplateData{Image: image}); err != nil {
+			return nil, fmt.Errorf("failed to render %q alias for %q images: %w", serverName, p.description, err)
+		}
+		source.Alias = b.String()
+		sources[serverName] = source
+	}
+	return &imageFamily{Description: p.description, Sources: sources}, nil
+}
+
+var (
+	// customImagePrefixes are user-defined image prefixes. They take precedence over wellKnownImages.
+	customImagePrefixes map[string]*customImagePrefix
+)
+
+// SetImagePrefixes configures user-defined image prefixes, replacing any previously configured ones. User-defined
+// prefixes take precedence over the built-in prefixes. It must be called before any images are resolved, typically
+// on manager startup.
+func SetImagePrefixes(prefixes map[string]ImagePrefix) error {
+	parsed := make(map[string]*customImagePrefix, len(prefixes))
+	for name, prefix := range prefixes {
+		if name == "" || strings.Contains(name, ":") {
+			return fmt.Errorf("invalid image prefix %q", name)
+		}
+		if len(prefix.Sources) == 0 {
+			return fmt.Errorf("image prefix %q has no sources", name)
+		}
+
+		p := &customImagePrefix{
+			description: prefix.Description,
+			sources:     make(map[string]Image, len(prefix.Sources)),
+			templates:   make(map[string]*template.Template, len(prefix.Sources)),
+		}
+		if p.description == "" {
+			p.description = name
+		}
+		for serverName, source := range prefix.Sources {
+			if serverName != Incus && serverName != LXD {
+				return fmt.Errorf("image prefix %q has source for unknown server %q, must be one of [%s %s]", name, serverName, Incus, LXD)
+			}
+			if source.Protocol != Simplestreams && source.Protocol != OCI {
+				return fmt.Errorf("image prefix %q has %q source with unknown protocol %q, must be one of [%s %s]", name, serverName, source.Protocol, Simplestreams, OCI)
+			}
+			if !strings.HasPrefix(source.Server, "https://") && !strings.HasPrefix(source.Server, "http://") {
+				return fmt.Errorf("image prefix %q has %q source with server %q that is not an HTTP or HTTPS server", name, serverName, source.Server)
+			}
+			if source.Fingerprint != "" {
+				return fmt.Errorf("image prefix %q has %q source with fingerprint, which is not supported", name, serverName)
+			}
+			tmpl, err := template.New(fmt.Sprintf("%s/%s", name, serverName)).Option("missingkey=error").Parse(source.Alias)
+			if err != nil {
+				return fmt.Errorf("image prefix %q has %q source with invalid alias template: %w", name, serverName, err)
+			}
+			p.sources[serverName] = source
+			p.templates[serverName] = tmpl
+		}
+		parsed[name] = p
+	}
+
+	customImagePrefixes = parsed
+	return nil
+}
+
+// LoadImagePrefixesFile reads user-defined image prefixes from a YAML file and configures them with SetImagePrefixes.
+// The file contains a map of prefix name to ImagePrefix, e.g.
+//
+//	corp:
+//	  sources:
+//	    incus:
+//	      protocol: simplestreams
+//	      server: https://images.example.internal
+//	      alias: "{{ .Image }}"
+func LoadImagePrefixesFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read image prefixes file: %w", err)
+	}
+	var prefixes map[string]ImagePrefix
+	if err := yaml.UnmarshalStrict(b, &prefixes); err != nil {
+		return fmt.Errorf("failed to parse image prefixes file %q: %w", path, err)
+	}
+	if err := SetImagePrefixes(prefixes); err != nil {
+		return fmt.Errorf("invalid image prefixes file %q: %w", path, err)
+	}
+	return nil
+}
@@ -59,9 +59,9 @@ func DefaultImage(image string) ImageFamily {
 }
 
 func CapnImage(image string) Image {
-	return Image{ // "capi:IMAGE" -> "IMAGE" from "https://d14dnvi2l3tc5t.cloudfront.net"
+	return Image{ // "capi:IMAGE" -> "IMAGE" from "https://d14dnvi2l3tc5t.cloudfront.net" (see SetDefaultSimplestreamsServer)
 		Protocol: Simplestreams,
-		Server:   DefaultSimplestreamsServer,
+		Server:   defaultSimplestreamsServer,
 		Alias:    image,
 	}
 }
@@ -0,0 +1,186 @@
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+// DefaultInstanceTypesURL is the URL of the instance type catalog that Incus uses to resolve instance flavors
+// (e.g. "t3.micro").
+const DefaultInstanceTypesURL = "https://images.linuxcontainers.org/meta/instance-types"
+
+// instanceTypesURL is the URL of the instance type catalog. If empty, instance types are not resolved.
+var instanceTypesURL = DefaultInstanceTypesURL
+
+// SetInstanceTypesURL sets the URL of the instance type catalog (e.g. a local mirror for air-gapped environments).
+// An empty URL disables resolving instance types. It must be called before any flavors are resolved, typically on
+// manager startup.
+func SetInstanceTypesURL(url string) error {
+	if url != "" && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return fmt.Errorf("instance type catalog %q is not an HTTP or HTTPS URL", url)
+	}
+	instanceTypesURL = strings.TrimSuffix(url, "/")
+	return nil
+}
+
+// instanceTypesCacheDuration is how long the instance type catalog is cached.
+const instanceTypesCacheDuration = 24 * time.Hour
+
+// InstanceTypeLimits are the limits of an instance flavor.
+type InstanceTypeLimits struct {
+	// CPU is the number of CPU cores.
+	CPU float64 `json:"cpu"`
+	// Memory is the amount of memory, in GiB.
+	Memory float64 `json:"mem"`
+}
+
+// CPULimit returns the value of the limits.cpu config key of the instance flavor.
+func (l InstanceTypeLimits) CPULimit() string {
+	return strconv.Itoa(int(math.Ceil(l.CPU)))
+}
+
+// MemoryLimit returns the value of the limits.memory config key of the instance flavor.
+func (l InstanceTypeLimits) MemoryLimit() string {
+	return fmt.Sprintf("%dMiB", int64(l.Memory*1024))
+}
+
+// flavorRegexp matches "c<cpu>-m<memory>" flavors, e.g. "c2-m4".
+var flavorRegexp = regexp.MustCompile(`^c([0-9.]+)-m([0-9.]+)$`)
+
+var instanceTypes struct {
+	sync.Mutex
+
+	url       string
+	catalog   map[string]map[string]InstanceTypeLimits
+	fetchedAt time.Time
+}
+
+// ResolveFlavor returns the limits of an instance flavor. Flavors can be either "c<cpu>-m<memory>" (e.g. "c2-m4"),
+// or an instance type from the Incus instance type catalog, optionally prefixed with the cloud (e.g. "t3.micro" or
+// "aws:t3.micro").
+//
+// Instance types are not resolved if the instance type catalog is disabled, or if images are resolved offline (see
+// SetOfflineImages). In that case, a terminal error is returned.
+func ResolveFlavor(ctx context.Context, flavor string) (InstanceTypeLimits, error) {
+	if m := flavorRegexp.FindStringSubmatch(flavor); m != nil {
+		cpu, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return InstanceTypeLimits{}, fmt.Errorf("invalid CPU count in flavor %q: %w", flavor, err)
+		}
+		memory, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return InstanceTypeLimits{}, fmt.Errorf("invalid memory in flavor %q: %w", flavor, err)
+		}
+		return InstanceTypeLimits{CPU: cpu, Memory: memory}, nil
+	}
+
+	switch {
+	case instanceTypesURL == "":
+		return InstanceTypeLimits{}, utils.TerminalError(fmt.Errorf("cannot resolve instance type %q, as the instance type catalog is disabled", flavor))
+	case offlineImages:
+		return InstanceTypeLimits{}, utils.TerminalError(fmt.Errorf("cannot resolve instance type %q, as the manager is running with --offline-images", flavor))
+	}
+
+	catalog, err := getInstanceTypes(ctx)
+	if err != nil {
+		return InstanceTypeLimits{}, err
+	}
+	limits, err := lookupInstanceType(catalog, flavor)
+	if err != nil {
+		return InstanceTypeLimits{}, utils.TerminalError(err)
+	}
+	return limits, nil
+}
+
+// lookupInstanceType looks up an instance type in the catalog. Without a cloud prefix, clouds are searched in
+// alphabetical order.
+func lookupInstanceType(catalog map[string]map[string]InstanceTypeLimits, flavor string) (InstanceTypeLimits, error) {
+	if cloud, name, ok := strings.Cut(flavor, ":"); ok {
+		if limits, ok := catalog[cloud][name]; ok {
+			return limits, nil
+		}
+		return InstanceTypeLimits{}, fmt.Errorf("instance type %q not found in cloud %q", name, cloud)
+	}
+
+	clouds := make([]string, 0, len(catalog))
+	for cloud := range catalog {
+		clouds = append(clouds, cloud)
+	}
+	slices.Sort(clouds)
+	for _, cloud := range clouds {
+		if limits, ok := catalog[cloud][flavor]; ok {
+			return limits, nil
+		}
+	}
+	return InstanceTypeLimits{}, fmt.Errorf("instance type %q not found", flavor)
+}
+
+// getInstanceTypes returns the instance type catalog, and refreshes it if needed.
+func getInstanceTypes(ctx context.Context) (map[string]map[string]InstanceTypeLimits, error) {
+	instanceTypes.Lock()
+	defer instanceTypes.Unlock()
+
+	if instanceTypes.catalog != nil && instanceTypes.url == instanceTypesURL && time.Since(instanceTypes.fetchedAt) < instanceTypesCacheDuration {
+		return instanceTypes.catalog, nil
+	}
+
+	// The index maps cloud names to the file with the instance types of the cloud, e.g. "aws: aws.yaml".
+	var index map[string]string
+	if err := fetchYAML(ctx, fmt.Sprintf("%s/.yaml", instanceTypesURL), &index); err != nil {
+		return nil, fmt.Errorf("failed to retrieve instance type index: %w", err)
+	}
+
+	catalog := make(map[string]map[string]InstanceTypeLimits, len(index))
+	for cloud, file := range index {
+		var types map[string]InstanceTypeLimits
+		if err := fetchYAML(ctx, fmt.Sprintf("%s/%s", instanceTypesURL, file), &types); err != nil {
+			return nil, fmt.Errorf("failed to retrieve instance types for cloud %q: %w", cloud, err)
+		}
+		catalog[cloud] = types
+	}
+
+	instanceTypes.url = instanceTypesURL
+	instanceTypes.catalog = catalog
+	instanceTypes.fetchedAt = time.Now()
+	return catalog, nil
+}
+
+func fetchYAML(ctx context.Context, url string, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to GET %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to GET %s: unexpected status %s", url, resp.Status)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := yaml.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
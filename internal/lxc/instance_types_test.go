@@ -0,0 +1,102 @@
+package lxc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/lxc/cluster-api-provider-incus/internal/utils"
+)
+
+func TestResolveFlavor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.yaml":
+			_, _ = w.Write([]byte("aws: aws.yaml\ngce: gce.yaml\n"))
+		case "/aws.yaml":
+			_, _ = w.Write([]byte("t3.micro:\n  cpu: 2.0\n  mem: 1.0\nt3.large:\n  cpu: 2.0\n  mem: 8.0\n"))
+		case "/gce.yaml":
+			_, _ = w.Write([]byte("f1-micro:\n  cpu: 0.2\n  mem: 0.6\nt3.micro:\n  cpu: 4.0\n  mem: 4.0\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { instanceTypesURL = url }(instanceTypesURL)
+	NewWithT(t).Expect(SetInstanceTypesURL(server.URL + "/")).To(Succeed())
+
+	for _, tc := range []struct {
+		flavor      string
+		expect      InstanceTypeLimits
+		expectError bool
+	}{
+		{flavor: "c2-m4", expect: InstanceTypeLimits{CPU: 2, Memory: 4}},
+		{flavor: "c1-m0.5", expect: InstanceTypeLimits{CPU: 1, Memory: 0.5}},
+		{flavor: "t3.micro", expect: InstanceTypeLimits{CPU: 2, Memory: 1}},
+		{flavor: "gce:t3.micro", expect: InstanceTypeLimits{CPU: 4, Memory: 4}},
+		{flavor: "f1-micro", expect: InstanceTypeLimits{CPU: 0.2, Memory: 0.6}},
+		{flavor: "aws:f1-micro", expectError: true},
+		{flavor: "unknown", expectError: true},
+	} {
+		t.Run(tc.flavor, func(t *testing.T) {
+			g := NewWithT(t)
+
+			limits, err := ResolveFlavor(context.Background(), tc.flavor)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(limits).To(Equal(tc.expect))
+		})
+	}
+}
+
+func TestResolveFlavorDisabled(t *testing.T) {
+	defer func(url string, offline bool) { instanceTypesURL, offlineImages = url, offline }(instanceTypesURL, offlineImages)
+
+	for _, tc := range []struct {
+		name    string
+		url     string
+		offline bool
+	}{
+		{name: "CatalogDisabled", url: ""},
+		{name: "OfflineImages", url: DefaultInstanceTypesURL, offline: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(SetInstanceTypesURL(tc.url)).To(Succeed())
+			SetOfflineImages(tc.offline)
+
+			limits, err := ResolveFlavor(context.Background(), "c2-m4")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(limits).To(Equal(InstanceTypeLimits{CPU: 2, Memory: 4}))
+
+			_, err = ResolveFlavor(context.Background(), "t3.micro")
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(utils.IsTerminalError(err)).To(BeTrue())
+		})
+	}
+}
+
+func TestSetInstanceTypesURL(t *testing.T) {
+	defer func(url string) { instanceTypesURL = url }(instanceTypesURL)
+
+	g := NewWithT(t)
+	g.Expect(SetInstanceTypesURL("https://mirror.example.com/meta/instance-types")).To(Succeed())
+	g.Expect(SetInstanceTypesURL("")).To(Succeed())
+	g.Expect(SetInstanceTypesURL("mirror.example.com/meta/instance-types")).ToNot(Succeed())
+}
+
+func TestInstanceTypeLimits(t *testing.T) {
+	g := NewWithT(t)
+
+	limits := InstanceTypeLimits{CPU: 0.2, Memory: 0.5}
+	g.Expect(limits.CPULimit()).To(Equal("1"))
+	g.Expect(limits.MemoryLimit()).To(Equal("512MiB"))
+}
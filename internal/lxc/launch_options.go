@@ -2,6 +2,7 @@ package lxc
 
 import (
 	"maps"
+	"strings"
 
 	"github.com/lxc/incus/v6/shared/api"
 )
@@ -18,18 +19,33 @@ type LaunchOptions struct {
 	// The replacement is expected to be idempotent.
 	// Not supported by virtual-machine instance types.
 	replacements map[string]map[string]string
+	// bootstrapReplacer returns text replacements for values that are only known after the instance is created (e.g.
+	// the cluster member of the instance). They are only applied to the bootstrap data of the instance, i.e. the
+	// bootstrapConfigKeys config values, and the instance templates and created files in bootstrapPaths.
+	// Supported by all instance types.
+	bootstrapReplacer func(instance *api.Instance) *strings.Replacer
+	// bootstrapConfigKeys are the instance config keys that contain bootstrap data.
+	bootstrapConfigKeys []string
+	// bootstrapPaths are the instance templates and created files that contain bootstrap data.
+	bootstrapPaths []string
 	// devices is instance device configuration.
 	devices map[string]map[string]string
 	// config is instance configuration.
 	config map[string]string
+	// appendConfig is instance configuration that is appended (space separated) to existing config values.
+	appendConfig map[string]string
 	// profiles is instance profiles.
 	profiles []string
 	// image is the instance source.
 	image ImageFamily
+	// registryMirrors are registry mirrors for OCI images, see Image.WithRegistryMirrors.
+	registryMirrors map[string]string
 	// flavor is the instance flavor.
 	flavor string
 	// instanceType is the instance type.
 	instanceType api.InstanceType
+	// architecture is the instance architecture, in Incus format (e.g. "x86_64").
+	architecture string
 	// unixSocket bind mounts the admin unix socket into the instance at /run-unix.socket (potentially insecure).
 	unixSocket bool
 }
@@ -95,6 +111,25 @@ func (o *LaunchOptions) WithReplacements(new map[string]map[string]string) *Laun
 	return o
 }
 
+// WithBootstrapReplacer sets text replacements that are applied to the bootstrap data of the instance after it is
+// created, and before it is started. See WithBootstrapConfigKeys and WithBootstrapPaths.
+func (o *LaunchOptions) WithBootstrapReplacer(f func(instance *api.Instance) *strings.Replacer) *LaunchOptions {
+	o.bootstrapReplacer = f
+	return o
+}
+
+// WithBootstrapConfigKeys marks instance config keys that contain bootstrap data.
+func (o *LaunchOptions) WithBootstrapConfigKeys(keys ...string) *LaunchOptions {
+	o.bootstrapConfigKeys = append(o.bootstrapConfigKeys, keys...)
+	return o
+}
+
+// WithBootstrapPaths marks instance templates and created files that contain bootstrap data.
+func (o *LaunchOptions) WithBootstrapPaths(paths ...string) *LaunchOptions {
+	o.bootstrapPaths = append(o.bootstrapPaths, paths...)
+	return o
+}
+
 // WithDevices adds instance devices.
 func (o *LaunchOptions) WithDevices(new map[string]map[string]string) *LaunchOptions {
 	if o.devices == nil {
@@ -115,6 +150,19 @@ func (o *LaunchOptions) WithConfig(new map[string]string) *LaunchOptions {
 	return o
 }
 
+// WithAppendConfig appends to instance config values, separated by a space. Values are appended after all other
+// config has been applied, so that config keys like "raw.qemu" may be extended without overriding user configuration.
+func (o *LaunchOptions) WithAppendConfig(new map[string]string) *LaunchOptions {
+	if o.appendConfig == nil {
+		o.appendConfig = maps.Clone(new)
+		return o
+	}
+	for key, value := range new {
+		o.appendConfig[key] = strings.TrimSpace(o.appendConfig[key] + " " + value)
+	}
+	return o
+}
+
 // WithProfiles adds instance profiles.
 func (o *LaunchOptions) WithProfiles(new []string) *LaunchOptions {
 	o.profiles = append(o.profiles, new...)
@@ -130,6 +178,16 @@ func (o *LaunchOptions) WithImage(image ImageFamily) *LaunchOptions {
 	return o
 }
 
+// WithRegistryMirrors sets registry mirrors for OCI images. See Image.WithRegistryMirrors.
+func (o *LaunchOptions) WithRegistryMirrors(new map[string]string) *LaunchOptions {
+	if o.registryMirrors == nil {
+		o.registryMirrors = maps.Clone(new)
+	} else {
+		maps.Copy(o.registryMirrors, new)
+	}
+	return o
+}
+
 // WithFlavor sets the instance flavor.
 func (o *LaunchOptions) WithFlavor(v string) *LaunchOptions {
 	o.flavor = v
@@ -142,6 +200,12 @@ func (o *LaunchOptions) WithInstanceType(v api.InstanceType) *LaunchOptions {
 	return o
 }
 
+// WithArchitecture sets the instance architecture, in Incus format (e.g. "x86_64").
+func (o *LaunchOptions) WithArchitecture(v string) *LaunchOptions {
+	o.architecture = v
+	return o
+}
+
 // WithUnixSocket bind mounts the admin unix socket into the instance at /run-unix.socket (potentially insecure).
 func (o *LaunchOptions) WithUnixSocket(v bool) *LaunchOptions {
 	o.unixSocket = v
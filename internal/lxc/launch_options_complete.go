@@ -30,6 +30,15 @@ func (o *LaunchOptions) complete(serverName string) error {
 		}
 	}
 
+	// append config values
+	for key, value := range o.appendConfig {
+		if o.config == nil {
+			o.config = make(map[string]string, len(o.appendConfig))
+		}
+		o.config[key] = strings.TrimSpace(o.config[key] + " " + value)
+	}
+	o.appendConfig = nil
+
 	// complete image configuration
 	if o.image == nil {
 		return utils.TerminalError(fmt.Errorf("cannot launch instance without image"))
@@ -38,6 +47,7 @@ func (o *LaunchOptions) complete(serverName string) error {
 	if err != nil {
 		return fmt.Errorf("unsupported instance image: %w", err)
 	}
+	image = image.WithRegistryMirrors(o.registryMirrors)
 	// if OCI image is specified as `IMG[:TAG]@sha256:HASH`, replace with `IMG@sha256:HASH`
 	if image.Protocol == OCI {
 		if imageWithoutHash, hash, ok := strings.Cut(image.Alias, "@"); ok {
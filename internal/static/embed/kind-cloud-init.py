@@ -1,6 +1,9 @@
 #!/usr/bin/env python3
 
+import base64
 import json
+import os
+import pwd
 import subprocess
 from pathlib import Path
 from time import sleep
@@ -18,6 +21,115 @@ systemctl start --no-block cloud-final.service
 """
 
 
+def run_commands(commands):
+    """run a list of shell commands, stopping at the first failure"""
+    if not commands:
+        return
+
+    subprocess.run(["bash", "-xe", "-s"], check=True, text=True, input="\n".join(commands))
+
+
+def write_files(files, deferred):
+    """write_files, contents are either plain text or base64"""
+    for file in files or []:
+        if bool(file.get("defer")) != deferred:
+            continue
+
+        path = Path(file["path"])
+        path.parent.mkdir(parents=True, exist_ok=True)
+
+        content = file["content"].encode()
+        if file.get("encoding") == "b64":
+            content = base64.b64decode(content)
+
+        with open(path, "ab" if file.get("append") else "wb") as f:
+            f.write(content)
+
+        subprocess.run(["chmod", file["permissions"], file["path"]], check=True)
+        subprocess.run(["chown", file["owner"], file["path"]], check=True)
+
+
+def create_users(users):
+    """create users, configure sudo and SSH authorized keys"""
+    sudoers = []
+    for user in users or []:
+        name = user["name"]
+        if name == "default":
+            print("Ignoring default user, kind instances do not have a default user")
+            continue
+
+        if subprocess.run(["id", name], capture_output=True).returncode != 0:
+            args = ["useradd", "--create-home"]
+            if user.get("homedir"):
+                args += ["--home-dir", user["homedir"]]
+            if user.get("shell"):
+                args += ["--shell", user["shell"]]
+            if user.get("gecos"):
+                args += ["--comment", user["gecos"]]
+            if user.get("primary_group"):
+                subprocess.run(["groupadd", "--force", user["primary_group"]], check=True)
+                args += ["--gid", user["primary_group"]]
+            subprocess.run(args + [name], check=True)
+
+        for group in user.get("groups") or []:
+            subprocess.run(["groupadd", "--force", group], check=True)
+            subprocess.run(["usermod", "--append", "--groups", group, name], check=True)
+
+        if user.get("passwd"):
+            subprocess.run(["usermod", "--password", user["passwd"], name], check=True)
+        if user.get("lock_passwd") is not False:
+            subprocess.run(["passwd", "--lock", name], check=True)
+
+        for rule in user.get("sudo") or []:
+            sudoers.append(f"{name} {rule}")
+
+        keys = user.get("ssh_authorized_keys") or []
+        if keys:
+            home = Path(pwd.getpwnam(name).pw_dir)
+            ssh_dir = home / ".ssh"
+            ssh_dir.mkdir(mode=0o700, exist_ok=True)
+            authorized_keys = ssh_dir / "authorized_keys"
+            authorized_keys.write_text("\n".join(keys) + "\n")
+            authorized_keys.chmod(0o600)
+            subprocess.run(["chown", "-R", f"{name}:", str(ssh_dir)], check=True)
+
+    if sudoers:
+        path = Path("/etc/sudoers.d/90-cloud-init-users")
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_text("\n".join(sudoers) + "\n")
+        path.chmod(0o440)
+
+
+def add_mounts(mounts):
+    """add mounts to /etc/fstab and mount them, mount failures are not fatal"""
+    # defaults for missing fields, as in cloud-init
+    defaults = ["", "", "auto", "defaults,nofail", "0", "2"]
+    for mount in mounts or []:
+        if len(mount) < 2 or not mount[0] or not mount[1]:
+            print(f"Ignoring invalid mounts entry {mount}")
+            continue
+
+        entry = [value or default for value, default in zip(mount + defaults[len(mount) :], defaults)]
+        Path(entry[1]).mkdir(parents=True, exist_ok=True)
+        with open("/etc/fstab", "a") as f:
+            f.write(" ".join(entry) + "\n")
+
+        p = subprocess.run(["mount", entry[1]], capture_output=True, text=True)
+        if p.returncode != 0:
+            print(f"Failed to mount {entry[1]}: {p.stderr}")
+
+
+def install_packages(packages, update, upgrade):
+    """install packages with apt-get"""
+    env = dict(os.environ, DEBIAN_FRONTEND="noninteractive")
+    if update or upgrade or packages:
+        subprocess.run(["apt-get", "update"], check=True, env=env)
+    if upgrade:
+        subprocess.run(["apt-get", "upgrade", "--yes"], check=True, env=env)
+    if packages:
+        subprocess.run(["apt-get", "install", "--no-install-recommends", "--yes"] + packages, check=True, env=env)
+
+
 if __name__ == "__main__":
     # wait for instance address before proceeding
     while True:
@@ -38,15 +150,21 @@ if __name__ == "__main__":
         subprocess.run(["bash", "-xe", "-c", CLOUD_INIT_SCRIPT], check=True)
         exit(0)
 
-    # load /hack/cloud-init.json and apply manually
+    # load /hack/cloud-init.json and apply manually, in the same order as cloud-init modules
     hack_cloud_config = json.loads(HACK_CLOUD_CONFIG_PATH.read_text())
-    for file in hack_cloud_config.get("write_files") or []:
-        path = Path(file["path"])
-        path.parent.mkdir(parents=True, exist_ok=True)
-        path.write_text(file["content"])
 
-        subprocess.run(["chmod", file["permissions"], file["path"]], check=True)
-        subprocess.run(["chown", file["owner"], file["path"]], check=True)
+    run_commands(hack_cloud_config.get("bootcmd"))
+    write_files(hack_cloud_config.get("write_files"), deferred=False)
+    create_users(hack_cloud_config.get("users"))
+    add_mounts(hack_cloud_config.get("mounts"))
+
+    if hack_cloud_config.get("ntp"):
+        print("Ignoring ntp configuration, containers use the clock of the host")
 
-    command = hack_cloud_config.get("runcmd") or []
-    subprocess.run(["bash", "-xe", "-s"], check=True, text=True, input="\n".join(command))
+    install_packages(
+        hack_cloud_config.get("packages"),
+        hack_cloud_config.get("package_update"),
+        hack_cloud_config.get("package_upgrade"),
+    )
+    write_files(hack_cloud_config.get("write_files"), deferred=True)
+    run_commands(hack_cloud_config.get("runcmd"))
@@ -0,0 +1,275 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+	"github.com/lxc/cluster-api-provider-incus/internal/endpointdns"
+	"github.com/lxc/cluster-api-provider-incus/internal/instances"
+	"github.com/lxc/cluster-api-provider-incus/internal/loadbalancer"
+)
+
+// SetupLXCClusterWebhookWithManager registers the webhook for LXCCluster in the manager.
+func SetupLXCClusterWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&infrav1.LXCCluster{}).
+		WithValidator(&LXCClusterCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1alpha2-lxccluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=lxcclusters,verbs=create;update,versions=v1alpha2,name=validation.lxccluster.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+// LXCClusterCustomValidator validates LXCCluster resources when they are created or updated.
+type LXCClusterCustomValidator struct{}
+
+var _ webhook.CustomValidator = &LXCClusterCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator.
+func (v *LXCClusterCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	lxcCluster, ok := obj.(*infrav1.LXCCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a LXCCluster object but got %T", obj)
+	}
+
+	return nil, toInvalidError("LXCCluster", lxcCluster.Name, validateLXCCluster(nil, lxcCluster))
+}
+
+// ValidateUpdate implements webhook.CustomValidator.
+func (v *LXCClusterCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldLXCCluster, ok := oldObj.(*infrav1.LXCCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a LXCCluster object but got %T", oldObj)
+	}
+	lxcCluster, ok := newObj.(*infrav1.LXCCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a LXCCluster object but got %T", newObj)
+	}
+
+	// Do not block objects that are being deleted, e.g. when the finalizer is removed.
+	if !lxcCluster.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return nil, toInvalidError("LXCCluster", lxcCluster.Name, validateLXCCluster(oldLXCCluster, lxcCluster))
+}
+
+// ValidateDelete implements webhook.CustomValidator.
+func (v *LXCClusterCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateLXCCluster validates a LXCCluster. If oldLXCCluster is not nil, only the fields that differ from
+// oldLXCCluster are validated, such that existing objects can still be updated (e.g. by the controllers).
+func validateLXCCluster(oldLXCCluster *infrav1.LXCCluster, lxcCluster *infrav1.LXCCluster) field.ErrorList {
+	var oldSpec *infrav1.LXCClusterSpec
+	if oldLXCCluster != nil {
+		oldSpec = &oldLXCCluster.Spec
+	}
+	allErrs := validateLXCClusterSpec(oldSpec, lxcCluster.Spec, field.NewPath("spec"))
+
+	// Instance names depend on the owning Cluster. The cluster name label is set by Cluster API, otherwise the
+	// LXCCluster is expected to have the same name as the Cluster.
+	if naming := lxcCluster.Spec.MachineNaming; naming != nil && len(allErrs) == 0 && (oldSpec == nil || !apiequality.Semantic.DeepEqual(oldSpec.MachineNaming, naming)) {
+		clusterName := lxcCluster.Labels[clusterv1.ClusterNameLabel]
+		if clusterName == "" {
+			clusterName = lxcCluster.Name
+		}
+		if err := instances.ValidateMachineNaming(naming, clusterName, lxcCluster.Namespace); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "machineNaming"), naming, fmt.Sprintf("invalid instance names for machines of cluster %q: %s", clusterName, err)))
+		}
+	}
+
+	if oldLXCCluster != nil {
+		allErrs = append(allErrs, validateLoadBalancerHandover(oldLXCCluster, lxcCluster)...)
+	}
+
+	return allErrs
+}
+
+// validateLoadBalancerHandover validates that the control plane endpoint can be handed over to the new load balancer,
+// if the load balancer type of the LXCCluster changes.
+func validateLoadBalancerHandover(oldLXCCluster *infrav1.LXCCluster, lxcCluster *infrav1.LXCCluster) field.ErrorList {
+	if loadbalancer.Identity(oldLXCCluster.Spec.LoadBalancer) == loadbalancer.Identity(lxcCluster.Spec.LoadBalancer) {
+		return nil
+	}
+
+	// The load balancer that serves the control plane endpoint is recorded in the status. It differs from the previous
+	// spec if the load balancer is changed again during a handover.
+	from := oldLXCCluster.Spec.LoadBalancer
+	if status := lxcCluster.Status.LoadBalancer; status != nil && status.ActiveSpec != nil {
+		from = *status.ActiveSpec
+	}
+	if err := loadbalancer.ValidateHandover(from, lxcCluster.Spec.LoadBalancer, lxcCluster.Spec.ControlPlaneEndpoint.Host); err != nil {
+		return field.ErrorList{field.Invalid(field.NewPath("spec", "loadBalancer"), "<loadBalancer>", err.Error())}
+	}
+	return nil
+}
+
+// validateLXCClusterSpec validates the spec of LXCCluster and LXCClusterTemplate resources. If oldSpec is not nil,
+// only the fields that differ from oldSpec are validated.
+func validateLXCClusterSpec(oldSpec *infrav1.LXCClusterSpec, spec infrav1.LXCClusterSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// changed returns true if the value returned by get differs between the old and the new spec.
+	changed := func(get func(spec infrav1.LXCClusterSpec) any) bool {
+		return oldSpec == nil || !apiequality.Semantic.DeepEqual(get(*oldSpec), get(spec))
+	}
+
+	for _, lb := range []struct {
+		name     string
+		template func(spec infrav1.LXCClusterSpec) any
+	}{
+		{name: "lxc", template: func(spec infrav1.LXCClusterSpec) any {
+			if spec.LoadBalancer.LXC == nil {
+				return ""
+			}
+			return spec.LoadBalancer.LXC.CustomHAProxyConfigTemplate
+		}},
+		{name: "oci", template: func(spec infrav1.LXCClusterSpec) any {
+			if spec.LoadBalancer.OCI == nil {
+				return ""
+			}
+			return spec.LoadBalancer.OCI.CustomHAProxyConfigTemplate
+		}},
+	} {
+		template := lb.template(spec).(string)
+		if template == "" || !changed(lb.template) {
+			continue
+		}
+		if err := loadbalancer.ValidateHAProxyConfigTemplate(template); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("loadBalancer", lb.name, "customHAProxyConfigTemplate"), "<template>", err.Error()))
+		}
+	}
+
+	if kubeVIP := spec.LoadBalancer.KubeVIP; kubeVIP != nil {
+		kubeVIPPath := fldPath.Child("loadBalancer", "kubeVIP")
+		kubeVIPField := func(get func(kubeVIP *infrav1.LXCLoadBalancerKubeVIP) any) func(spec infrav1.LXCClusterSpec) any {
+			return func(spec infrav1.LXCClusterSpec) any {
+				if spec.LoadBalancer.KubeVIP == nil {
+					return nil
+				}
+				return get(spec.LoadBalancer.KubeVIP)
+			}
+		}
+		if kubeVIP.CustomManifestTemplate != "" && changed(kubeVIPField(func(k *infrav1.LXCLoadBalancerKubeVIP) any { return k.CustomManifestTemplate })) {
+			if err := loadbalancer.ValidateKubeVIPManifestTemplate(kubeVIP.CustomManifestTemplate); err != nil {
+				allErrs = append(allErrs, field.Invalid(kubeVIPPath.Child("customManifestTemplate"), "<template>", err.Error()))
+			}
+		}
+		if kubeVIP.BGP != nil && changed(kubeVIPField(func(k *infrav1.LXCLoadBalancerKubeVIP) any { return k.BGP })) {
+			for i, peer := range kubeVIP.BGP.Peers {
+				if net.ParseIP(peer.Address) == nil {
+					allErrs = append(allErrs, field.Invalid(kubeVIPPath.Child("bgp", "peers").Index(i).Child("address"), peer.Address, "must be an IP address"))
+				}
+			}
+		}
+		if kubeVIP.Services != nil && changed(kubeVIPField(func(k *infrav1.LXCLoadBalancerKubeVIP) any { return k.Services })) && !isValidAddressRange(kubeVIP.Services.AddressRange) {
+			allErrs = append(allErrs, field.Invalid(kubeVIPPath.Child("services", "addressRange"), kubeVIP.Services.AddressRange, "must be an address range (e.g. 10.0.0.100-10.0.0.120) or a CIDR (e.g. 10.0.0.96/28)"))
+		}
+	}
+
+	if ovn := spec.LoadBalancer.OVN; ovn != nil && ovn.ListenAddress != "" && net.ParseIP(ovn.ListenAddress) == nil && changed(func(spec infrav1.LXCClusterSpec) any { return spec.LoadBalancer.OVN }) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("loadBalancer", "ovn", "listenAddress"), ovn.ListenAddress, "must be an IP address"))
+	}
+
+	if naming := spec.MachineNaming; naming != nil && changed(func(spec infrav1.LXCClusterSpec) any { return spec.MachineNaming }) {
+		if err := instances.ValidateMachineNamingTemplate(naming); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("machineNaming"), naming, err.Error()))
+		}
+	}
+
+	if dns := spec.ControlPlaneEndpointDNS; dns != nil {
+		dnsPath := fldPath.Child("controlPlaneEndpointDNS")
+		if changed(func(spec infrav1.LXCClusterSpec) any { return []any{spec.ControlPlaneEndpointDNS, spec.LoadBalancer} }) {
+			if ovn := spec.LoadBalancer.OVN; ovn != nil && ovn.ListenAddress == "" {
+				allErrs = append(allErrs, field.Required(fldPath.Child("loadBalancer", "ovn", "listenAddress"), "required when using controlPlaneEndpointDNS"))
+			} else if spec.LoadBalancer.LXC == nil && spec.LoadBalancer.OCI == nil && ovn == nil {
+				allErrs = append(allErrs, field.Forbidden(dnsPath, "only supported for the lxc, oci and ovn load balancer types"))
+			}
+		}
+		if changed(func(spec infrav1.LXCClusterSpec) any { return spec.ControlPlaneEndpointDNS }) {
+			if _, err := endpointdns.RecordName(dns.Name, dns.Zone); err != nil {
+				allErrs = append(allErrs, field.Invalid(dnsPath.Child("name"), dns.Name, err.Error()))
+			}
+			if dns.RFC2136 != nil {
+				if _, _, err := net.SplitHostPort(dns.RFC2136.Server); err != nil {
+					allErrs = append(allErrs, field.Invalid(dnsPath.Child("rfc2136", "server"), dns.RFC2136.Server, "must be in host:port format"))
+				}
+			}
+		}
+		if changed(func(spec infrav1.LXCClusterSpec) any {
+			return []any{spec.ControlPlaneEndpoint.Host, spec.ControlPlaneEndpointDNS}
+		}) {
+			if host := spec.ControlPlaneEndpoint.Host; host != "" && !strings.EqualFold(host, strings.TrimSuffix(dns.Name, ".")) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("controlPlaneEndpoint", "host"), host, "must match controlPlaneEndpointDNS.name"))
+			}
+		}
+	}
+
+	if registry := spec.Registry; registry != nil && changed(func(spec infrav1.LXCClusterSpec) any { return spec.Registry }) {
+		for i, mirror := range registry.Mirrors {
+			mirrorPath := fldPath.Child("registry", "mirrors").Index(i)
+			if err := instances.ValidateRegistryMirrorName(mirror.Registry); err != nil {
+				allErrs = append(allErrs, field.Invalid(mirrorPath.Child("registry"), mirror.Registry, err.Error()))
+			}
+			for j, endpoint := range mirror.Endpoints {
+				if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					allErrs = append(allErrs, field.Invalid(mirrorPath.Child("endpoints").Index(j), endpoint, "must be an http or https URL, e.g. https://mirror.example.com:5000"))
+				}
+			}
+			if mirror.CABundle != "" {
+				if block, _ := pem.Decode([]byte(mirror.CABundle)); block == nil || block.Type != "CERTIFICATE" {
+					allErrs = append(allErrs, field.Invalid(mirrorPath.Child("caBundle"), "<caBundle>", "must be a PEM encoded certificate bundle"))
+				}
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// isValidAddressRange returns true if value is an address range ("<start>-<end>") or a CIDR.
+func isValidAddressRange(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	start, end, ok := strings.Cut(value, "-")
+	return ok && net.ParseIP(start) != nil && net.ParseIP(end) != nil
+}
+
+// toInvalidError converts a list of field errors to an Invalid API error. It returns nil if the list is empty.
+func toInvalidError(kind string, name string, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(infrav1.GroupVersion.WithKind(kind).GroupKind(), name, allErrs)
+}
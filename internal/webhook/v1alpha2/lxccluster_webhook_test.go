@@ -0,0 +1,162 @@
+package v1alpha2
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func TestLXCClusterValidateCreate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		spec        infrav1.LXCClusterSpec
+		expectError bool
+	}{
+		{name: "Empty"},
+		{
+			name:        "CustomHAProxyConfigTemplate",
+			spec:        infrav1.LXCClusterSpec{LoadBalancer: infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{CustomHAProxyConfigTemplate: "global\n  maxconn {{ .MaxConn"}}},
+			expectError: true,
+		},
+		{
+			name: "OVNListenAddress",
+			spec: infrav1.LXCClusterSpec{LoadBalancer: infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0", ListenAddress: "10.0.0.10"}}},
+		},
+		{
+			name:        "OVNListenAddressNotIP",
+			spec:        infrav1.LXCClusterSpec{LoadBalancer: infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0", ListenAddress: "lb.example.com"}}},
+			expectError: true,
+		},
+		{
+			name:        "RegistryMirrorPathTraversal",
+			spec:        infrav1.LXCClusterSpec{Registry: &infrav1.LXCClusterRegistry{Mirrors: []infrav1.LXCClusterRegistryMirror{{Registry: "../../etc"}}}},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			lxcCluster := &infrav1.LXCCluster{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default"}, Spec: tc.spec}
+			_, err := (&LXCClusterCustomValidator{}).ValidateCreate(context.Background(), lxcCluster)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestLXCClusterValidateUpdate(t *testing.T) {
+	// invalidLXCCluster is an existing LXCCluster that does not pass validation, e.g. because it was created before
+	// the validation was introduced.
+	invalidLXCCluster := func() *infrav1.LXCCluster {
+		return &infrav1.LXCCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default", Finalizers: []string{infrav1.ClusterFinalizer}},
+			Spec: infrav1.LXCClusterSpec{
+				LoadBalancer: infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0", ListenAddress: "lb.example.com"}},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		name        string
+		update      func(lxcCluster *infrav1.LXCCluster)
+		expectError bool
+	}{
+		{
+			name: "UnchangedInvalidField",
+			update: func(lxcCluster *infrav1.LXCCluster) {
+				lxcCluster.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{Host: "10.0.0.10", Port: 6443}
+				lxcCluster.Status.Ready = true
+			},
+		},
+		{
+			name: "RemoveFinalizerWhileDeleting",
+			update: func(lxcCluster *infrav1.LXCCluster) {
+				deletionTimestamp := metav1.Now()
+				lxcCluster.DeletionTimestamp = &deletionTimestamp
+				lxcCluster.Finalizers = nil
+				lxcCluster.Spec.MachineNaming = &infrav1.LXCClusterMachineNaming{Template: "{{ .Unknown }}"}
+			},
+		},
+		{
+			name: "ChangedInvalidField",
+			update: func(lxcCluster *infrav1.LXCCluster) {
+				lxcCluster.Spec.LoadBalancer.OVN.ListenAddress = "lb2.example.com"
+			},
+			expectError: true,
+		},
+		{
+			name: "FixInvalidField",
+			update: func(lxcCluster *infrav1.LXCCluster) {
+				lxcCluster.Spec.LoadBalancer.OVN.ListenAddress = "10.0.0.10"
+			},
+		},
+		{
+			name: "NewInvalidField",
+			update: func(lxcCluster *infrav1.LXCCluster) {
+				lxcCluster.Spec.Registry = &infrav1.LXCClusterRegistry{Mirrors: []infrav1.LXCClusterRegistryMirror{{Registry: "../../etc"}}}
+			},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			oldLXCCluster := invalidLXCCluster()
+			lxcCluster := oldLXCCluster.DeepCopy()
+			tc.update(lxcCluster)
+
+			_, err := (&LXCClusterCustomValidator{}).ValidateUpdate(context.Background(), oldLXCCluster, lxcCluster)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestLXCClusterValidateUpdateLoadBalancerHandover(t *testing.T) {
+	kubeVIP := infrav1.LXCClusterLoadBalancer{KubeVIP: &infrav1.LXCLoadBalancerKubeVIP{}}
+	lxc := infrav1.LXCClusterLoadBalancer{LXC: &infrav1.LXCLoadBalancerInstance{}}
+	ovn := infrav1.LXCClusterLoadBalancer{OVN: &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0"}}
+
+	for _, tc := range []struct {
+		name        string
+		from, to    infrav1.LXCClusterLoadBalancer
+		endpoint    string
+		expectError bool
+	}{
+		{name: "IP/KubeVIPToOVN", from: kubeVIP, to: ovn, endpoint: "10.0.0.10"},
+		{name: "IP/KubeVIPToLXC", from: kubeVIP, to: lxc, endpoint: "10.0.0.10", expectError: true},
+		{name: "DNS/KubeVIPToLXC", from: kubeVIP, to: lxc, endpoint: "c1.example.com"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			oldLXCCluster := &infrav1.LXCCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "default"},
+				Spec: infrav1.LXCClusterSpec{
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: tc.endpoint, Port: 6443},
+					LoadBalancer:         tc.from,
+				},
+			}
+			lxcCluster := oldLXCCluster.DeepCopy()
+			lxcCluster.Spec.LoadBalancer = tc.to
+
+			_, err := (&LXCClusterCustomValidator{}).ValidateUpdate(context.Background(), oldLXCCluster, lxcCluster)
+			if tc.expectError {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
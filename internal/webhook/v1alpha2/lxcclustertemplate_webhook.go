@@ -0,0 +1,78 @@
+/*
+Copyright 2024 Angelos Kolaitis.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+// SetupLXCClusterTemplateWebhookWithManager registers the webhook for LXCClusterTemplate in the manager.
+func SetupLXCClusterTemplateWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&infrav1.LXCClusterTemplate{}).
+		WithValidator(&LXCClusterTemplateCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-infrastructure-cluster-x-k8s-io-v1alpha2-lxcclustertemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=infrastructure.cluster.x-k8s.io,resources=lxcclustertemplates,verbs=create;update,versions=v1alpha2,name=validation.lxcclustertemplate.infrastructure.cluster.x-k8s.io,admissionReviewVersions=v1
+
+// LXCClusterTemplateCustomValidator validates LXCClusterTemplate resources when they are created or updated.
+type LXCClusterTemplateCustomValidator struct{}
+
+var _ webhook.CustomValidator = &LXCClusterTemplateCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator.
+func (v *LXCClusterTemplateCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	lxcClusterTemplate, ok := obj.(*infrav1.LXCClusterTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a LXCClusterTemplate object but got %T", obj)
+	}
+
+	return nil, toInvalidError("LXCClusterTemplate", lxcClusterTemplate.Name, validateLXCClusterSpec(nil, lxcClusterTemplate.Spec.Template.Spec, field.NewPath("spec", "template", "spec")))
+}
+
+// ValidateUpdate implements webhook.CustomValidator.
+func (v *LXCClusterTemplateCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldLXCClusterTemplate, ok := oldObj.(*infrav1.LXCClusterTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a LXCClusterTemplate object but got %T", oldObj)
+	}
+	lxcClusterTemplate, ok := newObj.(*infrav1.LXCClusterTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a LXCClusterTemplate object but got %T", newObj)
+	}
+
+	// Do not block objects that are being deleted, e.g. when a finalizer is removed.
+	if !lxcClusterTemplate.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	return nil, toInvalidError("LXCClusterTemplate", lxcClusterTemplate.Name, validateLXCClusterSpec(&oldLXCClusterTemplate.Spec.Template.Spec, lxcClusterTemplate.Spec.Template.Spec, field.NewPath("spec", "template", "spec")))
+}
+
+// ValidateDelete implements webhook.CustomValidator.
+func (v *LXCClusterTemplateCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
@@ -0,0 +1,59 @@
+package v1alpha2
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	infrav1 "github.com/lxc/cluster-api-provider-incus/api/v1alpha2"
+)
+
+func TestLXCClusterTemplateValidate(t *testing.T) {
+	newTemplate := func(listenAddress string) *infrav1.LXCClusterTemplate {
+		lxcClusterTemplate := &infrav1.LXCClusterTemplate{ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "default"}}
+		lxcClusterTemplate.Spec.Template.Spec.LoadBalancer.OVN = &infrav1.LXCLoadBalancerOVN{NetworkName: "ovn0", ListenAddress: listenAddress}
+		return lxcClusterTemplate
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := (&LXCClusterTemplateCustomValidator{}).ValidateCreate(context.Background(), newTemplate("10.0.0.10"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = (&LXCClusterTemplateCustomValidator{}).ValidateCreate(context.Background(), newTemplate("lb.example.com"))
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("UpdateUnchangedInvalidField", func(t *testing.T) {
+		g := NewWithT(t)
+
+		oldTemplate := newTemplate("lb.example.com")
+		lxcClusterTemplate := oldTemplate.DeepCopy()
+		lxcClusterTemplate.Labels = map[string]string{"example.com/owner": "team"}
+
+		_, err := (&LXCClusterTemplateCustomValidator{}).ValidateUpdate(context.Background(), oldTemplate, lxcClusterTemplate)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("UpdateChangedInvalidField", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := (&LXCClusterTemplateCustomValidator{}).ValidateUpdate(context.Background(), newTemplate("10.0.0.10"), newTemplate("lb.example.com"))
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("UpdateWhileDeleting", func(t *testing.T) {
+		g := NewWithT(t)
+
+		oldTemplate := newTemplate("10.0.0.10")
+		lxcClusterTemplate := newTemplate("lb.example.com")
+		deletionTimestamp := metav1.Now()
+		lxcClusterTemplate.DeletionTimestamp = &deletionTimestamp
+
+		_, err := (&LXCClusterTemplateCustomValidator{}).ValidateUpdate(context.Background(), oldTemplate, lxcClusterTemplate)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+}
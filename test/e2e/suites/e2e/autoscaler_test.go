@@ -14,7 +14,6 @@ import (
 )
 
 var _ = Describe("Autoscaler", func() {
-	// TODO: investigate why ScaleToAndFromZero is failing to scale back up from zero
 	e2e.AutoscalerSpec(context.TODO(), func() e2e.AutoscalerSpecInput {
 		return e2e.AutoscalerSpecInput{
 			E2EConfig:              e2eCtx.E2EConfig,
@@ -30,6 +29,7 @@ var _ = Describe("Autoscaler", func() {
 			InfrastructureMachineTemplateKind: "lxcmachinetemplates",
 			AutoscalerVersion:                 "v1.31.1",
 			InstallOnManagementCluster:        true,
+			ScaleToAndFromZero:                true,
 		}
 	})
 })